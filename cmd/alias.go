@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -29,6 +28,8 @@ var (
 
 	# Set an alias to a range, then get the range
 	> sheet alias set myrangealias myworkbook myworksheet!myrange
+	# Or straight from a link to a worksheet or range in the browser
+	> sheet alias set myrangealias 'https://docs.google.com/spreadsheets/d/SpReAdShEeTiD/edit#gid=0&range=A1:C4'
 	> sheet get @myrangealias
 
 	# Set an alias to a workbook, then get a range in a worksheet in that workbook
@@ -39,8 +40,8 @@ var (
 	> sheet alias set mydata myworkbook myworksheet
 	> sheet tail @mydata
 			`,
-		Run: func(cmd *cobra.Command, args []string) {
-			doAlias(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doAlias(cmd, args)
 		},
 	}
 )
@@ -51,67 +52,84 @@ func init() {
 	viper.BindPFlag("alias-spec-prefix", aliasCmd.PersistentFlags().Lookup("alias-spec-prefix"))
 }
 
-func doAlias(cmd *cobra.Command, args []string) {
+func doAlias(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		doAliasAll()
-		return
+		return doAliasAll(cmd)
 	}
 	switch args[0] {
 	case "get":
-		doAliasGet(cmd, args)
+		return doAliasGet(cmd, args)
 	case "set":
-		doAliasSet(cmd, args)
+		return doAliasSet(cmd, args)
 	case "rm":
-		doAliasRm(cmd, args)
+		return doAliasRm(cmd, args)
 	default:
-		log.Println("Unknown alias command", args[0])
 		cmd.Help()
+		return fmt.Errorf("unknown alias command: %v", args[0])
 	}
 }
 
-func doAliasAll() {
-	aliases := sheet.GetAllAliases()
-	for k := range aliases {
-		spec, err := sheet.GetAlias(k)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Printf("%v => %v\n", k, spec.String())
+func doAliasAll(cmd *cobra.Command) error {
+	aliases, err := sheet.GetAllAliases()
+	if err != nil {
+		return err
+	}
+	for k, spec := range aliases {
+		fmt.Fprintf(cmd.OutOrStdout(), "%v => %v\n", k, spec.String())
 	}
+	return nil
 }
 
-func doAliasGet(_ *cobra.Command, args []string) {
+func doAliasGet(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
-		doAliasAll()
-		return
+		return doAliasAll(cmd)
 	}
 	dataspec, err := sheet.GetAlias(args[1])
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	fmt.Printf("%v => (%v)\n", args[1], dataspec.String())
+	fmt.Fprintf(cmd.OutOrStdout(), "%v => (%v)\n", args[1], dataspec.String())
+	return nil
 }
 
-func doAliasSet(cmd *cobra.Command, args []string) {
+func doAliasSet(cmd *cobra.Command, args []string) error {
 	if len(args) < 3 || len(args) > 4 {
-		fmt.Println("alias set requires 2, 3 or 4 arguments")
 		cmd.Help()
-		return
+		return fmt.Errorf("alias set requires 2, 3 or 4 arguments")
 	}
-	spec, err := sheet.ExpandArgsToDataSpec(args[2:])
+	spec, err := expandAliasArgs(cmd, args[2:])
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if err := sheet.SetAlias(args[1], spec); err != nil {
+		return err
 	}
-	sheet.SetAlias(args[1], spec)
-	fmt.Printf("%v => (%v)\n", args[1], spec.String())
+	fmt.Fprintf(cmd.OutOrStdout(), "%v => (%v)\n", args[1], spec.String())
+	return nil
 }
 
-func doAliasRm(cmd *cobra.Command, args []string) {
+// expandAliasArgs expands the data spec for an alias. We only talk to the API if we need to look up
+// the worksheet for a link, so setting aliases doesn't usually need credentials.
+func expandAliasArgs(cmd *cobra.Command, args []string) (*sheet.DataSpec, error) {
+	if len(args) != 1 || !sheet.IsSpreadsheetURL(args[0]) {
+		return sheet.ExpandArgsToDataSpec(args)
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+	return sheet.ResolveArgsToDataSpec(ctx, b, args)
+}
+
+func doAliasRm(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		fmt.Println("alias rm requires 1 argument")
 		cmd.Help()
-		return
+		return fmt.Errorf("alias rm requires 1 argument")
+	}
+	if err := sheet.DeleteAlias(args[1]); err != nil {
+		return err
 	}
-	sheet.DeleteAlias(args[1])
-	fmt.Println("Deleting alias", args[1])
+	fmt.Fprintln(cmd.OutOrStdout(), "Deleting alias", args[1])
+	return nil
 }
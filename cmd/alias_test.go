@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doAliasSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    *sheet.DataSpec
+		wantErr bool
+	}{
+		{
+			name: "range",
+			args: []string{"set", "myrange", "wb", "people!A1:B2"},
+			want: &sheet.DataSpec{Workbook: "wb", Worksheet: "people", Range: sheet.MustRangeFromString("A1:B2")},
+		},
+		{
+			name: "urlrange",
+			args: []string{"set", "myrange", "https://docs.google.com/spreadsheets/d/wb/edit#gid=0&range=A1:C4"},
+			want: &sheet.DataSpec{Workbook: "wb", Worksheet: "people", Range: sheet.MustRangeFromString("A1:C4")},
+		},
+		{
+			name: "urlworkbook",
+			args: []string{"set", "mywb", "https://docs.google.com/spreadsheets/d/wb/edit"},
+			want: &sheet.DataSpec{Workbook: "wb"},
+		},
+		{
+			name:    "urlnosuchgid",
+			args:    []string{"set", "mywb", "https://docs.google.com/spreadsheets/d/wb/edit#gid=42"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakeBackend(t)
+
+			_, err := runCommand(doAlias, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doAlias() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := sheet.GetAlias(tt.args[1])
+			if err != nil {
+				t.Fatalf("GetAlias() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAlias() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doAliasSetNoBackend(t *testing.T) {
+	// Setting an alias to a workbook by ID shouldn't need to talk to the API.
+	setupFakeBackend(t)
+	oldBackend := newBackend
+	newBackend = func(context.Context) (sheet.Backend, error) { return nil, errors.New("no backend for you") }
+	t.Cleanup(func() { newBackend = oldBackend })
+
+	if _, err := runCommand(doAlias, []string{"set", "mywb", "wb"}, ""); err != nil {
+		t.Errorf("doAlias() error = %v", err)
+	}
+}
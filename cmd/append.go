@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// appendCmd represents the append command
+var appendCmd = &cobra.Command{
+	Use:   "append <data spec>",
+	Short: "Append data to a worksheet",
+	Long: `Append data from stdin after the last row of data in a worksheet or range.
+
+e.g.:
+
+# Add today's log lines to the end of a worksheet
+> sheet append @myworkbook logs < today.csv
+
+# Append within a table in a range (rows are added after the last row of the table)
+> sheet append @myworkbook 'logs!A:C' < today.csv
+
+Unlike 'sheet put', nothing is cleared first, and the input is streamed to the sheet
+in batches of --write-chunksize rows, so it's fine for large amounts of data.
+
+The range written by each batch is printed as it's written.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doAppend(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(appendCmd)
+}
+
+func doAppend(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
+
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+
+	if !spec.IsWorksheet() && !spec.IsRange() {
+		return fmt.Errorf("data spec must specify a worksheet or range: %v", args)
+	}
+
+	// Read from stdin in format specified by --input-format (or input-format config)
+	r, err := sheet.NewValueReader(cmd.InOrStdin(), inputFormat)
+
+	if err != nil {
+		return fmt.Errorf("unable to read data from stdin: %v", err)
+	}
+
+	for {
+		// --write-chunksize
+		data, err := r.ReadRows(writeChunkSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read data from stdin: %v", err)
+		}
+
+		updated, err := sheet.AppendData(ctx, b, spec, data, writeOptions())
+		if err != nil {
+			return fmt.Errorf("unable to append data: %v", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), updated)
+	}
+	return nil
+}
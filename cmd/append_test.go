@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_doAppend(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		stdin     string
+		chunksize int
+		want      string
+		wantOut   string
+		wantErr   bool
+	}{
+		{
+			name:      "worksheet",
+			args:      []string{"@people"},
+			stdin:     "dave,40\nerin,41\n",
+			chunksize: 500,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\ndave,40\nerin,41\n",
+			wantOut:   "people!A5:B6\n",
+		},
+		{
+			name:      "chunked",
+			args:      []string{"wb", "people"},
+			stdin:     "dave,40\nerin,41\nfrank,42\n",
+			chunksize: 2,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\ndave,40\nerin,41\nfrank,42\n",
+			wantOut:   "people!A5:B6\npeople!A7:B7\n",
+		},
+		{
+			name:      "noinput",
+			args:      []string{"@people"},
+			stdin:     "",
+			chunksize: 500,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name:      "workbook",
+			args:      []string{"@wb"},
+			stdin:     "dave,40\n",
+			chunksize: 500,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupFakeBackend(t)
+			oldChunkSize := writeChunkSize
+			writeChunkSize = tt.chunksize
+			t.Cleanup(func() { writeChunkSize = oldChunkSize })
+
+			out, err := runCommand(doAppend, tt.args, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doAppend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.wantOut {
+				t.Errorf("doAppend() printed %q, want %q", out, tt.wantOut)
+			}
+			if got := worksheetContents(t, m, "wb", "people"); got != tt.want {
+				t.Errorf("doAppend() left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
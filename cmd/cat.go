@@ -5,7 +5,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -18,56 +17,85 @@ var catCmd = &cobra.Command{
 	Long: `Data spec must specify a worksheet, i.e.:
 > sheet cat SpreAdSheeTiD myworksheet
 > sheet cat @myworkbook myworksheet
-> sheet cat @myworksheet `,
-	Run: func(cmd *cobra.Command, args []string) {
-		doCat(cmd, args)
+> sheet cat @myworksheet
+
+The first row is a header. With --columns, only those columns are output (by header or
+uppercase letter, in the order given), and with --where, only rows matching every condition are, e.g.:
+
+> sheet cat @myworksheet --columns=Name,Email --where 'Status=active' --where 'Age>30'
+
+Conditions are =, !=, <, <=, > or >=, and compare numbers as numbers and anything else as text.
+Rows are filtered a --read-chunksize chunk at a time, as they're read.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doCat(cmd, args)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(catCmd)
+	addFilterFlags(catCmd)
 }
 
-func doCat(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doCat(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	dataspec, err := sheet.ExpandArgsToDataSpec(args)
+	dataspec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
 	if !dataspec.IsWorksheet() {
-		log.Fatalf("data spec must specify a worksheet: %v", args)
+		return fmt.Errorf("data spec must specify a worksheet: %v", args)
+	}
+
+	filter, err := rowFilter()
+	if err != nil {
+		return err
 	}
 
+	w := sheet.NewValueWriter(cmd.OutOrStdout(), outputFormat, jsonKeys)
+
 	start := 1
 	// --read-chunksize
 	end := readChunkSize
-	chunkspec := fmt.Sprintf("%v!%v:%v", dataspec.Worksheet, start, end)
-
-	resp, err := srv.Spreadsheets.Values.Get(dataspec.Workbook, chunkspec).Do()
-	if err != nil {
-		log.Fatalf("Unable to retrieve data from sheet: %v", err)
-	}
 
 	for {
-		sheet.PrintValues(resp, outputFormat)
+		chunkspec := worksheetRows(dataspec.Worksheet, start, end)
+		resp, err := b.GetValues(ctx, dataspec.Workbook, chunkspec, filterReadOptions())
+		if err != nil {
+			return err
+		}
+
+		full := len(resp.Values) == readChunkSize
+		if filter != nil {
+			if resp.Values, err = filter.Filter(resp.Values); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Write(resp); err != nil {
+			return err
+		}
 
-		if len(resp.Values) < readChunkSize {
+		if !full {
 			break
 		}
 
 		start = 1 + end
 		end = start + (readChunkSize - 1)
-		chunkspec = fmt.Sprintf("%v!%v:%v", dataspec.Worksheet, start, end)
-		resp, err = srv.Spreadsheets.Values.Get(dataspec.Workbook, chunkspec).Do()
-		if err != nil {
-			log.Fatalf("Unable to retrieve data from sheet: %v", err)
-		}
 	}
+
+	return w.Close()
+}
+
+// worksheetRows returns the A1 notation for rows first to last of a worksheet, quoting the worksheet if need be.
+func worksheetRows(worksheet string, first, last int) string {
+	spec := sheet.DataSpec{Worksheet: worksheet, Range: sheet.DataRange{StartRow: first, EndRow: last}}
+	return spec.GetInSheetDataSpec()
 }
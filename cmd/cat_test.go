@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doCat(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		chunksize int
+		columns   []string
+		where     []string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "onechunk",
+			args:      []string{"@people"},
+			chunksize: 500,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name:      "severalchunks",
+			args:      []string{"wb", "people"},
+			chunksize: 2,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name:      "exactchunks",
+			args:      []string{"wb", "people"},
+			chunksize: 4,
+			want:      "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name:      "filtered",
+			args:      []string{"@people"},
+			chunksize: 2,
+			columns:   []string{"name"},
+			where:     []string{"age>25"},
+			want:      "name\nalice\ncarol\n",
+		},
+		{
+			name:      "filteredrow",
+			args:      []string{"@people"},
+			chunksize: 2,
+			where:     []string{"name=bob"},
+			want:      "name,age\nbob,25\n",
+		},
+		{
+			name:      "nosuchcolumn",
+			args:      []string{"@people"},
+			chunksize: 2,
+			where:     []string{"email=x"},
+			wantErr:   true,
+		},
+		{
+			name:      "range",
+			args:      []string{"wb", "people!A1:B2"},
+			chunksize: 500,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakeBackend(t)
+			oldChunkSize := readChunkSize
+			readChunkSize = tt.chunksize
+			filterColumns = tt.columns
+			filterWhere = tt.where
+			t.Cleanup(func() {
+				readChunkSize = oldChunkSize
+				filterColumns = nil
+				filterWhere = nil
+			})
+
+			got, err := runCommand(doCat, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doCat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doCat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doCatCancelled(t *testing.T) {
+	setupFakeBackend(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := runCommandContext(ctx, doCat, []string{"@people"}, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("doCat() error = %v, want context.Canceled", err)
+	}
+	if got != "" {
+		t.Errorf("doCat() = %q, want nothing", got)
+	}
+}
+
+func Test_doCatQuotedWorksheet(t *testing.T) {
+	b := setupFakeBackend(t)
+	title := addAwkwardWorksheet(t, b)
+	oldChunkSize := readChunkSize
+	readChunkSize = 2
+	t.Cleanup(func() { readChunkSize = oldChunkSize })
+
+	got, err := runCommand(doCat, []string{"wb", sheet.QuoteWorksheet(title)}, "")
+	if err != nil {
+		t.Fatalf("doCat() error = %v", err)
+	}
+	if want := "name,age\nalice,30\nbob,25\ncarol,35\n"; got != want {
+		t.Errorf("doCat() = %q, want %q", got, want)
+	}
+}
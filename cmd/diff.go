@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var (
+	diffKey string
+	diffCmd = &cobra.Command{
+		Use:   "diff <data spec> <data spec|file>",
+		Short: "Show the differences between two worksheets or ranges, or a worksheet or range and a file",
+		Long: `Compare two worksheets or ranges (or one and a local file, or - for stdin) cell by cell,
+and output the changes needed to make the first look like the second, i.e.:
+
+	# Compare two worksheets in a workbook
+	> sheet diff @myworkbook thisweek lastweek
+
+	# ...or in different workbooks (a second workbook must be an alias or a link)
+	> sheet diff @myworkbook 'data!A1:F20' @otherworkbook 'data!A1:F20'
+
+	# Compare a worksheet with a local file, read in --input-format
+	> sheet diff @mysheet people.csv
+
+Each changed cell is output with its address in the first worksheet or range, as:
+
+	B3: "30" → "31"
+
+With --key, the first row is a header, and rows are matched by their value in the column with that
+header, so reordered rows aren't differences (rows with a blank key, like blank rows, are skipped).
+Rows whose key is only on one side are output as:
+
+	- bob (row 4): "bob","25"
+	+ dave (row 5): "dave","40"
+
+With --output-format=json, the differences are output as a json object, with "changed", "added" and
+"removed" lists. The "changed" list can be given to 'sheet patch'.
+
+If the last argument is the name of a file that exists, it's read as a file, rather than a worksheet.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doDiff(cmd, args)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.PersistentFlags().StringVar(&diffKey, "key", "", "Match rows by the value in the column with this header, rather than by position")
+}
+
+// isDiffFile returns true if arg refers to a local file (or stdin) rather than a worksheet.
+func isDiffFile(arg string) bool {
+	if arg == "-" {
+		return true
+	}
+	prefix := sheet.DefaultClient().AliasPrefix
+	if prefix == "" {
+		prefix = "@"
+	}
+	if strings.HasPrefix(arg, prefix) || sheet.IsSpreadsheetURL(arg) {
+		return false
+	}
+	fi, err := os.Stat(arg)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+func doDiff(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		cmd.Help()
+		return fmt.Errorf("diff requires two things to compare")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+
+	file := ""
+	if isDiffFile(args[len(args)-1]) {
+		file = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	specs, err := sheet.ResolveArgsToDataSpecs(ctx, b, args)
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	want := 2
+	if file != "" {
+		want = 1
+	}
+	if len(specs) != want {
+		return fmt.Errorf("diff requires two worksheets or ranges (or one and a file), not %v", args)
+	}
+	for _, spec := range specs {
+		if spec.IsWorkbook() {
+			return fmt.Errorf("data spec must specify a worksheet or range, not a workbook: %v", spec.String())
+		}
+	}
+
+	opts := &sheet.DiffOptions{Key: diffKey, ARange: specs[0].Range}
+	a, err := diffValues(ctx, b, specs[0])
+	if err != nil {
+		return err
+	}
+
+	var other [][]string
+	if file != "" {
+		other, err = diffFileValues(cmd, file)
+	} else {
+		opts.BRange = specs[1].Range
+		other, err = diffValues(ctx, b, specs[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	diff, err := sheet.DiffValues(a, other, opts)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == sheet.JsonFormat || outputFormat == sheet.NdjsonFormat {
+		out, err := json.Marshal(diff)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return err
+	}
+	return sheet.WriteDiff(cmd.OutOrStdout(), diff)
+}
+
+func diffValues(ctx context.Context, b sheet.Backend, spec *sheet.DataSpec) ([][]string, error) {
+	resp, err := b.GetValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), readOptions())
+	if err != nil {
+		return nil, err
+	}
+	return sheet.StringsFromValueRange(resp), nil
+}
+
+func diffFileValues(cmd *cobra.Command, file string) ([][]string, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	// In format specified by --input-format (or input-format config)
+	data, err := sheet.ScanValues(bufio.NewReader(r), inputFormat)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read data from %v: %v", file, err)
+	}
+	return data, nil
+}
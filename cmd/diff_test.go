@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		file    string
+		stdin   string
+		key     string
+		format  sheet.DataFormat
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "worksheets",
+			args: []string{"wb", "people", "other"},
+			want: "B2: \"30\" → \"31\"\nA4: \"carol\" → \"\"\nB4: \"35\" → \"\"\n",
+		},
+		{
+			name: "ranges",
+			args: []string{"wb", "people!A2:B2", "other!A2:B2"},
+			want: "B2: \"30\" → \"31\"\n",
+		},
+		{
+			name: "same",
+			args: []string{"@people", "people"},
+			want: "",
+		},
+		{
+			name: "keyed",
+			args: []string{"wb", "people", "reordered"},
+			key:  "name",
+			want: "B3: \"25\" → \"26\"\n- alice (row 2): \"alice\",\"30\"\n+ dave (row 3): \"dave\",\"40\"\n",
+		},
+		{
+			name: "file",
+			args: []string{"wb", "people!A1:B2"},
+			file: "name,age\nalice,29\n",
+			want: "B2: \"30\" → \"29\"\n",
+		},
+		{
+			name:  "stdin",
+			args:  []string{"@people!A3:B3", "-"},
+			stdin: "bob,25\n",
+			want:  "",
+		},
+		{
+			name:   "json",
+			args:   []string{"wb", "people", "reordered"},
+			key:    "name",
+			format: sheet.JsonFormat,
+			want:   `{"changed":[{"cell":"B3","old":"25","new":"26"}],"added":[{"key":"dave","row":3,"values":["dave","40"]}],"removed":[{"key":"alice","row":2,"values":["alice","30"]}]}` + "\n",
+		},
+		{
+			name:    "onlyone",
+			args:    []string{"wb", "people"},
+			wantErr: true,
+		},
+		{
+			name:    "three",
+			args:    []string{"wb", "people", "other", "reordered"},
+			wantErr: true,
+		},
+		{
+			name:    "workbook",
+			args:    []string{"wb", "@wb"},
+			wantErr: true,
+		},
+		{
+			name:    "nosuchworksheet",
+			args:    []string{"wb", "people", "nope"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := setupFakeBackend(t)
+			b.SetValues("wb", "other", [][]interface{}{{"name", "age"}, {"alice", 31}, {"bob", 25}})
+			b.SetValues("wb", "reordered", [][]interface{}{{"name", "age"}, {"carol", 35}, {"dave", 40}, {"bob", 26}})
+			diffKey = tt.key
+			outputFormat = sheet.CsvFormat
+			if tt.format != "" {
+				outputFormat = tt.format
+			}
+			t.Cleanup(func() {
+				diffKey = ""
+				outputFormat = sheet.CsvFormat
+			})
+
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "data.csv")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, path)
+			}
+
+			got, err := runCommand(doDiff, args, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	sheet "github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -17,7 +16,7 @@ var (
 			}
 			return nil
 		},
-		Use:   "get <data spec>",
+		Use:   "get <data spec> [more worksheets or ranges...]",
 		Short: "get a range of data from a sheet",
 		Long: `Get data given a spreadsheet ID and a range specifier.
 For example:
@@ -29,38 +28,98 @@ Or, get data based on aliases ('help alias'):
 	> sheet get @mysheet worksheet!A1:B100
 	> sheet get @myfavouriterange
 
+Several worksheets and ranges can be fetched at once, in one request per workbook.
+Each is output after a '==> range <==' header, or as a json object keyed by range
+(with --output-format=ndjson, each row is {"range": ..., "row": ...}):
+
+	> sheet get @mysheet 'people!A1:B10' 'places!A1:C5' =Totals
+	> sheet get @mysheet people @myotherworkbook Sheet1
+
+A second workbook must be given as an alias or a link.
+
+With --columns and --where, the first row of each worksheet or range is a header, and only
+the given columns (by header or uppercase letter), of rows matching every condition, are output:
+
+	> sheet get @mysheet people --columns=Name,Email --where 'Status=active' --where 'Age>30'
+
+See 'sheet help cat' for the conditions.
 `,
-		Run: func(cmd *cobra.Command, args []string) {
-			doGet(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doGet(cmd, args)
 		},
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(getCmd)
+	addFilterFlags(getCmd)
 }
 
-func doGet(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doGet(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	dataspec, err := sheet.ExpandArgsToDataSpec(args)
+	specs, err := sheet.ResolveArgsToDataSpecs(ctx, b, args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
-	if dataspec.IsWorkbook() {
-		log.Fatalf("get command requires a data spec that is a worksheet or range, not a workbook")
+	for _, spec := range specs {
+		if spec.IsWorkbook() {
+			return fmt.Errorf("get command requires a data spec that is a worksheet or range, not a workbook")
+		}
 	}
 
-	resp, err := srv.Spreadsheets.Values.Get(dataspec.Workbook, dataspec.GetInSheetDataSpec()).Do()
+	filter, err := rowFilter()
 	if err != nil {
-		log.Fatalf("Unable to retrieve data from sheet: %v", err)
+		return err
+	}
+
+	if len(specs) == 1 {
+		resp, err := b.GetValues(ctx, specs[0].Workbook, specs[0].GetInSheetDataSpec(), filterReadOptions())
+		if err != nil {
+			return err
+		}
+		if filter != nil {
+			filter.SetStartColumn(specs[0].Range.StartCol)
+			if resp.Values, err = filter.Filter(resp.Values); err != nil {
+				return err
+			}
+		}
+
+		w := sheet.NewValueWriter(cmd.OutOrStdout(), outputFormat, jsonKeys)
+		if err := w.Write(resp); err != nil {
+			return err
+		}
+		return w.Close()
 	}
 
-	sheet.PrintValues(resp, outputFormat)
+	resp, err := sheet.BatchGetValues(ctx, b, specs, filterReadOptions())
+	if err != nil {
+		return err
+	}
+	// Label each section as it was asked for, rather than as the API normalises it.
+	workbooks := map[string]bool{}
+	for _, spec := range specs {
+		workbooks[spec.Workbook] = true
+	}
+	for i, spec := range specs {
+		resp[i].Range = spec.GetInSheetDataSpec()
+		if len(workbooks) > 1 {
+			resp[i].Range = spec.Workbook + "/" + resp[i].Range
+		}
+		if filter != nil {
+			filter.Reset()
+			filter.SetStartColumn(spec.Range.StartCol)
+			if resp[i].Values, err = filter.Filter(resp[i].Values); err != nil {
+				return fmt.Errorf("%v: %v", resp[i].Range, err)
+			}
+		}
+	}
+	return sheet.WriteValueRanges(cmd.OutOrStdout(), resp, outputFormat, jsonKeys)
 }
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		format  sheet.DataFormat
+		keys    bool
+		columns []string
+		where   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "worksheet",
+			args: []string{"wb", "people"},
+			want: "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name: "range",
+			args: []string{"wb", "people!A2:B3"},
+			want: "alice,30\nbob,25\n",
+		},
+		{
+			name: "alias",
+			args: []string{"@people"},
+			want: "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name: "aliasrange",
+			args: []string{"@people!B1:B2"},
+			want: "age\n30\n",
+		},
+		{
+			name:   "keyedjson",
+			args:   []string{"@people!A1:B2"},
+			format: sheet.NdjsonFormat,
+			keys:   true,
+			want:   "{\"name\":\"alice\",\"age\":\"30\"}\n",
+		},
+		{
+			name: "url",
+			args: []string{"https://docs.google.com/spreadsheets/d/wb/edit#gid=0&range=A3:B4"},
+			want: "bob,25\ncarol,35\n",
+		},
+		{
+			name: "urlworksheet",
+			args: []string{"https://docs.google.com/spreadsheets/d/wb/edit#gid=0"},
+			want: "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name: "urlandworksheet",
+			args: []string{"https://docs.google.com/spreadsheets/d/wb/edit#gid=0", "people!A2:B2"},
+			want: "alice,30\n",
+		},
+		{
+			name:    "urlnosuchgid",
+			args:    []string{"https://docs.google.com/spreadsheets/d/wb/edit#gid=42"},
+			wantErr: true,
+		},
+		{
+			name: "multiple",
+			args: []string{"wb", "people!A1:B2", "people!A4:B4"},
+			want: "==> people!A1:B2 <==\nname,age\nalice,30\n\n==> people!A4:B4 <==\ncarol,35\n",
+		},
+		{
+			name: "multiplealiases",
+			args: []string{"@people!A2:A2", "@people!B3:B3"},
+			want: "==> people!A2 <==\nalice\n\n==> people!B3 <==\n25\n",
+		},
+		{
+			name:   "multiplejson",
+			args:   []string{"wb", "people!A1:B2", "people!A4:B4"},
+			format: sheet.JsonFormat,
+			want:   "{\n\"people!A1:B2\": [\n[\"name\",\"age\"],\n[\"alice\",\"30\"]\n],\n\"people!A4:B4\": [\n[\"carol\",\"35\"]\n]\n}\n",
+		},
+		{
+			name:   "multiplekeyedndjson",
+			args:   []string{"wb", "people!A1:B2", "people!A1:B1"},
+			format: sheet.NdjsonFormat,
+			keys:   true,
+			want:   "{\"range\":\"people!A1:B2\",\"row\":{\"name\":\"alice\",\"age\":\"30\"}}\n",
+		},
+		{
+			name:    "columns",
+			args:    []string{"@people"},
+			columns: []string{"age", "A"},
+			want:    "age,name\n30,alice\n25,bob\n35,carol\n",
+		},
+		{
+			name:  "where",
+			args:  []string{"@people"},
+			where: []string{"age>=30", "name!=carol"},
+			want:  "name,age\nalice,30\n",
+		},
+		{
+			name:    "multiplefiltered",
+			args:    []string{"wb", "people!A1:B2", "people"},
+			columns: []string{"age"},
+			where:   []string{"age<30"},
+			want:    "==> people!A1:B2 <==\nage\n\n==> people <==\nage\n25\n",
+		},
+		{
+			name:    "columnletteroffset",
+			args:    []string{"wb", "people!B1:B4"},
+			columns: []string{"B"},
+			where:   []string{"B>25"},
+			want:    "age\n30\n35\n",
+		},
+		{
+			name:    "nosuchcolumn",
+			args:    []string{"@people"},
+			columns: []string{"email"},
+			wantErr: true,
+		},
+		{
+			name:    "badwhere",
+			args:    []string{"@people"},
+			where:   []string{"age"},
+			wantErr: true,
+		},
+		{
+			name:    "multipleworkbook",
+			args:    []string{"wb", "people", "@wb"},
+			wantErr: true,
+		},
+		{
+			name:    "multiplenosuchworksheet",
+			args:    []string{"wb", "people", "nope"},
+			wantErr: true,
+		},
+		{
+			name:    "workbook",
+			args:    []string{"wb"},
+			wantErr: true,
+		},
+		{
+			name:    "nosuchworksheet",
+			args:    []string{"wb", "nope"},
+			wantErr: true,
+		},
+		{
+			name:    "nosuchalias",
+			args:    []string{"@nope"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakeBackend(t)
+			outputFormat = sheet.CsvFormat
+			if tt.format != "" {
+				outputFormat = tt.format
+			}
+			jsonKeys = tt.keys
+			filterColumns = tt.columns
+			filterWhere = tt.where
+			t.Cleanup(func() {
+				outputFormat = sheet.CsvFormat
+				jsonKeys = false
+				filterColumns = nil
+				filterWhere = nil
+			})
+
+			got, err := runCommand(doGet, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doGet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doGet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
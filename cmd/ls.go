@@ -5,7 +5,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -22,8 +21,8 @@ var lsCmd = &cobra.Command{
 	},
 	Use:   "ls <spreadsheet ID/alias>",
 	Short: "List worksheets in the sheet.",
-	Run: func(cmd *cobra.Command, args []string) {
-		doLs(cmd, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doLs(cmd, args)
 	},
 }
 
@@ -31,29 +30,31 @@ func init() {
 	rootCmd.AddCommand(lsCmd)
 }
 
-func doLs(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doLs(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
 	dataspec, err := sheet.ExpandArgsToDataSpec(args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
 	if !dataspec.IsWorkbook() {
-		log.Fatalf("data spec must specify a workbook: %v", args)
+		return fmt.Errorf("data spec must specify a workbook: %v", args)
 	}
 
-	resp, err := srv.Spreadsheets.Get(dataspec.Workbook).Do()
+	resp, err := b.GetWorkbook(ctx, dataspec.Workbook)
 	if err != nil {
-		log.Fatalf("Unable to retrieve sheet Id %v: %v", args[0], err)
+		return fmt.Errorf("unable to retrieve sheet Id %v: %v", args[0], err)
 	}
 
 	for _, sheet := range resp.Sheets {
-		fmt.Println(sheet.Properties.Title)
+		fmt.Fprintln(cmd.OutOrStdout(), sheet.Properties.Title)
 	}
+	return nil
 }
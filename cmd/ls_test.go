@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_doLs(t *testing.T) {
+	m := setupFakeBackend(t)
+	m.AddWorkbook("wb2", "first", "second")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "alias",
+			args: []string{"@wb"},
+			want: "people\n",
+		},
+		{
+			name: "id",
+			args: []string{"wb2"},
+			want: "first\nsecond\n",
+		},
+		{
+			name:    "worksheetalias",
+			args:    []string{"@people"},
+			wantErr: true,
+		},
+		{
+			name:    "nosuchworkbook",
+			args:    []string{"nope"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runCommand(doLs, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doLs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// namedrangeCmd represents the namedrange command
+var namedrangeCmd = &cobra.Command{
+	Use:   "namedrange [ls|set|rm] ...",
+	Short: "Manipulate named ranges in a workbook",
+	Long: `List, set or delete the named ranges kept in a workbook (as in Data > Named ranges).
+	Unlike aliases, these are shared with everyone using the workbook.
+
+	Named ranges can be used with other commands as =name in place of a worksheet or range.
+
+	i.e.:
+
+	# List the named ranges in a workbook
+	> sheet namedrange ls myworkbook
+
+	# Name a range, then get it
+	> sheet namedrange set Totals myworkbook 'Summary!A1:D10'
+	> sheet get myworkbook =Totals
+
+	# Delete a named range (the data in it stays)
+	> sheet namedrange rm Totals myworkbook
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doNamedRange(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(namedrangeCmd)
+}
+
+func doNamedRange(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		cmd.Help()
+		return fmt.Errorf("namedrange requires a command: ls, set or rm")
+	}
+	switch args[0] {
+	case "ls":
+		return doNamedRangeLs(cmd, args)
+	case "set":
+		return doNamedRangeSet(cmd, args)
+	case "rm":
+		return doNamedRangeRm(cmd, args)
+	default:
+		cmd.Help()
+		return fmt.Errorf("unknown namedrange command: %v", args[0])
+	}
+}
+
+func doNamedRangeLs(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		cmd.Help()
+		return fmt.Errorf("namedrange ls requires a workbook")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+	spec, err := sheet.ExpandArgsToDataSpec(args[1:])
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	if !spec.IsWorkbook() {
+		return fmt.Errorf("data spec must specify a workbook: %v", args[1:])
+	}
+
+	ranges, err := sheet.GetNamedRanges(ctx, b, spec.Workbook)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for name := range ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(cmd.OutOrStdout(), "%v => %v\n", name, ranges[name].GetInSheetDataSpec())
+	}
+	return nil
+}
+
+func doNamedRangeSet(cmd *cobra.Command, args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		cmd.Help()
+		return fmt.Errorf("namedrange set requires a name and a worksheet or range")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args[2:])
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	if err := sheet.SetNamedRange(ctx, b, args[1], spec); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%v => %v\n", args[1], spec.GetInSheetDataSpec())
+	return nil
+}
+
+func doNamedRangeRm(cmd *cobra.Command, args []string) error {
+	if len(args) != 3 {
+		cmd.Help()
+		return fmt.Errorf("namedrange rm requires a name and a workbook")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+	spec, err := sheet.ExpandArgsToDataSpec(args[2:])
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	if !spec.IsWorkbook() {
+		return fmt.Errorf("data spec must specify a workbook: %v", args[2:])
+	}
+	if err := sheet.DeleteNamedRange(ctx, b, spec.Workbook, args[1]); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Deleting named range", args[1])
+	return nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doNamedRange(t *testing.T) {
+	b := setupFakeBackend(t)
+
+	steps := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", args: []string{"ls", "wb"}, want: ""},
+		{name: "set", args: []string{"set", "Ages", "wb", "people!B2:B4"}, want: "Ages => people!B2:B4\n"},
+		{name: "setviaalias", args: []string{"set", "Everyone", "@people"}, want: "Everyone => people\n"},
+		{name: "ls", args: []string{"ls", "@wb"}, want: "Ages => people!B2:B4\nEveryone => people\n"},
+		{name: "move", args: []string{"set", "Ages", "wb", "people!B1:B4"}, want: "Ages => people!B1:B4\n"},
+		{name: "badname", args: []string{"set", "A1", "wb", "people!B1:B4"}, wantErr: true},
+		{name: "notarange", args: []string{"set", "Book", "wb"}, wantErr: true},
+		{name: "rm", args: []string{"rm", "Everyone", "wb"}, want: "Deleting named range Everyone\n"},
+		{name: "rmagain", args: []string{"rm", "Everyone", "wb"}, wantErr: true},
+		{name: "lsafterrm", args: []string{"ls", "wb"}, want: "Ages => people!B1:B4\n"},
+		{name: "lsworksheet", args: []string{"ls", "wb", "people"}, wantErr: true},
+		{name: "nocommand", args: []string{}, wantErr: true},
+		{name: "unknowncommand", args: []string{"frob"}, wantErr: true},
+	}
+	for _, tt := range steps {
+		got, err := runCommand(doNamedRange, tt.args, "")
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%v: doNamedRange() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want && !tt.wantErr {
+			t.Errorf("%v: doNamedRange() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Everything's in the workbook for others to see.
+	ranges, err := sheet.GetNamedRanges(context.Background(), b, "wb")
+	if err != nil {
+		t.Fatalf("GetNamedRanges() error = %v", err)
+	}
+	if len(ranges) != 1 || ranges["Ages"] == nil {
+		t.Errorf("GetNamedRanges() = %v, want just Ages", ranges)
+	}
+}
+
+func Test_namedRangeInCommands(t *testing.T) {
+	b := setupFakeBackend(t)
+	spec := &sheet.DataSpec{Workbook: "wb", Worksheet: "people", Range: sheet.MustRangeFromString("A2:B3")}
+	if err := sheet.SetNamedRange(context.Background(), b, "Some", spec); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+
+	got, err := runCommand(doGet, []string{"wb", "=Some"}, "")
+	if err != nil {
+		t.Fatalf("doGet() error = %v", err)
+	}
+	if want := "alice,30\nbob,25\n"; got != want {
+		t.Errorf("doGet() = %q, want %q", got, want)
+	}
+
+	if _, err := runCommand(doPut, []string{"@wb", "=Some"}, "dave,40\nerin,45\n"); err != nil {
+		t.Fatalf("doPut() error = %v", err)
+	}
+	if got, want := worksheetContents(t, b, "wb", "people"), "name,age\ndave,40\nerin,45\ncarol,35\n"; got != want {
+		t.Errorf("worksheet after doPut() = %q, want %q", got, want)
+	}
+
+	if _, err := runCommand(doGet, []string{"wb", "=Nope"}, ""); err == nil {
+		t.Errorf("doGet() of a missing named range error = nil, want an error")
+	}
+}
+
+func Test_namedRangeWholeWorksheet(t *testing.T) {
+	b := setupFakeBackend(t)
+	spec := &sheet.DataSpec{Workbook: "wb", Worksheet: "people"}
+	if err := sheet.SetNamedRange(context.Background(), b, "Everyone", spec); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+
+	// Writing to it only writes its cells, rather than replacing the worksheet.
+	if _, err := runCommand(doPut, []string{"wb", "=Everyone"}, "name,age\ndave,40\n"); err != nil {
+		t.Fatalf("doPut() error = %v", err)
+	}
+	if got, want := worksheetContents(t, b, "wb", "people"), "name,age\ndave,40\n"; got != want {
+		t.Errorf("worksheet after doPut() = %q, want %q", got, want)
+	}
+
+	// Removing it clears its cells, and never deletes the worksheet.
+	if _, err := runCommand(doRm, []string{"wb", "=Everyone"}, ""); err != nil {
+		t.Fatalf("doRm() error = %v", err)
+	}
+	if got := worksheetTitles(t, b, "wb"); len(got) != 1 || got[0] != "people" {
+		t.Errorf("worksheets after doRm() = %v, want [people]", got)
+	}
+	if got := worksheetContents(t, b, "wb", "people"); got != "" {
+		t.Errorf("worksheet after doRm() = %q, want it empty", got)
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// patchCmd represents the patch command
+var (
+	patchVerify bool
+	patchCmd    = &cobra.Command{
+		Use:   "patch <data spec>",
+		Short: "Change individual cells, or rows by key, from a json patch on stdin",
+		Long: `Apply a json patch from stdin to a worksheet or range, writing only the cells that change,
+in a single request. The patch is one of:
+
+	# Cells and their new values
+	{"B3": "31", "C4": 2}
+
+	# Cell changes, optionally with the value each cell should have now
+	[{"cell": "B3", "old": "30", "new": "31"}]
+
+	# Rows to update or insert, matched by their value in the column with the "key" header
+	{"key": "name", "rows": [{"name": "bob", "age": 26}, {"name": "dave", "age": 40}]}
+
+e.g.:
+
+	> sheet patch @myworkbook people < changes.json
+
+	# Apply the differences between two worksheets
+	> sheet diff --output-format=json @myworkbook people people_new | sheet patch --verify @myworkbook people
+
+Cell addresses are in the worksheet, even when patching a range, and must be inside the range.
+
+With --verify, cells with an "old" value are read first, and nothing is written if any have changed.
+
+Rows are only patched in worksheets, whose first row is a header. Only the columns given for each row
+are changed, and rows with new keys are added after the last row.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doPatch(cmd, args)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+	patchCmd.PersistentFlags().BoolVar(&patchVerify, "verify", false, "Check that cells still have their \"old\" values before writing anything")
+}
+
+func doPatch(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	if spec.IsWorkbook() {
+		return fmt.Errorf("data spec must specify a worksheet or range, not a workbook: %v", spec.String())
+	}
+
+	patch, err := sheet.ParsePatch(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	opts := &sheet.PatchOptions{WriteOptions: *writeOptions(), Verify: patchVerify, ReadOptions: readOptions()}
+	if patch.Key != "" {
+		// Rows are compared with what's there to see what's changed, so compare numbers as numbers.
+		opts.ReadOptions = compareReadOptions()
+		res, err := sheet.PatchRows(ctx, b, spec, patch.Key, patch.Rows, opts)
+		if err != nil {
+			return fmt.Errorf("unable to patch rows: %v", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "updated %d rows, inserted %d rows\n", res.Updated, res.Inserted)
+		return nil
+	}
+
+	if err := sheet.PatchCells(ctx, b, spec, patch.Cells, opts); err != nil {
+		return fmt.Errorf("unable to patch cells: %v", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "patched %d cells\n", len(patch.Cells))
+	return nil
+}
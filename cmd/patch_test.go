@@ -0,0 +1,78 @@
+package cmd
+
+import "testing"
+
+func Test_doPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		verify  bool
+		want    string
+		wantWs  string
+		wantErr bool
+	}{
+		{
+			name:   "cells",
+			args:   []string{"@people"},
+			stdin:  `{"B2": 31, "A4": "carole"}`,
+			want:   "patched 2 cells\n",
+			wantWs: "name,age\nalice,31\nbob,25\ncarole,35\n",
+		},
+		{
+			name:   "verified",
+			args:   []string{"wb", "people!A2:B4"},
+			stdin:  `[{"cell": "B3", "old": "25", "new": "26"}]`,
+			verify: true,
+			want:   "patched 1 cells\n",
+			wantWs: "name,age\nalice,30\nbob,26\ncarol,35\n",
+		},
+		{
+			name:    "conflict",
+			args:    []string{"@people"},
+			stdin:   `[{"cell": "B3", "old": "24", "new": "26"}]`,
+			verify:  true,
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:   "rows",
+			args:   []string{"@people"},
+			stdin:  `{"key": "name", "rows": [{"name": "bob", "age": 26}, {"name": "dave", "age": 40}]}`,
+			want:   "updated 1 rows, inserted 1 rows\n",
+			wantWs: "name,age\nalice,30\nbob,26\ncarol,35\ndave,40\n",
+		},
+		{
+			name:    "workbook",
+			args:    []string{"@wb"},
+			stdin:   `{"A1": "x"}`,
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "badpatch",
+			args:    []string{"@people"},
+			stdin:   `A1,x`,
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := setupFakeBackend(t)
+			patchVerify = tt.verify
+			t.Cleanup(func() { patchVerify = false })
+
+			got, err := runCommand(doPatch, tt.args, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doPatch() = %q, want %q", got, tt.want)
+			}
+			if ws := worksheetContents(t, b, "wb", "people"); ws != tt.wantWs {
+				t.Errorf("after doPatch() worksheet = %q, want %q", ws, tt.wantWs)
+			}
+		})
+	}
+}
@@ -2,17 +2,22 @@ package cmd
 
 import (
 	"bufio"
-	"log"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // putCmd represents the put command
 var (
-	forcePut bool
-	putCmd   = &cobra.Command{
+	forcePut          bool
+	putBatchFile      string
+	putCheckpointFile string
+	putCmd            = &cobra.Command{
 		Use:   "put",
 		Short: "Write data to gsheets",
 		Long: `Write data from stdin to a range or worksheet.
@@ -33,11 +38,35 @@ When writing to worksheet, the worksheet will be cleared first.
 When writing to a range, the range size must match the size of the data being written. 
  - If the range is larger, the extra cells will be cleared. If the range is smaller, the write will fail.
 
- Note: 'sheet put' reads the entire input into memory before writing to the sheet, since we're writing to a fixed-size range.
-  - If you are writing large amounts of data, consider using 'sheet append' instead.
+When writing to a worksheet, data is written --write-chunksize rows at a time, growing the worksheet as needed.
+ - With --checkpoint, progress is saved to a file after each chunk. If the put is interrupted, running it again
+   with the same input and checkpoint file carries on where it left off.
+
+ Note: when writing to a range, 'sheet put' reads the entire input into memory first, since it must fit in the range.
+
+With --batch, several ranges are written in one request, as listed in a yaml manifest:
+
+# Defaults for the writes below.
+workbook: "@myworkbook"
+format: csv
+writes:
+  - range: "Summary!A1:C3"
+    file: summary.csv       # relative to the manifest
+  - range: "=Totals"
+    data: |
+      total,42
+  - range: "Sheet1!A1:B1"
+    workbook: https://docs.google.com/spreadsheets/d/SpReAdShEeTiD/edit
+    format: tsv
+    data: "a\tb"
+
+> sheet put --batch manifest.yaml
+
+Each write must be to a fixed-size range (or an alias or named range for one), and nothing is written
+unless all of the data fits.
 `,
-		Run: func(cmd *cobra.Command, args []string) {
-			doPut(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doPut(cmd, args)
 		},
 	}
 )
@@ -46,60 +75,174 @@ func init() {
 	rootCmd.AddCommand(putCmd)
 
 	putCmd.PersistentFlags().BoolVar(&forcePut, "force-put", false, "Override protect-worksheets and put data")
+	putCmd.PersistentFlags().StringVar(&putBatchFile, "batch", "", "Write the ranges listed in this yaml manifest, rather than stdin")
+	putCmd.PersistentFlags().StringVar(&putCheckpointFile, "checkpoint", "", "Save progress writing to a worksheet in this file, and resume from it if it exists")
 }
 
-func doPut(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doPut(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	spec, err := sheet.ExpandArgsToDataSpec(args)
+	if putBatchFile != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--batch takes its data specs from the manifest, not arguments: %v", args)
+		}
+		return doPutBatch(cmd, b, putBatchFile)
+	}
+
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
 	if spec.IsWorkbook() {
-		log.Fatalf("Workbooks cannot be....putten to.")
+		return fmt.Errorf("workbooks cannot be....putten to")
 	}
 
-	if spec.IsWorksheet() {
-		err = sheet.ClearWorksheet(srv, spec, protectWorksheets, forcePut)
+	// We won't 'put' to a range not of fixed size (i.e. a range of full rows or cols)
+	if spec.IsRange() && !spec.Range.IsFixedSize() {
+		return fmt.Errorf("ranges must be of fixed size to be...putten to")
+	}
 
+	if spec.IsWorksheet() {
+		// Stream from stdin in format specified by --input-format, --write-chunksize rows at a time.
+		opts := &sheet.StreamOptions{
+			WriteOptions:   *writeOptions(),
+			Format:         inputFormat,
+			ChunkSize:      writeChunkSize,
+			CheckpointFile: putCheckpointFile,
+		}
+		err = sheet.WriteStreamToWorksheet(ctx, b, spec, cmd.InOrStdin(), protectWorksheets, forcePut, opts)
 		if err != nil {
-			log.Fatalf("Unable to clear worksheet: %v", err)
+			return fmt.Errorf("unable to write data to worksheet: %v", err)
 		}
+		return nil
+	}
+
+	if putCheckpointFile != "" {
+		return fmt.Errorf("--checkpoint only works when writing to a worksheet")
 	}
 
 	// Read data from stdin
-	r := bufio.NewReader(os.Stdin)
+	r := bufio.NewReader(cmd.InOrStdin())
 
 	// Read from stdin in format specified by --input-format (or input-format config)
 	data, err := sheet.ScanValues(r, inputFormat)
 
 	if err != nil {
-		log.Fatalf("Unable to read data from stdin: %v", err)
+		return fmt.Errorf("unable to read data from stdin: %v", err)
 	}
 
-	if spec.IsWorksheet() {
-		err = sheet.WriteDataToWorksheet(srv, spec, data, protectWorksheets, forcePut)
+	// Write to a range, clearing it first.
+	err = sheet.WriteDataToRange(ctx, b, spec, data, writeOptions())
+
+	if err != nil {
+		return fmt.Errorf("unable to write data to range: %v", err)
+	}
+	return nil
+}
+
+// batchManifest is the yaml read by put --batch.
+type batchManifest struct {
+	// Defaults for writes that don't have their own.
+	Workbook string
+	Format   string
+	Writes   []batchWrite
+}
+
+type batchWrite struct {
+	Workbook string
+	// A range, as put would take it after the workbook: 'Sheet1!A1:B2', =NamedRange, or an alias.
+	Range  string
+	Format string
+	// Either the name of a file to read (relative to the manifest), or the data itself.
+	File string
+	Data string
+}
+
+func readBatchManifest(path string) (*batchManifest, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("unable to read batch manifest: %v", err)
+	}
+	m := &batchManifest{}
+	if err := v.Unmarshal(m); err != nil {
+		return nil, fmt.Errorf("unable to parse batch manifest %v: %v", path, err)
+	}
+	if len(m.Writes) == 0 {
+		return nil, fmt.Errorf("no writes in batch manifest %v", path)
+	}
+	return m, nil
+}
+
+func doPutBatch(cmd *cobra.Command, b sheet.Backend, path string) error {
+	ctx := cmd.Context()
+	m, err := readBatchManifest(path)
+	if err != nil {
+		return err
+	}
+
+	writes := []sheet.RangeData{}
+	for i, w := range m.Writes {
+		workbook := w.Workbook
+		if workbook == "" {
+			workbook = m.Workbook
+		}
+		args := []string{w.Range}
+		if workbook != "" {
+			args = []string{workbook, w.Range}
+		}
+		spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 		if err != nil {
-			log.Fatalf("Unable to write data to worksheet: %v", err)
+			return fmt.Errorf("unable to expand data spec for write %d: %v", i+1, err)
 		}
-	} else {
-		// Write to a range, clearing it first.
-
-		// We won't 'put' to a range not of fixed size (i.e. a range of full rows or cols)
-		if !spec.Range.IsFixedSize() {
-			log.Fatalf("Ranges must be of fixed size to be...putten to.")
+		if !spec.IsRange() || !spec.Range.IsFixedSize() {
+			return fmt.Errorf("write %d must be to a fixed-size range, not %v", i+1, spec.String())
 		}
 
-		err = sheet.WriteDataToRange(srv, spec, data)
+		format := inputFormat
+		if w.Format == "" {
+			w.Format = m.Format
+		}
+		if w.Format != "" {
+			if err := format.Set(w.Format); err != nil {
+				return fmt.Errorf("write %d: %v", i+1, err)
+			}
+		}
 
+		var input string
+		switch {
+		case w.File != "" && w.Data != "":
+			return fmt.Errorf("write %d has both a file and data", i+1)
+		case w.File != "":
+			file := w.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			contents, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("write %d: %v", i+1, err)
+			}
+			input = string(contents)
+		default:
+			input = w.Data
+		}
+		data, err := sheet.ScanValues(bufio.NewReader(strings.NewReader(input)), format)
 		if err != nil {
-			log.Fatalf("Unable to write data to range: %v", err)
+			return fmt.Errorf("unable to read data for write %d: %v", i+1, err)
 		}
+		writes = append(writes, sheet.RangeData{Spec: spec, Data: data})
+	}
+
+	if err := sheet.WriteDataToRanges(ctx, b, writes, writeOptions()); err != nil {
+		return fmt.Errorf("unable to write data to ranges: %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_doPut(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		protect bool
+		force   bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "worksheet",
+			args:  []string{"@people"},
+			stdin: "name,age\ndave,40\n",
+			want:  "name,age\ndave,40\n",
+		},
+		{
+			name:  "range",
+			args:  []string{"wb", "people!A2:B3"},
+			stdin: "dave,40\n",
+			want:  "name,age\ndave,40\n\ncarol,35\n",
+		},
+		{
+			name:    "rangetoosmall",
+			args:    []string{"wb", "people!A2:B2"},
+			stdin:   "dave,40\nerin,41\n",
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "notfixedsize",
+			args:    []string{"wb", "people!A:B"},
+			stdin:   "dave,40\n",
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "protected",
+			args:    []string{"@people"},
+			stdin:   "name,age\ndave,40\n",
+			protect: true,
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "protectedwithforce",
+			args:    []string{"@people"},
+			stdin:   "name,age\ndave,40\n",
+			protect: true,
+			force:   true,
+			want:    "name,age\ndave,40\n",
+		},
+		{
+			name:    "workbook",
+			args:    []string{"@wb"},
+			stdin:   "dave,40\n",
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupFakeBackend(t)
+			protectWorksheets = tt.protect
+			forcePut = tt.force
+			t.Cleanup(func() {
+				protectWorksheets = false
+				forcePut = false
+			})
+
+			_, err := runCommand(doPut, tt.args, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doPut() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := worksheetContents(t, m, "wb", "people"); got != tt.want {
+				t.Errorf("doPut() left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doPutBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		// Extra files alongside the manifest.
+		files   map[string]string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "inlineandfile",
+			manifest: `workbook: "@wb"
+writes:
+  - range: "people!A2:B2"
+    data: "dave,40"
+  - range: "people!A4:B5"
+    file: carol.tsv
+    format: tsv
+`,
+			files: map[string]string{"carol.tsv": "erin\t41\n"},
+			want:  "name,age\ndave,40\nbob,25\nerin,41\n",
+		},
+		{
+			name: "aliasrange",
+			manifest: `writes:
+  - range: "@people!B3:B3"
+    data: "26"
+`,
+			want: "name,age\nalice,30\nbob,26\ncarol,35\n",
+		},
+		{
+			name: "overflowwritesnothing",
+			manifest: `workbook: wb
+writes:
+  - range: "people!A2:B2"
+    data: "dave,40"
+  - range: "people!A3:A3"
+    data: "erin,41"
+`,
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name: "worksheet",
+			manifest: `workbook: wb
+writes:
+  - range: people
+    data: "dave,40"
+`,
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name: "nowrites",
+			manifest: `workbook: wb
+`,
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name: "args",
+			manifest: `writes:
+  - range: "@people!B3:B3"
+    data: "26"
+`,
+			args:    []string{"wb"},
+			want:    "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupFakeBackend(t)
+			dir := t.TempDir()
+			for name, contents := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			putBatchFile = filepath.Join(dir, "manifest.yaml")
+			t.Cleanup(func() { putBatchFile = "" })
+			if err := os.WriteFile(putBatchFile, []byte(tt.manifest), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := runCommand(doPut, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doPut() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := worksheetContents(t, m, "wb", "people"); got != tt.want {
+				t.Errorf("doPut() left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doPutCheckpoint(t *testing.T) {
+	m := setupFakeBackend(t)
+	putCheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+	writeChunkSize = 2
+	t.Cleanup(func() {
+		putCheckpointFile = ""
+		writeChunkSize = 500
+	})
+
+	// Pretend the first chunk was written before we were interrupted.
+	if err := os.WriteFile(putCheckpointFile, []byte(`{"workbook":"wb","worksheet":"people","rows":2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(doPut, []string{"@people"}, "name,age\ndave,40\nerin,41\n"); err != nil {
+		t.Fatalf("doPut() error = %v", err)
+	}
+	if got, want := worksheetContents(t, m, "wb", "people"), "name,age\nalice,30\nerin,41\ncarol,35\n"; got != want {
+		t.Errorf("doPut() left %q, want %q", got, want)
+	}
+	if _, err := os.Stat(putCheckpointFile); !os.IsNotExist(err) {
+		t.Errorf("doPut() left checkpoint file behind (%v)", err)
+	}
+
+	if _, err := runCommand(doPut, []string{"wb", "people!A1:B2"}, "a,b\n"); err == nil {
+		t.Errorf("doPut() with --checkpoint to a range error = nil, want error")
+	}
+}
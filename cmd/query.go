@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run a SQL query over worksheets",
+	Long: `Run a SQL query over one or more worksheets or ranges, and output the results with a header row.
+Tables are aliases, or data specs in single quotes (as 'sheet get' takes them, with quotes doubled,
+e.g. 'SpReAdShEeTiD ''My Sheet''!A1:D9'), and the first row of each is a header. The tables are
+read in full and the query is run locally, e.g.:
+
+	> sheet query "SELECT Name, SUM(Amount) FROM @sales GROUP BY Name ORDER BY 2 DESC"
+
+	> sheet query "SELECT o.Id, c.Email FROM @orders o
+	    JOIN 'SpReAdShEeTiD Customers' c ON o.Customer = c.Name
+	    WHERE o.Status = 'open' AND o.Total > 100"
+
+The SQL supported is:
+
+	SELECT [* | t.* | expr [AS name]], ...
+	FROM table [[AS] t] [[INNER | LEFT [OUTER]] JOIN table [[AS] t] ON expr]...
+	[WHERE expr] [GROUP BY expr|number, ...] [HAVING expr]
+	[ORDER BY expr|name|number [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+
+Columns are referred to by header ("double quoted" if they have spaces in, and qualified with
+the table name, e.g. sales.Name, if more than one table has them). Expressions can use =, !=, <>,
+<, <=, >, >=, AND, OR, NOT, LIKE (with % and _), + - * /, 'strings', numbers, and the aggregates
+COUNT(*), COUNT, SUM, AVG, MIN and MAX. Numbers are compared as numbers, and text as text.
+Empty cells are like NULL, and never equal each other, so blank keys don't join; use = '' to
+find them.
+
+Unless --render is given, values are read unformatted (with dates as text), so that numbers
+formatted like $1,200.00 are still numbers.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doQuery(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+}
+
+func doQuery(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		cmd.Help()
+		return fmt.Errorf("query requires some SQL")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+
+	// Let the query be given as several arguments, if it doesn't need quoting from the shell.
+	resp, err := sheet.Query(ctx, b, strings.Join(args, " "), compareReadOptions())
+	if err != nil {
+		return err
+	}
+
+	w := sheet.NewValueWriter(cmd.OutOrStdout(), outputFormat, jsonKeys)
+	if err := w.Write(resp); err != nil {
+		return err
+	}
+	return w.Close()
+}
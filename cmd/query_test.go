@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		format  sheet.DataFormat
+		keys    bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "select",
+			args: []string{"SELECT name FROM @people WHERE age > 26 ORDER BY age DESC"},
+			want: "name\ncarol\nalice\n",
+		},
+		{
+			name: "severalargs",
+			args: []string{"SELECT", "COUNT(*)", "AS", "n", "FROM", "@people"},
+			want: "n\n3\n",
+		},
+		{
+			name: "join",
+			args: []string{"SELECT p.name, t.team FROM @people p JOIN 'wb teams' t ON p.name = t.name ORDER BY 2, 1"},
+			want: "name,team\nalice,blue\ncarol,blue\nbob,red\n",
+		},
+		{
+			name:   "keyedjson",
+			args:   []string{"SELECT team, SUM(age) AS total FROM @people JOIN 'wb teams' ON people.name = teams.name GROUP BY team ORDER BY team"},
+			format: sheet.NdjsonFormat,
+			keys:   true,
+			want:   "{\"team\":\"blue\",\"total\":65}\n{\"team\":\"red\",\"total\":25}\n",
+		},
+		{
+			name:    "badsql",
+			args:    []string{"SELECT FROM @people"},
+			wantErr: true,
+		},
+		{
+			name:    "nosuchalias",
+			args:    []string{"SELECT * FROM @nope"},
+			wantErr: true,
+		},
+		{
+			name:    "nosql",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := setupFakeBackend(t)
+			b.SetValues("wb", "teams", [][]interface{}{{"name", "team"}, {"alice", "blue"}, {"bob", "red"}, {"carol", "blue"}})
+			outputFormat = sheet.CsvFormat
+			if tt.format != "" {
+				outputFormat = tt.format
+			}
+			jsonKeys = tt.keys
+			t.Cleanup(func() {
+				outputFormat = sheet.CsvFormat
+				jsonKeys = false
+			})
+
+			got, err := runCommand(doQuery, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("doQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_compareReadOptions(t *testing.T) {
+	flags := rootCmd.PersistentFlags()
+	t.Cleanup(func() {
+		valueRender = sheet.FormattedRender
+		flags.Lookup("render").Changed = false
+	})
+
+	want := &sheet.ReadOptions{ValueRender: sheet.UnformattedRender, DateRender: sheet.StringDateRender}
+	if got := compareReadOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareReadOptions() = %v, want %v", got, want)
+	}
+
+	if err := flags.Set("render", "formatted"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	want = &sheet.ReadOptions{ValueRender: sheet.FormattedRender, DateRender: sheet.SerialDateRender}
+	if got := compareReadOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("with --render, compareReadOptions() = %v, want %v", got, want)
+	}
+}
@@ -1,8 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -33,8 +34,8 @@ This will protect all sheets from deletion.
 
 If you're sure, you can also use the --force-delete flag to override the protection.
 `,
-		Run: func(cmd *cobra.Command, args []string) {
-			doRm(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doRm(cmd, args)
 		},
 	}
 )
@@ -45,32 +46,33 @@ func init() {
 	rmCmd.PersistentFlags().BoolVar(&forceDelete, "force-delete", false, "Override protect-workbooks and protect-worksheets")
 }
 
-func doRm(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doRm(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	spec, err := sheet.ExpandArgsToDataSpec(args)
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
 	if spec.IsWorkbook() {
-		log.Fatalf("You can't delete a workbook with this command.")
+		return fmt.Errorf("you can't delete a workbook with this command")
 	}
 
-	if mayDelete(spec) {
-		err = DeleteSpecified(srv, spec)
-		if err != nil {
-			log.Fatalf("Unable to delete (%v): %v", spec.String(), err)
-		}
-	} else {
-		log.Fatalf("Protection prevents deletion of: (%v). Use --force-delete to force", spec.String())
+	if !mayDelete(spec) {
+		return fmt.Errorf("protection prevents deletion of: (%v). Use --force-delete to force", spec.String())
 	}
 
+	err = DeleteSpecified(ctx, cmd.OutOrStdout(), b, spec)
+	if err != nil {
+		return fmt.Errorf("unable to delete (%v): %v", spec.String(), err)
+	}
+	return nil
 }
 
 func mayDelete(spec *sheet.DataSpec) bool {
@@ -88,44 +90,33 @@ func mayDelete(spec *sheet.DataSpec) bool {
 	return true
 }
 
-func DeleteSpecified(srv *sheets.Service, spec *sheet.DataSpec) error {
-	fmt.Printf("Deleting: %v\n", spec.String())
+func DeleteSpecified(ctx context.Context, out io.Writer, b sheet.Backend, spec *sheet.DataSpec) error {
+	fmt.Fprintf(out, "Deleting: %v\n", spec.String())
 
 	if spec.IsWorkbook() {
 		return fmt.Errorf("you can't delete a workbook with this command")
 	}
 
-	wb, err := sheets.NewSpreadsheetsService(srv).Get(spec.Workbook).Do()
+	ws, err := sheet.FindWorksheet(ctx, b, spec.Workbook, spec.Worksheet)
 
 	if err != nil {
-		return fmt.Errorf("unable to retrieve workbook: %v", err)
-	}
-
-	var rm_id int64
-	for _, sheet := range wb.Sheets {
-		if sheet.Properties.Title == spec.Worksheet {
-			rm_id = sheet.Properties.SheetId
-		}
-	}
-
-	if rm_id == 0 {
-		return fmt.Errorf("unable to find worksheet: %v", spec.Worksheet)
+		return err
 	}
 
 	if spec.IsWorksheet() {
-		srv.Spreadsheets.BatchUpdate(spec.Workbook,
-			&sheets.BatchUpdateSpreadsheetRequest{
-				Requests: []*sheets.Request{
-					{
-						DeleteSheet: &sheets.DeleteSheetRequest{
-							SheetId: rm_id},
-					},
-				},
-			}).Do()
+		_, err := b.BatchUpdate(ctx, spec.Workbook, []*sheets.Request{
+			{
+				DeleteSheet: &sheets.DeleteSheetRequest{
+					SheetId: ws.SheetId},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete worksheet (%v): %v", spec, err)
+		}
 	}
 
 	if spec.IsRange() {
-		_, err := srv.Spreadsheets.Values.Clear(spec.Workbook, spec.Worksheet+"!"+spec.Range.String(), &sheets.ClearValuesRequest{}).Do()
+		err := b.ClearValues(ctx, spec.Workbook, spec.GetInSheetDataSpec())
 		if err != nil {
 			return fmt.Errorf("unable to clear range (%v): %v", spec, err)
 		}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/gerrowadat/sheet/lib"
+	"google.golang.org/api/sheets/v4"
 )
 
 func Test_mayDelete(t *testing.T) {
@@ -60,3 +63,86 @@ func Test_mayDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_doRm(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		protect    bool
+		force      bool
+		wantSheets string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "worksheet",
+			args:       []string{"wb", "people"},
+			wantSheets: "other",
+		},
+		{
+			name:       "protectedworksheet",
+			args:       []string{"@people"},
+			protect:    true,
+			wantSheets: "people,other",
+			want:       "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr:    true,
+		},
+		{
+			name:       "protectedworksheetwithforce",
+			args:       []string{"@people"},
+			protect:    true,
+			force:      true,
+			wantSheets: "other",
+		},
+		{
+			name:       "range",
+			args:       []string{"@people!A2:B3"},
+			protect:    true,
+			wantSheets: "people,other",
+			want:       "name,age\n\n\ncarol,35\n",
+		},
+		{
+			name:       "nosuchworksheet",
+			args:       []string{"wb", "nope"},
+			wantSheets: "people,other",
+			want:       "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr:    true,
+		},
+		{
+			name:       "workbook",
+			args:       []string{"@wb"},
+			wantSheets: "people,other",
+			want:       "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupFakeBackend(t)
+			// The first worksheet has id 0, which should be deletable too.
+			_, err := m.BatchUpdate(context.Background(), "wb", []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: "other"}}}})
+			if err != nil {
+				t.Fatalf("BatchUpdate() error = %v", err)
+			}
+			protectWorksheets = tt.protect
+			forceDelete = tt.force
+			t.Cleanup(func() {
+				protectWorksheets = false
+				forceDelete = false
+			})
+
+			_, err = runCommand(doRm, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doRm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(worksheetTitles(t, m, "wb"), ","); got != tt.wantSheets {
+				t.Errorf("doRm() left worksheets %q, want %q", got, tt.wantSheets)
+			}
+			if tt.want != "" {
+				if got := worksheetContents(t, m, "wb", "people"); got != tt.want {
+					t.Errorf("doRm() left %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
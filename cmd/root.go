@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -14,17 +17,35 @@ var (
 	configFormat      = "yaml"
 	outputFormat      = sheet.CsvFormat
 	inputFormat       = sheet.CsvFormat
+	authMode          = sheet.OAuthMode
 	clientSecretFile  string
 	authTokenFile     string
+	keyFile           string
+	oauthFlow         = sheet.LoopbackFlow
 	readChunkSize     int
 	writeChunkSize    int
 	protectWorksheets bool
+	jsonKeys          bool
+	valueRender       = sheet.FormattedRender
+	dateRender        = sheet.SerialDateRender
+	inputOption       = sheet.UserEnteredInput
+	sanitizeFormulas  bool
+	maxAttempts       int
+	requestsPerMinute int
+	shareRateLimit    bool
+	filterColumns     []string
+	filterWhere       []string
+
+	// How commands get a Backend to talk to. Tests replace this with an in-memory one.
+	newBackend = sheet.GetBackend
 
 	rootCmd = &cobra.Command{
 		Use:   "sheet",
 		Short: "Manipulate google sheet data",
 		Long: `A utility to send and recieve data to/from a google
 sheet from the command line in various forms.`,
+		// Errors from commands are about the data or the API, not how the command was used.
+		SilenceUsage: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			return initializeConfig(cmd)
 		},
@@ -37,34 +58,116 @@ sheet from the command line in various forms.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel whatever's in progress on ^C or SIGTERM, so chunked reads and writes stop between requests.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// After the first signal, go back to the default behaviour so a second one kills us outright.
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil {
+		// Interrupted, whether or not the command noticed.
+		os.Exit(130)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().Var(&authMode, "auth-mode", "How to authenticate ([oauth|service-account|adc])")
+	viper.BindPFlag("auth-mode", rootCmd.PersistentFlags().Lookup("auth-mode"))
 	rootCmd.PersistentFlags().StringVar(&clientSecretFile, "clientsecretfile", "", "Client secret file")
 	viper.BindPFlag("clientsecretfile", rootCmd.PersistentFlags().Lookup("clientsecretfile"))
 	rootCmd.PersistentFlags().StringVar(&authTokenFile, "authtokenfile", "", "where to store our oauth token")
 	viper.BindPFlag("authtokenfile", rootCmd.PersistentFlags().Lookup("authtokenfile"))
+	rootCmd.PersistentFlags().Var(&oauthFlow, "oauth-flow", "How to get a new oauth token ([loopback|manual])")
+	viper.BindPFlag("oauth-flow", rootCmd.PersistentFlags().Lookup("oauth-flow"))
+	rootCmd.PersistentFlags().StringVar(&keyFile, "keyfile", "", "Service account key file (with --auth-mode=service-account)")
+	viper.BindPFlag("keyfile", rootCmd.PersistentFlags().Lookup("keyfile"))
 	// This is passed directly to viper.SetConfigType
 	rootCmd.PersistentFlags().StringVar(&configFormat, "configformat", "yaml", "config file format")
 
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", sheet.DefaultMaxAttempts, "How many times to try API requests that fail with quota or server errors")
+	viper.BindPFlag("max-attempts", rootCmd.PersistentFlags().Lookup("max-attempts"))
+	rootCmd.PersistentFlags().IntVar(&requestsPerMinute, "requests-per-minute", 0, "Limit API requests to this many a minute (0 for no limit)")
+	viper.BindPFlag("requests-per-minute", rootCmd.PersistentFlags().Lookup("requests-per-minute"))
+	rootCmd.PersistentFlags().BoolVar(&shareRateLimit, "share-rate-limit", false, "Share --requests-per-minute with other sheet processes, via ~/.config/sheet/ratelimit.json")
+	viper.BindPFlag("share-rate-limit", rootCmd.PersistentFlags().Lookup("share-rate-limit"))
+
 	rootCmd.PersistentFlags().IntVar(&readChunkSize, "read-chunksize", 500, "How many rows at a time to read while fetching data")
 	viper.BindPFlag("read-chunksize", rootCmd.PersistentFlags().Lookup("read-chunksize"))
 	rootCmd.PersistentFlags().IntVar(&writeChunkSize, "write-chunksize", 500, "How many rows at a time to write at a time while updating data")
 	viper.BindPFlag("write-chunksize", rootCmd.PersistentFlags().Lookup("write-chunksize"))
 
-	rootCmd.PersistentFlags().Var(&outputFormat, "output-format", "Output format ([csv|tsv])")
+	rootCmd.PersistentFlags().Var(&outputFormat, "output-format", "Output format ([csv|tsv|json|ndjson])")
 	viper.BindPFlag("output-format", rootCmd.PersistentFlags().Lookup("output-format"))
 	rootCmd.PersistentFlags().Var(&inputFormat, "input-format", "Input format ([csv|tsv])")
 	viper.BindPFlag("input-format", rootCmd.PersistentFlags().Lookup("input-format"))
+	rootCmd.PersistentFlags().BoolVar(&jsonKeys, "json-keys", false, "For json/ndjson output, output each row as an object keyed by the header row")
+	viper.BindPFlag("json-keys", rootCmd.PersistentFlags().Lookup("json-keys"))
+
+	rootCmd.PersistentFlags().Var(&valueRender, "render", "How to render values that are read ([formatted|unformatted|formula])")
+	viper.BindPFlag("render", rootCmd.PersistentFlags().Lookup("render"))
+	rootCmd.PersistentFlags().Var(&dateRender, "date-render", "How to render dates with --render=unformatted or formula ([serial|string])")
+	viper.BindPFlag("date-render", rootCmd.PersistentFlags().Lookup("date-render"))
+
+	rootCmd.PersistentFlags().Var(&inputOption, "input-option", "How written values are interpreted ([raw|user-entered])")
+	viper.BindPFlag("input-option", rootCmd.PersistentFlags().Lookup("input-option"))
+	rootCmd.PersistentFlags().BoolVar(&sanitizeFormulas, "sanitize-formulas", false, "Escape written values that look like formulas (with --input-option=user-entered)")
+	viper.BindPFlag("sanitize-formulas", rootCmd.PersistentFlags().Lookup("sanitize-formulas"))
 
 	rootCmd.PersistentFlags().BoolVar(&protectWorksheets, "protect-worksheets", false, "Never delete any worksheets")
 	viper.BindPFlag("protect-worksheets", rootCmd.PersistentFlags().Lookup("protect-worksheets"))
 }
 
+func readOptions() *sheet.ReadOptions {
+	return &sheet.ReadOptions{ValueRender: valueRender, DateRender: dateRender}
+}
+
+// compareReadOptions returns the read options for commands that compare or compute with what they read.
+// Unless --render (or --date-render) was given, values are read unformatted, with dates as strings, so that
+// "$1,200.00" is the number 1200 rather than text.
+func compareReadOptions() *sheet.ReadOptions {
+	ret := readOptions()
+	if !rootCmd.PersistentFlags().Changed("render") {
+		ret.ValueRender = sheet.UnformattedRender
+		if !rootCmd.PersistentFlags().Changed("date-render") {
+			ret.DateRender = sheet.StringDateRender
+		}
+	}
+	return ret
+}
+
+// addFilterFlags adds --columns and --where to a command that reads data, for rowFilter.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSliceVar(&filterColumns, "columns", nil, "Only output these columns, by header or uppercase letter (e.g. Name,Email or A,C)")
+	cmd.PersistentFlags().StringArrayVar(&filterWhere, "where", nil, "Only output rows matching a condition on a column, e.g. 'Status=active' or 'Age>30' (can be repeated; reads values unformatted unless --render is given)")
+}
+
+// filterReadOptions returns the read options for a command taking --where, which needs to compare numbers
+// as numbers, so reads them unformatted unless --render was given (see compareReadOptions).
+func filterReadOptions() *sheet.ReadOptions {
+	if len(filterWhere) == 0 {
+		return readOptions()
+	}
+	return compareReadOptions()
+}
+
+// rowFilter returns a filter for --columns and --where, or nil if neither was given.
+func rowFilter() (*sheet.RowFilter, error) {
+	if len(filterColumns) == 0 && len(filterWhere) == 0 {
+		return nil, nil
+	}
+	return sheet.NewRowFilter(filterColumns, filterWhere)
+}
+
+func writeOptions() *sheet.WriteOptions {
+	return &sheet.WriteOptions{InputOption: inputOption, SanitizeFormulas: sanitizeFormulas}
+}
+
 func initializeConfig(_ *cobra.Command) error {
 	// With thanks to https://github.com/carolynvs/stingoftheviper
 
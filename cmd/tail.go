@@ -1,12 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
-	"google.golang.org/api/sheets/v4"
 )
 
 // tailCmd represents the tail command
@@ -23,8 +22,8 @@ var (
 	# Show the last 10 lines of the 'myworksheet' worksheet. 
 	> sheet tail SpReAdShEetId myworksheet --lines=10
 	> sheet tail @mysheet --lines=50`,
-		Run: func(cmd *cobra.Command, args []string) {
-			doTail(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doTail(cmd, args)
 		},
 	}
 )
@@ -34,72 +33,97 @@ func init() {
 	tailCmd.PersistentFlags().IntVar(&tailLines, "lines", 10, "Lines to output")
 }
 
-func doTail(_ *cobra.Command, args []string) {
-	srv, err := sheet.GetService()
+func doTail(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	dataspec, err := sheet.ExpandArgsToDataSpec(args)
+	dataspec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 
 	if !dataspec.IsWorksheet() {
-		log.Fatalf("data spec must specify a worksheet: %v", args)
+		return fmt.Errorf("data spec must specify a worksheet: %v", args)
 	}
 
-	resp, err := srv.Spreadsheets.Get(dataspec.Workbook).Do()
+	ws, err := sheet.FindWorksheet(ctx, b, dataspec.Workbook, dataspec.Worksheet)
 	if err != nil {
-		log.Fatalf("Unable to retrieve sheet Id %v: %v", args[0], err)
+		return err
 	}
 
-	if len(resp.Sheets) == 0 {
-		log.Fatalf("Sheet ID %v has no sheets", args[0])
+	// Properties.GridProperties.RowCount gives the grid size, not the amunt of data.
+	// This seems to be 1000 for new sheets, so expensively poll through it.
+	last_datarow, err := findLastDataRow(ctx, b, dataspec, ws.GridProperties.RowCount)
+	if err != nil {
+		return err
+	}
+	if last_datarow == 0 {
+		// No data at all.
+		return sheet.NewValueWriter(cmd.OutOrStdout(), outputFormat, jsonKeys).Close()
 	}
 
-	for _, sh := range resp.Sheets {
-		if sh.Properties.Title == dataspec.Worksheet {
-			// Properties.GridProperties.RowCount gives the grid size, not the amunt of data.
-			// This seems to be 1000 for new sheets, so expensively poll through it.
-			last_datarow := findLastDataRow(srv, dataspec, sh.Properties.GridProperties.RowCount)
-			// We get the last line by default
-			tailLines--
-			chunkspec := fmt.Sprintf("%v!%v:%v", dataspec.Worksheet, max(1, last_datarow-int64(tailLines)), last_datarow)
-			resp, err := srv.Spreadsheets.Values.Get(dataspec.Workbook, chunkspec).Do()
-			if err != nil {
-				log.Fatalf("Unable to retrieve data from sheet at %v: %v", chunkspec, err)
-			}
-			sheet.PrintValues(resp, outputFormat)
-			return
+	// We get the last line by default
+	first_row := max(1, last_datarow-int64(tailLines-1))
+	chunkspec := worksheetRows(dataspec.Worksheet, int(first_row), int(last_datarow))
+	resp, err := b.GetValues(ctx, dataspec.Workbook, chunkspec, readOptions())
+	if err != nil {
+		return err
+	}
+
+	w := sheet.NewValueWriter(cmd.OutOrStdout(), outputFormat, jsonKeys)
+	if jsonKeys && first_row > 1 {
+		// We're not starting at the top, so go fetch the header row for keys.
+		header, err := getHeaderRow(ctx, b, dataspec)
+		if err != nil {
+			return err
+		}
+		w.SetHeader(header)
+	}
+	if err := w.Write(resp); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func getHeaderRow(ctx context.Context, b sheet.Backend, dataspec *sheet.DataSpec) ([]string, error) {
+	resp, err := b.GetValues(ctx, dataspec.Workbook, worksheetRows(dataspec.Worksheet, 1, 1), readOptions())
+	if err != nil {
+		return nil, err
+	}
+	header := []string{}
+	if len(resp.Values) > 0 {
+		for _, v := range resp.Values[0] {
+			header = append(header, sheet.CellFromValue(v).String())
 		}
 	}
-	// If we get here, we didn't find our worksheet.
-	log.Fatalf("unable to find worksheet %v in spreadsheet %v", dataspec.Worksheet, args[0])
+	return header, nil
 }
 
-func findLastDataRow(srv *sheets.Service, dataspec *sheet.DataSpec, chunk_end int64) int64 {
-	if chunk_end == 1 {
-		return 0
+func findLastDataRow(ctx context.Context, b sheet.Backend, dataspec *sheet.DataSpec, chunk_end int64) (int64, error) {
+	if chunk_end < 1 {
+		return 0, nil
 	}
 
-	chunk_start := max(1, chunk_end-int64(readChunkSize))
+	chunk_start := max(1, chunk_end-int64(readChunkSize)+1)
 
 	// worksheet!chunk_start:chunk_end
-	chunkspec := fmt.Sprintf("%v!%v:%v", dataspec.Worksheet, chunk_start, chunk_end)
+	chunkspec := worksheetRows(dataspec.Worksheet, int(chunk_start), int(chunk_end))
 
-	resp, err := srv.Spreadsheets.Values.Get(dataspec.Workbook, chunkspec).Do()
+	resp, err := b.GetValues(ctx, dataspec.Workbook, chunkspec, readOptions())
 	if err != nil {
-		log.Fatalf("Unable to retrieve data from sheet: %v", err)
+		return 0, err
 	}
 
 	// We get no values back if there is no data, so the first non-zero length chunk we see
 	// scanning backwards from eof is the end of our data.
 	if len(resp.Values) > 0 {
-		return chunk_start + int64(len(resp.Values)-1)
+		return chunk_start + int64(len(resp.Values)-1), nil
 	} else {
-		return findLastDataRow(srv, dataspec, chunk_start)
+		return findLastDataRow(ctx, b, dataspec, chunk_start-1)
 	}
 }
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func Test_doTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		lines   int
+		format  sheet.DataFormat
+		keys    bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "last2",
+			args:  []string{"@people"},
+			lines: 2,
+			want:  "bob,25\ncarol,35\n",
+		},
+		{
+			name:  "morethanthereis",
+			args:  []string{"wb", "people"},
+			lines: 10,
+			want:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+		},
+		{
+			name:   "keyedfromheader",
+			args:   []string{"@people"},
+			lines:  1,
+			format: sheet.NdjsonFormat,
+			keys:   true,
+			want:   "{\"name\":\"carol\",\"age\":\"35\"}\n",
+		},
+		{
+			name:    "nosuchworksheet",
+			args:    []string{"wb", "nope"},
+			lines:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFakeBackend(t)
+			tailLines = tt.lines
+			outputFormat = sheet.CsvFormat
+			if tt.format != "" {
+				outputFormat = tt.format
+			}
+			jsonKeys = tt.keys
+			t.Cleanup(func() {
+				tailLines = 10
+				outputFormat = sheet.CsvFormat
+				jsonKeys = false
+			})
+
+			got, err := runCommand(doTail, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doTail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("doTail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doTailEmptyWorksheet(t *testing.T) {
+	m := setupFakeBackend(t)
+	m.AddWorkbook("empty", "Sheet1")
+
+	got, err := runCommand(doTail, []string{"empty", "Sheet1"}, "")
+	if err != nil {
+		t.Fatalf("doTail() error = %v", err)
+	}
+	if got != "" {
+		t.Errorf("doTail() = %q, want nothing", got)
+	}
+}
+
+func Test_doTailQuotedWorksheet(t *testing.T) {
+	b := setupFakeBackend(t)
+	addAwkwardWorksheet(t, b)
+	tailLines = 1
+	outputFormat = sheet.NdjsonFormat
+	jsonKeys = true
+	t.Cleanup(func() {
+		tailLines = 10
+		outputFormat = sheet.CsvFormat
+		jsonKeys = false
+	})
+
+	// Quoted as it would be in the Sheets UI.
+	got, err := runCommand(doTail, []string{"wb", "'Q1 ''Sales''!'"}, "")
+	if err != nil {
+		t.Fatalf("doTail() error = %v", err)
+	}
+	if want := "{\"name\":\"carol\",\"age\":\"35\"}\n"; got != want {
+		t.Errorf("doTail() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/sheets/v4"
+)
+
+// setupFakeBackend points commands at an in-memory backend with a "wb" workbook
+// containing a "people" worksheet, and a config with aliases for them.
+func setupFakeBackend(t *testing.T) *sheet.MemoryBackend {
+	sheet.SetupTempConfig(t, "aliases")
+
+	m := sheet.NewMemoryBackend()
+	m.AddWorkbook("wb", "people")
+	err := m.SetValues("wb", "people", [][]interface{}{
+		{"name", "age"},
+		{"alice", 30},
+		{"bob", 25},
+		{"carol", 35},
+	})
+	if err != nil {
+		t.Fatalf("SetValues() error = %v", err)
+	}
+
+	oldBackend := newBackend
+	newBackend = func(context.Context) (sheet.Backend, error) { return m, nil }
+	t.Cleanup(func() { newBackend = oldBackend })
+
+	return m
+}
+
+// addAwkwardWorksheet adds a worksheet to b's "wb" workbook whose title needs quoting in A1 notation,
+// with the same contents as "people".
+func addAwkwardWorksheet(t *testing.T, b *sheet.MemoryBackend) string {
+	title := "Q1 'Sales'!"
+	if _, err := b.BatchUpdate(context.Background(), "wb", []*sheets.Request{
+		{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: title}}},
+	}); err != nil {
+		t.Fatalf("BatchUpdate() error = %v", err)
+	}
+	if err := b.SetValues("wb", title, [][]interface{}{
+		{"name", "age"},
+		{"alice", 30},
+		{"bob", 25},
+		{"carol", 35},
+	}); err != nil {
+		t.Fatalf("SetValues() error = %v", err)
+	}
+	return title
+}
+
+// runCommand runs a command's implementation with the given args and stdin, returning its output.
+func runCommand(run func(*cobra.Command, []string) error, args []string, stdin string) (string, error) {
+	return runCommandContext(context.Background(), run, args, stdin)
+}
+
+func runCommandContext(ctx context.Context, run func(*cobra.Command, []string) error, args []string, stdin string) (string, error) {
+	c := &cobra.Command{}
+	c.SetContext(ctx)
+	out := &bytes.Buffer{}
+	c.SetOut(out)
+	c.SetIn(strings.NewReader(stdin))
+	err := run(c, args)
+	return out.String(), err
+}
+
+// worksheetContents returns a worksheet as csv, for comparing against in tests.
+func worksheetContents(t *testing.T, b sheet.Backend, workbook string, worksheet string) string {
+	resp, err := b.GetValues(context.Background(), workbook, worksheet, nil)
+	if err != nil {
+		t.Fatalf("GetValues() error = %v", err)
+	}
+	csv, err := sheet.FormatValues(resp, sheet.CsvFormat)
+	if err != nil {
+		t.Fatalf("FormatValues() error = %v", err)
+	}
+	return csv
+}
@@ -1,8 +1,8 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/gerrowadat/sheet/lib"
 	"github.com/spf13/cobra"
@@ -32,8 +32,8 @@ sheet touch worksheet @myworkbook mynewsheet
 # (Only works for worksheets, not workbooks)
 sheet touch worksheet @mynewsheet
 `,
-		Run: func(cmd *cobra.Command, args []string) {
-			doTouch(cmd, args)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doTouch(cmd, args)
 		},
 	}
 )
@@ -44,31 +44,32 @@ func init() {
 	viper.BindPFlag("default-workbook-title", touchCmd.PersistentFlags().Lookup("default-workbook-title"))
 }
 
-func doTouch(_ *cobra.Command, args []string) {
+func doTouch(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
-		log.Fatalf("touch command requires a subcommand: workbook or worksheet")
+		return fmt.Errorf("touch command requires a subcommand: workbook or worksheet")
 	}
 
-	srv, err := sheet.GetService()
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
 
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
 	switch args[0] {
 	case "workbook":
-		doTouchWorkbook(srv, args[1:])
+		return doTouchWorkbook(cmd, b, args[1:])
 	case "worksheet":
-		doTouchWorksheet(srv, args[1:])
+		return doTouchWorksheet(ctx, b, args[1:])
 	default:
-		log.Fatalf("Unknown touch command: %v", args[0])
+		return fmt.Errorf("unknown touch command: %v", args[0])
 	}
 }
 
-func doTouchWorkbook(srv *sheets.Service, args []string) {
+func doTouchWorkbook(cmd *cobra.Command, b sheet.Backend, args []string) error {
 	// Only argument is the workbook title.
 	if len(args) > 1 {
-		log.Fatalf("touch workbook requires 0 or 1 arguments")
+		return fmt.Errorf("touch workbook requires 0 or 1 arguments")
 	}
 	var workbookTitle string
 
@@ -84,53 +85,52 @@ func doTouchWorkbook(srv *sheets.Service, args []string) {
 		}
 	}
 
-	resp, err := srv.Spreadsheets.Create(&sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{Title: workbookTitle}}).Do()
+	resp, err := b.CreateWorkbook(cmd.Context(), workbookTitle)
 
 	if err != nil {
-		log.Fatalf("Unable to create workbook: %v", err)
+		return fmt.Errorf("unable to create workbook: %v", err)
 	}
 	// Simply print the new spreadsheet ID, for doing terrifying scripts.
-	fmt.Println(resp.SpreadsheetId)
+	fmt.Fprintln(cmd.OutOrStdout(), resp.SpreadsheetId)
+	return nil
 }
 
-func doTouchWorksheet(srv *sheets.Service, args []string) {
-	dataspec, err := sheet.ExpandArgsToDataSpec(args)
+func doTouchWorksheet(ctx context.Context, b sheet.Backend, args []string) error {
+	dataspec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
 	if err != nil {
-		log.Fatalf("Unable to expand data spec: %v", err)
+		return fmt.Errorf("unable to expand data spec: %v", err)
 	}
 	if !dataspec.IsWorksheet() {
-		log.Fatalf("touch worksheet requires a worksheet spec")
+		return fmt.Errorf("touch worksheet requires a worksheet spec")
 	}
 
 	// Get the existing worksheets
-	resp, err := srv.Spreadsheets.Get(dataspec.Workbook).Do()
+	resp, err := b.GetWorkbook(ctx, dataspec.Workbook)
 	if err != nil {
-		log.Fatalf("Unable to retrieve workbook: %v", err)
+		return fmt.Errorf("unable to retrieve workbook: %v", err)
 	}
 
 	// Check if the worksheet exists
 	for _, sheet := range resp.Sheets {
 		if sheet.Properties.Title == dataspec.Worksheet {
 			// The worksheet exists, nothing to do
-			return
+			return nil
 		}
 	}
 
 	// The worksheet doesn't exist, create it
-	_, err = srv.Spreadsheets.BatchUpdate(dataspec.Workbook,
-		&sheets.BatchUpdateSpreadsheetRequest{
-			Requests: []*sheets.Request{
-				{
-					AddSheet: &sheets.AddSheetRequest{
-						Properties: &sheets.SheetProperties{
-							Title: dataspec.Worksheet,
-						},
-					},
+	_, err = b.BatchUpdate(ctx, dataspec.Workbook, []*sheets.Request{
+		{
+			AddSheet: &sheets.AddSheetRequest{
+				Properties: &sheets.SheetProperties{
+					Title: dataspec.Worksheet,
 				},
 			},
-		}).Do()
+		},
+	})
 
 	if err != nil {
-		log.Fatalf("Unable to create worksheet: %v", err)
+		return fmt.Errorf("unable to create worksheet: %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gerrowadat/sheet/lib"
+)
+
+func worksheetTitles(t *testing.T, b sheet.Backend, workbook string) []string {
+	wb, err := b.GetWorkbook(context.Background(), workbook)
+	if err != nil {
+		t.Fatalf("GetWorkbook() error = %v", err)
+	}
+	ret := []string{}
+	for _, sh := range wb.Sheets {
+		ret = append(ret, sh.Properties.Title)
+	}
+	return ret
+}
+
+func Test_doTouchWorkbook(t *testing.T) {
+	m := setupFakeBackend(t)
+
+	out, err := runCommand(doTouch, []string{"workbook", "TPS Reports"}, "")
+	if err != nil {
+		t.Fatalf("doTouch() error = %v", err)
+	}
+
+	id := strings.TrimSpace(out)
+	wb, err := m.GetWorkbook(context.Background(), id)
+	if err != nil {
+		t.Fatalf("doTouch() printed %q, which isn't a workbook: %v", out, err)
+	}
+	if wb.Properties.Title != "TPS Reports" {
+		t.Errorf("doTouch() created workbook titled %q, want %q", wb.Properties.Title, "TPS Reports")
+	}
+}
+
+func Test_doTouchWorksheet(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "new",
+			args: []string{"worksheet", "@wb", "things"},
+			want: "people,things",
+		},
+		{
+			name: "existing",
+			args: []string{"worksheet", "@people"},
+			want: "people",
+		},
+		{
+			name:    "range",
+			args:    []string{"worksheet", "wb", "things!A1:B2"},
+			want:    "people",
+			wantErr: true,
+		},
+		{
+			name:    "nosubcommand",
+			args:    []string{},
+			want:    "people",
+			wantErr: true,
+		},
+		{
+			name:    "badsubcommand",
+			args:    []string{"folder", "wb"},
+			want:    "people",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupFakeBackend(t)
+
+			_, err := runCommand(doTouch, tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doTouch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(worksheetTitles(t, m, "wb"), ","); got != tt.want {
+				t.Errorf("doTouch() left worksheets %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/gerrowadat/sheet/lib"
+	"github.com/spf13/cobra"
+)
+
+// upsertCmd represents the upsert command
+var (
+	upsertKey   string
+	upsertPrune bool
+	upsertCmd   = &cobra.Command{
+		Use:   "upsert <worksheet spec> --key=<header>",
+		Short: "Update rows of a worksheet by key, adding new ones",
+		Long: `Read rows with a header from stdin, and match them to the rows of a worksheet by their value
+in the --key column. Rows that have changed are updated in place, and rows with new keys are added
+after the last row, e.g.:
+
+	> sheet upsert @inventory --key=SKU < updates.csv
+
+Only the cells that differ are written, comparing with the worksheet's unformatted values (unless
+--render is given), so that 1000 matches a cell shown as 1,000. The input's columns can be in any
+order, and can leave some out, but they must all be in the worksheet's header.
+
+With --prune, rows whose keys aren't in the input are deleted, so the worksheet ends up with
+exactly the input's rows (in its existing order, with new rows at the end). Rows with a blank
+key, such as blank rows, are left alone, even with --prune. The rows are deleted before anything
+is written, so if writing fails after that, the error says how many rows were deleted.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doUpsert(cmd, args)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(upsertCmd)
+	upsertCmd.PersistentFlags().StringVar(&upsertKey, "key", "", "The header of the column that identifies rows")
+	upsertCmd.PersistentFlags().BoolVar(&upsertPrune, "prune", false, "Delete rows whose keys aren't in the input")
+}
+
+func doUpsert(cmd *cobra.Command, args []string) error {
+	if upsertKey == "" {
+		cmd.Help()
+		return fmt.Errorf("upsert requires --key")
+	}
+	ctx := cmd.Context()
+	b, err := newBackend(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+
+	spec, err := sheet.ResolveArgsToDataSpec(ctx, b, args)
+	if err != nil {
+		return fmt.Errorf("unable to expand data spec: %v", err)
+	}
+	if !spec.IsWorksheet() {
+		return fmt.Errorf("data spec must specify a worksheet: %v", spec.String())
+	}
+
+	// Read from stdin in format specified by --input-format (or input-format config)
+	data, err := sheet.ScanValues(bufio.NewReader(cmd.InOrStdin()), inputFormat)
+	if err != nil {
+		return fmt.Errorf("unable to read data from stdin: %v", err)
+	}
+
+	opts := &sheet.PatchOptions{WriteOptions: *writeOptions(), ReadOptions: compareReadOptions()}
+	res, err := sheet.UpsertRows(ctx, b, spec, upsertKey, data, upsertPrune, opts)
+	if err != nil {
+		return fmt.Errorf("unable to upsert rows: %v", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "updated %d rows, inserted %d rows, deleted %d rows\n", res.Updated, res.Inserted, res.Deleted)
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import "testing"
+
+func Test_doUpsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		key     string
+		prune   bool
+		want    string
+		wantWs  string
+		wantErr bool
+	}{
+		{
+			name:   "upsert",
+			args:   []string{"@people"},
+			stdin:  "age,name\n26,bob\n40,dave\n",
+			key:    "name",
+			want:   "updated 1 rows, inserted 1 rows, deleted 0 rows\n",
+			wantWs: "name,age\nalice,30\nbob,26\ncarol,35\ndave,40\n",
+		},
+		{
+			name:   "prune",
+			args:   []string{"wb", "people"},
+			stdin:  "name,age\ncarol,35\nalice,31\n",
+			key:    "name",
+			prune:  true,
+			want:   "updated 1 rows, inserted 0 rows, deleted 1 rows\n",
+			wantWs: "name,age\nalice,31\ncarol,35\n",
+		},
+		{
+			name:    "nokey",
+			args:    []string{"@people"},
+			stdin:   "name,age\nbob,26\n",
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "range",
+			args:    []string{"wb", "people!A1:B4"},
+			stdin:   "name,age\nbob,26\n",
+			key:     "name",
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknowncolumn",
+			args:    []string{"@people"},
+			stdin:   "name,height\nbob,180\n",
+			key:     "name",
+			wantWs:  "name,age\nalice,30\nbob,25\ncarol,35\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := setupFakeBackend(t)
+			upsertKey = tt.key
+			upsertPrune = tt.prune
+			t.Cleanup(func() {
+				upsertKey = ""
+				upsertPrune = false
+			})
+
+			got, err := runCommand(doUpsert, tt.args, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doUpsert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("doUpsert() = %q, want %q", got, tt.want)
+			}
+			if ws := worksheetContents(t, b, "wb", "people"); ws != tt.wantWs {
+				t.Errorf("after doUpsert() worksheet = %q, want %q", ws, tt.wantWs)
+			}
+		})
+	}
+}
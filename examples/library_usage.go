@@ -42,8 +42,10 @@ func main() {
 func readExample() {
 	fmt.Println("=== Reading Example ===")
 
-	// Get an authenticated Google Sheets service
-	srv, err := sheet.GetService()
+	ctx := context.Background()
+
+	// Get a Backend that talks to Google Sheets
+	b, err := sheet.GetBackend(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -55,22 +57,25 @@ func readExample() {
 	}
 
 	// Read data from the worksheet
-	resp, err := srv.Spreadsheets.Values.Get(
-		spec.Workbook,
-		spec.GetInSheetDataSpec(),
-	).Do()
+	resp, err := b.GetValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Print the data as CSV
-	fmt.Print(sheet.FormatValues(resp, sheet.CsvFormat))
+	csvOutput, err := sheet.FormatValues(resp, sheet.CsvFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(csvOutput)
 }
 
 func writeExample() {
 	fmt.Println("\n=== Writing Example ===")
 
-	srv, err := sheet.GetService()
+	ctx := context.Background()
+
+	b, err := sheet.GetBackend(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -89,8 +94,8 @@ func writeExample() {
 	}
 
 	// Write data to the worksheet
-	// Parameters: service, spec, data, protect, force
-	err = sheet.WriteDataToWorksheet(srv, spec, data, false, false)
+	// Parameters: context, backend, spec, data, protect, force, write options (nil for defaults)
+	err = sheet.WriteDataToWorksheet(ctx, b, spec, data, false, false, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -101,7 +106,9 @@ func writeExample() {
 func rangeExample() {
 	fmt.Println("\n=== Range Example ===")
 
-	srv, err := sheet.GetService()
+	ctx := context.Background()
+
+	b, err := sheet.GetBackend(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -110,7 +117,7 @@ func rangeExample() {
 	spec := &sheet.DataSpec{
 		Workbook:  "your-spreadsheet-id-here",
 		Worksheet: "Sheet1",
-		Range:     sheet.RangeFromString("A1:C3"),
+		Range:     sheet.MustRangeFromString("A1:C3"),
 	}
 
 	// Data to write (must fit in the specified range)
@@ -120,7 +127,7 @@ func rangeExample() {
 		{"4", "5", "6"},
 	}
 
-	err = sheet.WriteDataToRange(srv, spec, data)
+	err = sheet.WriteDataToRange(ctx, b, spec, data, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -156,7 +163,10 @@ func formatExample() {
 	}
 
 	// Format as TSV
-	tsvOutput := sheet.FormatValues(valueRange, sheet.TsvFormat)
+	tsvOutput, err := sheet.FormatValues(valueRange, sheet.TsvFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println("\nFormatted as TSV:")
 	fmt.Print(tsvOutput)
 }
@@ -165,7 +175,14 @@ func directAuthExample() {
 	fmt.Println("\n=== Direct Auth Example ===")
 
 	// Authenticate without viper by providing credential paths directly
-	client := sheet.GetClient("/path/to/client_secret.json", "/path/to/token.json")
+	client, err := sheet.GetClient("/path/to/client_secret.json", "/path/to/token.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Or, for unattended use, a service account key or Application Default Credentials:
+	//   client, err := sheet.GetServiceAccountClient("/path/to/service_account.json")
+	//   client, err := sheet.GetDefaultClient()
 
 	srv, err := sheets.NewService(context.Background(), option.WithHTTPClient(client))
 	if err != nil {
@@ -177,12 +194,14 @@ func directAuthExample() {
 		Worksheet: "Sheet1",
 	}
 
-	resp, err := srv.Spreadsheets.Values.Get(spec.Workbook, spec.GetInSheetDataSpec()).Do()
+	resp, err := sheet.NewGoogleBackend(srv).GetValues(context.Background(), spec.Workbook, spec.GetInSheetDataSpec(), nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	sheet.PrintValues(resp, sheet.CsvFormat)
+	if err := sheet.PrintValues(resp, sheet.CsvFormat); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func dataSpecExample() {
@@ -198,14 +217,14 @@ func dataSpecExample() {
 	rngSpec := &sheet.DataSpec{
 		Workbook:  "my-spreadsheet-id",
 		Worksheet: "Sheet1",
-		Range:     sheet.RangeFromString("A1:C10"),
+		Range:     sheet.MustRangeFromString("A1:C10"),
 	}
 	fmt.Printf("IsRange: %v\n", rngSpec.IsRange())                   // true
 	fmt.Printf("InSheetRef: %v\n", rngSpec.GetInSheetDataSpec())     // Sheet1!A1:C10
 	fmt.Printf("String: %v\n", rngSpec.String())                     // Workbook: my-spreadsheet-id, Worksheet: Sheet1, Range: A1:C10
 
 	// DataRange utilities
-	r := sheet.RangeFromString("A1:D10")
+	r := sheet.MustRangeFromString("A1:D10")
 	cols, rows := r.SizeXY()
 	fmt.Printf("Range %v: %d cols x %d rows, fixed=%v\n", r.String(), cols, rows, r.IsFixedSize())
 }
@@ -2,10 +2,20 @@ package sheet
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/spf13/viper"
 )
 
+// AliasStore is somewhere aliases are kept. GetAlias and DeleteAlias return ErrAliasNotFound
+// for aliases that don't exist.
+type AliasStore interface {
+	GetAlias(name string) (*DataSpec, error)
+	SetAlias(name string, spec *DataSpec) error
+	DeleteAlias(name string) error
+	GetAllAliases() (map[string]*DataSpec, error)
+}
+
 func aliasValid(name string, _ *DataSpec) error {
 	// Simple checks
 	if name == "" {
@@ -15,80 +25,159 @@ func aliasValid(name string, _ *DataSpec) error {
 	return nil
 }
 
-func SetAlias(name string, spec *DataSpec) error {
+// ViperAliasStore keeps aliases in the 'aliases' item of a viper config, as the CLI does.
+type ViperAliasStore struct {
+	v *viper.Viper
+}
+
+func NewViperAliasStore(v *viper.Viper) *ViperAliasStore {
+	return &ViperAliasStore{v: v}
+}
+
+// SetAlias sets an alias, and writes the config.
+func (s *ViperAliasStore) SetAlias(name string, spec *DataSpec) error {
 	if err := aliasValid(name, spec); err != nil {
 		return err
 	}
 	// Remove the alias if it exists (i.e. ignore errors)
-	DeleteAlias(name)
+	s.DeleteAlias(name)
 	if spec.Workbook != "" {
-		viper.Set("aliases."+name+".workbook", spec.Workbook)
+		s.v.Set("aliases."+name+".workbook", spec.Workbook)
 	}
 	if spec.Worksheet != "" {
-		viper.Set("aliases."+name+".worksheet", spec.Worksheet)
+		s.v.Set("aliases."+name+".worksheet", spec.Worksheet)
 	}
 	if (spec.Range != DataRange{}) {
-		viper.Set("aliases."+name+".range", spec.Range.String())
+		s.v.Set("aliases."+name+".range", spec.Range.String())
 	}
-	viper.WriteConfig()
-	return nil
+	if spec.NamedRange != "" {
+		s.v.Set("aliases."+name+".namedrange", spec.NamedRange)
+	}
+	return s.v.WriteConfig()
 }
 
-func GetAlias(name string) (*DataSpec, error) {
+func dataSpecFromAliasConfig(alias map[string]interface{}) (*DataSpec, error) {
 	ret := &DataSpec{}
-	all := viper.GetStringMap("aliases")
-	for k := range all {
-		if k == name {
-			alias := all[k].(map[string]interface{})
-			for k, v := range alias {
-				if k == "workbook" {
-					ret.Workbook = v.(string)
-				}
-				if k == "worksheet" {
-					ret.Worksheet = v.(string)
-				}
-				if k == "range" {
-					_, err := ret.Range.FromString(v.(string))
-					if err != nil {
-						return nil, err
-					}
-				}
+	for k, v := range alias {
+		if k == "workbook" {
+			ret.Workbook = v.(string)
+		}
+		if k == "worksheet" {
+			ret.Worksheet = v.(string)
+		}
+		if k == "namedrange" {
+			ret.NamedRange = v.(string)
+		}
+		if k == "range" {
+			_, err := ret.Range.FromString(v.(string))
+			if err != nil {
+				return nil, err
 			}
-			return ret, nil
 		}
 	}
-	return nil, fmt.Errorf("alias not found: %v", name)
+	return ret, nil
 }
 
-func GetAllAliases() map[string]*DataSpec {
+func (s *ViperAliasStore) GetAlias(name string) (*DataSpec, error) {
+	all := s.v.GetStringMap("aliases")
+	for k := range all {
+		// Deleted aliases are left as nil
+		if alias, ok := all[k].(map[string]interface{}); ok && k == name {
+			return dataSpecFromAliasConfig(alias)
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrAliasNotFound, name)
+}
+
+func (s *ViperAliasStore) GetAllAliases() (map[string]*DataSpec, error) {
 	ret := map[string]*DataSpec{}
-	all := viper.GetStringMap("aliases")
+	all := s.v.GetStringMap("aliases")
 	for k := range all {
-		alias := all[k].(map[string]interface{})
-		spec := &DataSpec{}
-		for k, v := range alias {
-			if k == "workbook" {
-				spec.Workbook = v.(string)
-			}
-			if k == "worksheet" {
-				spec.Worksheet = v.(string)
-			}
-			if k == "range" {
-				_, err := spec.Range.FromString(v.(string))
-				if err != nil {
-					return nil
-				}
-			}
+		alias, ok := all[k].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		spec, err := dataSpecFromAliasConfig(alias)
+		if err != nil {
+			return nil, fmt.Errorf("bad range in alias %v: %w", k, err)
 		}
 		ret[k] = spec
 	}
-	return ret
+	return ret, nil
 }
 
-func DeleteAlias(name string) error {
-	if _, err := GetAlias(name); err != nil {
+func (s *ViperAliasStore) DeleteAlias(name string) error {
+	if _, err := s.GetAlias(name); err != nil {
 		return err
 	}
-	viper.Set("aliases."+name, nil)
+	s.v.Set("aliases."+name, nil)
 	return nil
 }
+
+// MemoryAliasStore keeps aliases in memory, e.g. for services that manage their own configuration.
+type MemoryAliasStore struct {
+	mu      sync.Mutex
+	aliases map[string]DataSpec
+}
+
+func NewMemoryAliasStore() *MemoryAliasStore {
+	return &MemoryAliasStore{aliases: map[string]DataSpec{}}
+}
+
+func (s *MemoryAliasStore) SetAlias(name string, spec *DataSpec) error {
+	if err := aliasValid(name, spec); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases[name] = *spec
+	return nil
+}
+
+func (s *MemoryAliasStore) GetAlias(name string) (*DataSpec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spec, ok := s.aliases[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrAliasNotFound, name)
+	}
+	return &spec, nil
+}
+
+func (s *MemoryAliasStore) GetAllAliases() (map[string]*DataSpec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := map[string]*DataSpec{}
+	for k, spec := range s.aliases {
+		ret[k] = &spec
+	}
+	return ret, nil
+}
+
+func (s *MemoryAliasStore) DeleteAlias(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.aliases[name]; !ok {
+		return fmt.Errorf("%w: %v", ErrAliasNotFound, name)
+	}
+	delete(s.aliases, name)
+	return nil
+}
+
+// These use the aliases in the global viper config.
+
+func SetAlias(name string, spec *DataSpec) error {
+	return DefaultClient().Aliases.SetAlias(name, spec)
+}
+
+func GetAlias(name string) (*DataSpec, error) {
+	return DefaultClient().Aliases.GetAlias(name)
+}
+
+func GetAllAliases() (map[string]*DataSpec, error) {
+	return DefaultClient().Aliases.GetAllAliases()
+}
+
+func DeleteAlias(name string) error {
+	return DefaultClient().Aliases.DeleteAlias(name)
+}
@@ -30,7 +30,7 @@ func TestGetAlias(t *testing.T) {
 		{
 			name:    "AllFields",
 			args:    args{name: "myrange"},
-			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: RangeFromString("A1:B2")},
+			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A1:B2")},
 			wantErr: false,
 		},
 	}
@@ -74,9 +74,21 @@ func TestSetAlias(t *testing.T) {
 		},
 		{
 			name:      "ReplaceAllFields",
-			args:      args{name: "myrange", spec: &DataSpec{Workbook: "a", Worksheet: "b", Range: RangeFromString("A1:B2")}},
+			args:      args{name: "myrange", spec: &DataSpec{Workbook: "a", Worksheet: "b", Range: MustRangeFromString("A1:B2")}},
 			wantErr:   false,
-			wantAfter: &DataSpec{Workbook: "a", Worksheet: "b", Range: RangeFromString("A1:B2")},
+			wantAfter: &DataSpec{Workbook: "a", Worksheet: "b", Range: MustRangeFromString("A1:B2")},
+		},
+		{
+			name:      "NamedRange",
+			args:      args{name: "totals", spec: &DataSpec{Workbook: "a", NamedRange: "Totals"}},
+			wantErr:   false,
+			wantAfter: &DataSpec{Workbook: "a", NamedRange: "Totals"},
+		},
+		{
+			name:      "WorksheetNeedingQuotes",
+			args:      args{name: "sales", spec: &DataSpec{Workbook: "a", Worksheet: "Q1 'Sales'!", Range: MustRangeFromString("A1:B2")}},
+			wantErr:   false,
+			wantAfter: &DataSpec{Workbook: "a", Worksheet: "Q1 'Sales'!", Range: MustRangeFromString("A1:B2")},
 		},
 	}
 	SetupTempConfig(t, "alias")
@@ -162,7 +174,7 @@ func TestGetAllAliases(t *testing.T) {
 		{
 			name:   "AllAliases",
 			config: "alias_small",
-			want:   map[string]*DataSpec{"a": {Workbook: "a"}, "b": {Workbook: "b", Worksheet: "c", Range: RangeFromString("A1:B2")}},
+			want:   map[string]*DataSpec{"a": {Workbook: "a"}, "b": {Workbook: "b", Worksheet: "c", Range: MustRangeFromString("A1:B2")}},
 		},
 		{
 			name:   "NoAliases",
@@ -173,7 +185,11 @@ func TestGetAllAliases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			SetupTempConfig(t, tt.config)
-			if got := GetAllAliases(); !reflect.DeepEqual(got, tt.want) {
+			got, err := GetAllAliases()
+			if err != nil {
+				t.Fatalf("GetAllAliases() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetAllAliases() = %v, want %v", got, tt.want)
 			}
 		})
@@ -3,84 +3,165 @@ package sheet
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
-	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
 
-func GetService() (*sheets.Service, error) {
-	ctx := context.Background()
+// If modifying these scopes, delete your previously saved token.json.
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
 
-	secretfile := viper.Get("clientsecretfile")
-	tokenfile := viper.Get("authtokenfile")
-	if secretfile == nil {
-		log.Fatalf("No client secret file found. Please set in config or --clientsecretfile.")
+// Implement an enum-a-like for the --auth-mode flag
+type AuthMode string
+
+const (
+	// Interactive installed-app OAuth flow, with the token cached in authtokenfile.
+	OAuthMode AuthMode = "oauth"
+	// A service account key file (keyfile). Never prompts.
+	ServiceAccountMode AuthMode = "service-account"
+	// Application Default Credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud, or the metadata server). Never prompts.
+	ADCMode AuthMode = "adc"
+)
+
+func (m *AuthMode) String() string { return string(*m) }
+func (m *AuthMode) Type() string   { return "AuthMode" }
+func (m *AuthMode) Set(v string) error {
+	switch v {
+	case "oauth", "service-account", "adc":
+		*m = AuthMode(v)
+		return nil
+	default:
+		return errors.New("invalid AuthMode. Allowed [oauth|service-account|adc]")
+	}
+}
+
+// AuthConfig says how to authenticate, as the auth-mode, clientsecretfile, authtokenfile, oauth-flow
+// and keyfile config items do for the CLI.
+type AuthConfig struct {
+	// Defaults to OAuthMode.
+	Mode AuthMode
+	// For OAuthMode.
+	ClientSecretFile string
+	AuthTokenFile    string
+	// How to get a new oauth token, if there isn't one in AuthTokenFile. Defaults to LoopbackFlow.
+	OAuthFlow OAuthFlow
+	// For ServiceAccountMode.
+	KeyFile string
+}
+
+func authConfigFromViper(v *viper.Viper) AuthConfig {
+	return AuthConfig{
+		Mode:             AuthMode(v.GetString("auth-mode")),
+		ClientSecretFile: v.GetString("clientsecretfile"),
+		AuthTokenFile:    v.GetString("authtokenfile"),
+		OAuthFlow:        OAuthFlow(v.GetString("oauth-flow")),
+		KeyFile:          v.GetString("keyfile"),
 	}
+}
 
-	if tokenfile == nil {
-		log.Fatalf("No auth token file found. Please set in config or --authtokenfile")
+// HTTPClient returns an authenticated client for use with the Sheets API.
+// ctx is only used while authenticating, e.g. it can cancel waiting for the oauth flow.
+// The client isn't tied to it.
+func (a AuthConfig) HTTPClient(ctx context.Context) (*http.Client, error) {
+	switch a.Mode {
+	case "", OAuthMode:
+		if a.ClientSecretFile == "" {
+			return nil, fmt.Errorf("no client secret file found. Please set in config or --clientsecretfile")
+		}
+		if a.AuthTokenFile == "" {
+			return nil, fmt.Errorf("no auth token file found. Please set in config or --authtokenfile")
+		}
+		flow := LoopbackFlow
+		if a.OAuthFlow != "" {
+			flow = a.OAuthFlow
+		}
+		return getClientWithFlow(ctx, a.ClientSecretFile, a.AuthTokenFile, flow)
+	case ServiceAccountMode:
+		if a.KeyFile == "" {
+			return nil, fmt.Errorf("no service account key file found. Please set in config or --keyfile")
+		}
+		return GetServiceAccountClient(a.KeyFile)
+	case ADCMode:
+		return GetDefaultClient()
+	default:
+		return nil, fmt.Errorf("unknown auth-mode: %v", a.Mode)
 	}
+}
 
-	client := GetClient(secretfile.(string), tokenfile.(string))
+// GetService returns a Sheets service, authenticated according to the auth-mode config item
+// (and clientsecretfile/authtokenfile or keyfile, as appropriate).
+func GetService(ctx context.Context) (*sheets.Service, error) {
+	return DefaultClient().Service(ctx)
+}
+
+// GetServiceAccountClient returns a client authenticated as the service account in keyfile
+// (a JSON key, as downloaded from the cloud console). The spreadsheets used must be shared
+// with the service account's email address.
+func GetServiceAccountClient(keyfile string) (*http.Client, error) {
+	b, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account key file: %w", err)
+	}
+	config, err := google.JWTConfigFromJSON(b, sheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse service account key file: %w", err)
+	}
+	return config.Client(context.Background()), nil
+}
 
-	srv, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+// GetDefaultClient returns a client authenticated with Application Default Credentials.
+func GetDefaultClient() (*http.Client, error) {
+	client, err := google.DefaultClient(context.Background(), sheetsScope)
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
+		return nil, fmt.Errorf("unable to find application default credentials: %w", err)
 	}
-	return srv, nil
+	return client, nil
 }
 
 // This is mostly copy-pasted from Google's quickstart guide, because I am a serious programmer.
 // https://developers.google.com/sheets/api/quickstart/go
 
 // Retrieve a token, saves the token, then returns the generated client.
-func GetClient(secretfile string, tokfile string) *http.Client {
+// If there's no saved token, the user is sent through the loopback flow to get one.
+func GetClient(secretfile string, tokfile string) (*http.Client, error) {
+	return GetClientWithFlow(secretfile, tokfile, LoopbackFlow)
+}
+
+// GetClientWithFlow is GetClient, with the choice of oauth flow used if there's no saved token.
+func GetClientWithFlow(secretfile string, tokfile string, flow OAuthFlow) (*http.Client, error) {
+	return getClientWithFlow(context.Background(), secretfile, tokfile, flow)
+}
+
+func getClientWithFlow(ctx context.Context, secretfile string, tokfile string, flow OAuthFlow) (*http.Client, error) {
 	// The file token.json stores the user's access and refresh tokens, and is
 	// created automatically when the authorization flow completes for the first
 	// time.
 	b, err := os.ReadFile(secretfile)
 	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+		return nil, fmt.Errorf("unable to read client secret file: %w", err)
 	}
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, "https://www.googleapis.com/auth/spreadsheets")
+	config, err := google.ConfigFromJSON(b, sheetsScope)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		return nil, fmt.Errorf("unable to parse client secret file to config: %w", err)
 	}
 
 	tok, err := tokenFromFile(tokfile)
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokfile, tok)
+		tok, err = getTokenFromWeb(ctx, config, flow)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveToken(tokfile, tok); err != nil {
+			return nil, err
+		}
 	}
-	return config.Client(context.Background(), tok)
-}
-
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-	fmt.Printf("Note: If the auth flow redirects to localhost, copy the " +
-		"'code' parameter from the localhost URL and paste it here.\n")
-
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code: %v", err)
-	}
-
-	tok, err := config.Exchange(context.TODO(), authCode)
-	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
-	}
-	return tok
+	return config.Client(context.Background(), tok), nil
 }
 
 // Retrieves a token from a local file.
@@ -96,12 +177,15 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 }
 
 // Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
-	fmt.Printf("Saving credential file to: %s\n", path)
+func saveToken(path string, token *oauth2.Token) error {
+	fmt.Fprintf(authPromptOutput, "Saving credential file to: %s\n", path)
 	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		return fmt.Errorf("unable to cache oauth token: %w", err)
 	}
 	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+	if err := json.NewEncoder(f).Encode(token); err != nil {
+		return fmt.Errorf("unable to cache oauth token: %w", err)
+	}
+	return nil
 }
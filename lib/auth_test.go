@@ -0,0 +1,92 @@
+package sheet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestAuthMode_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		wantErr bool
+	}{
+		{name: "OAuth", v: "oauth"},
+		{name: "ServiceAccount", v: "service-account"},
+		{name: "ADC", v: "adc"},
+		{name: "Unknown", v: "kerberos", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m AuthMode
+			if err := m.Set(tt.v); (err != nil) != tt.wantErr {
+				t.Errorf("AuthMode.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthConfig_HTTPClient(t *testing.T) {
+	tokenfile := t.TempDir() + "/token.json"
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "OAuthNoSecretFile",
+			config:  map[string]string{"authtokenfile": tokenfile},
+			wantErr: true,
+		},
+		{
+			name:    "OAuthNoTokenFile",
+			config:  map[string]string{"clientsecretfile": "testdata/client_secret.json"},
+			wantErr: true,
+		},
+		{
+			name:    "OAuthBadSecretFile",
+			config:  map[string]string{"auth-mode": "oauth", "clientsecretfile": "testdata/service_account.json", "authtokenfile": tokenfile},
+			wantErr: true,
+		},
+		{
+			name:    "ServiceAccount",
+			config:  map[string]string{"auth-mode": "service-account", "keyfile": "testdata/service_account.json"},
+			wantErr: false,
+		},
+		{
+			name:    "ServiceAccountNoKeyFile",
+			config:  map[string]string{"auth-mode": "service-account"},
+			wantErr: true,
+		},
+		{
+			name:    "ServiceAccountMissingKeyFile",
+			config:  map[string]string{"auth-mode": "service-account", "keyfile": "testdata/nosuchfile.json"},
+			wantErr: true,
+		},
+		{
+			// An installed-app secret isn't a service account key.
+			name:    "ServiceAccountWrongKeyType",
+			config:  map[string]string{"auth-mode": "service-account", "keyfile": "testdata/client_secret.json"},
+			wantErr: true,
+		},
+		{
+			name:    "UnknownMode",
+			config:  map[string]string{"auth-mode": "kerberos"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for k, val := range tt.config {
+				v.Set(k, val)
+			}
+			_, err := authConfigFromViper(v).HTTPClient(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuthConfig.HTTPClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
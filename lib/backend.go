@@ -0,0 +1,135 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Backend is the set of Sheets operations used by this package (and the CLI).
+// Every call takes a context, which is applied to the underlying API request.
+// GoogleBackend talks to the real API, MemoryBackend is an in-memory fake for testing.
+type Backend interface {
+	// GetValues reads the values in rng (e.g. "Sheet1!A1:B2") from a workbook.
+	GetValues(ctx context.Context, workbook string, rng string, opts *ReadOptions) (*sheets.ValueRange, error)
+	// UpdateValues writes values to rng, starting at its top-left cell.
+	UpdateValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) error
+	// BatchGetValues reads several ranges from a workbook in one request, returning them in the same order.
+	BatchGetValues(ctx context.Context, workbook string, ranges []string, opts *ReadOptions) ([]*sheets.ValueRange, error)
+	// BatchUpdateValues writes several ValueRanges (each to its Range) to a workbook in one request.
+	BatchUpdateValues(ctx context.Context, workbook string, data []*sheets.ValueRange, input InputOption) error
+	// AppendValues writes values after the last row of data in rng, inserting rows as needed,
+	// and returns the range written to.
+	AppendValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) (string, error)
+	// ClearValues clears the values (but not formatting etc.) in rng.
+	ClearValues(ctx context.Context, workbook string, rng string) error
+	// GetWorkbook returns the workbook's metadata (title, worksheets etc.), but no values.
+	GetWorkbook(ctx context.Context, workbook string) (*sheets.Spreadsheet, error)
+	// CreateWorkbook creates a new workbook and returns its metadata.
+	CreateWorkbook(ctx context.Context, title string) (*sheets.Spreadsheet, error)
+	// BatchUpdate applies structural changes (adding and deleting worksheets etc.) to a workbook.
+	BatchUpdate(ctx context.Context, workbook string, requests []*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error)
+}
+
+// GoogleBackend is a Backend backed by the Google Sheets API.
+type GoogleBackend struct {
+	srv *sheets.Service
+}
+
+func NewGoogleBackend(srv *sheets.Service) *GoogleBackend {
+	return &GoogleBackend{srv: srv}
+}
+
+// GetBackend returns a GoogleBackend, authenticated the same way as GetService.
+func GetBackend(ctx context.Context) (Backend, error) {
+	return DefaultClient().GetBackend(ctx)
+}
+
+func (g *GoogleBackend) GetValues(ctx context.Context, workbook string, rng string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+
+	call := g.srv.Spreadsheets.Values.Get(workbook, rng).ValueRenderOption(opts.ValueRender.apiOption())
+	if opts.ValueRender == UnformattedRender || opts.ValueRender == FormulaRender {
+		call = call.DateTimeRenderOption(opts.DateRender.apiOption())
+	}
+
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from %v: %w", rng, err)
+	}
+	return resp, nil
+}
+
+func (g *GoogleBackend) UpdateValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) error {
+	_, err := g.srv.Spreadsheets.Values.Update(workbook, rng, values).ValueInputOption(input.apiOption()).Context(ctx).Do()
+	return err
+}
+
+func (g *GoogleBackend) BatchGetValues(ctx context.Context, workbook string, ranges []string, opts *ReadOptions) ([]*sheets.ValueRange, error) {
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+
+	call := g.srv.Spreadsheets.Values.BatchGet(workbook).Ranges(ranges...).ValueRenderOption(opts.ValueRender.apiOption())
+	if opts.ValueRender == UnformattedRender || opts.ValueRender == FormulaRender {
+		call = call.DateTimeRenderOption(opts.DateRender.apiOption())
+	}
+
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from %v: %w", strings.Join(ranges, ", "), err)
+	}
+	return resp.ValueRanges, nil
+}
+
+func (g *GoogleBackend) BatchUpdateValues(ctx context.Context, workbook string, data []*sheets.ValueRange, input InputOption) error {
+	req := &sheets.BatchUpdateValuesRequest{Data: data, ValueInputOption: input.apiOption()}
+	_, err := g.srv.Spreadsheets.Values.BatchUpdate(workbook, req).Context(ctx).Do()
+	return err
+}
+
+func (g *GoogleBackend) AppendValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) (string, error) {
+	resp, err := g.srv.Spreadsheets.Values.Append(workbook, rng, values).ValueInputOption(input.apiOption()).InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	if resp.Updates == nil {
+		return "", nil
+	}
+	return resp.Updates.UpdatedRange, nil
+}
+
+func (g *GoogleBackend) ClearValues(ctx context.Context, workbook string, rng string) error {
+	_, err := g.srv.Spreadsheets.Values.Clear(workbook, rng, &sheets.ClearValuesRequest{}).Context(ctx).Do()
+	return err
+}
+
+func (g *GoogleBackend) GetWorkbook(ctx context.Context, workbook string) (*sheets.Spreadsheet, error) {
+	return g.srv.Spreadsheets.Get(workbook).Context(ctx).Do()
+}
+
+func (g *GoogleBackend) CreateWorkbook(ctx context.Context, title string) (*sheets.Spreadsheet, error) {
+	return g.srv.Spreadsheets.Create(&sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{Title: title}}).Context(ctx).Do()
+}
+
+func (g *GoogleBackend) BatchUpdate(ctx context.Context, workbook string, requests []*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	return g.srv.Spreadsheets.BatchUpdate(workbook, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).Context(ctx).Do()
+}
+
+// FindWorksheet returns the properties of the named worksheet in a workbook.
+func FindWorksheet(ctx context.Context, b Backend, workbook string, title string) (*sheets.SheetProperties, error) {
+	wb, err := b.GetWorkbook(ctx, workbook)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve workbook %v: %w", workbook, err)
+	}
+	for _, sh := range wb.Sheets {
+		if sh.Properties.Title == title {
+			return sh.Properties, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v in workbook %v", ErrWorksheetNotFound, title, workbook)
+}
@@ -0,0 +1,228 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// ExpandArgsToDataSpecs is like ExpandArgsToDataSpec, but for arguments naming several worksheets or ranges.
+func ExpandArgsToDataSpecs(args []string) ([]*DataSpec, error) {
+	return DefaultClient().ExpandArgsToDataSpecs(args)
+}
+
+// ExpandArgsToDataSpecs is like ExpandArgsToDataSpec, but for arguments naming several worksheets or ranges, e.g.
+//
+//	wb people 'people!A1:B2' =Totals @otherworkbook Sheet1 @myrange
+//
+// The first argument (if it isn't an alias) is a workbook. Later bare arguments are worksheets, ranges or
+// named ranges in the most recent workbook. Aliases (and links) for a workbook change the workbook that later
+// arguments refer to, so a second workbook must be given as an alias or link. A workbook with nothing after it
+// is returned as it is.
+func (c *Client) ExpandArgsToDataSpecs(args []string) ([]*DataSpec, error) {
+	return c.expandArgsToDataSpecs(args, func(arg string, _ bool) (*DataSpec, error) {
+		workbook, err := workbookFromArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &DataSpec{Workbook: workbook}, nil
+	})
+}
+
+// ResolveArgsToDataSpecs is like ExpandArgsToDataSpecs, but named ranges are resolved, and links pick up
+// their worksheet and range as ResolveArgsToDataSpec does -- unless a worksheet or range follows the link.
+func ResolveArgsToDataSpecs(ctx context.Context, b Backend, args []string) ([]*DataSpec, error) {
+	return clientWithBackend(b).ResolveArgsToDataSpecs(ctx, args)
+}
+
+func (c *Client) ResolveArgsToDataSpecs(ctx context.Context, args []string) ([]*DataSpec, error) {
+	specs, err := c.expandArgsToDataSpecs(args, func(arg string, followed bool) (*DataSpec, error) {
+		if followed {
+			return c.ExpandArgsToDataSpec([]string{arg})
+		}
+		return c.ResolveArgsToDataSpec(ctx, []string{arg})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, spec := range specs {
+		if !spec.IsNamedRange() {
+			continue
+		}
+		b, err := c.GetBackend(ctx)
+		if err != nil {
+			return nil, err
+		}
+		specs[i], err = ResolveNamedRange(ctx, b, spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return specs, nil
+}
+
+// expandArgsToDataSpecs does the work of ExpandArgsToDataSpecs, using workbookArg for arguments that
+// start a new workbook (the first argument, and links), saying whether a worksheet or range follows them.
+func (c *Client) expandArgsToDataSpecs(args []string, workbookArg func(arg string, followed bool) (*DataSpec, error)) ([]*DataSpec, error) {
+	alias_prefix := c.AliasPrefix
+	if alias_prefix == "" {
+		alias_prefix = "@"
+	}
+
+	ret := []*DataSpec{}
+	// The workbook that bare arguments refer to, and whether it's been used by one yet.
+	workbook := ""
+	used := true
+	for i, arg := range args {
+		var spec *DataSpec
+		var err error
+		switch {
+		case strings.HasPrefix(arg, alias_prefix):
+			spec, err = c.dataSpecFromAlias(strings.TrimPrefix(arg, alias_prefix))
+		case i == 0 || IsSpreadsheetURL(arg):
+			followed := i+1 < len(args) && !strings.HasPrefix(args[i+1], alias_prefix) && !IsSpreadsheetURL(args[i+1])
+			spec, err = workbookArg(arg, followed)
+		default:
+			if workbook == "" {
+				return nil, fmt.Errorf("no workbook for %v", arg)
+			}
+			spec, err = (&DataSpec{Workbook: workbook}).FromString(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if spec.Workbook == "" {
+			return nil, fmt.Errorf("no workbook for %v", arg)
+		}
+
+		if !used && spec.Workbook != workbook {
+			// The previous workbook had nothing after it.
+			ret = append(ret, &DataSpec{Workbook: workbook})
+		}
+		workbook = spec.Workbook
+		used = !spec.IsWorkbook()
+		if used {
+			ret = append(ret, spec)
+		}
+	}
+	if !used {
+		ret = append(ret, &DataSpec{Workbook: workbook})
+	}
+	return ret, nil
+}
+
+// BatchGetValues reads the worksheets and ranges in specs, with one request per workbook,
+// and returns their values in the same order as specs. opts may be nil.
+func BatchGetValues(ctx context.Context, b Backend, specs []*DataSpec, opts *ReadOptions) ([]*sheets.ValueRange, error) {
+	return clientWithBackend(b).BatchGetValues(ctx, specs, opts)
+}
+
+func (c *Client) BatchGetValues(ctx context.Context, specs []*DataSpec, opts *ReadOptions) ([]*sheets.ValueRange, error) {
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*sheets.ValueRange, len(specs))
+	for _, group := range groupByWorkbook(specs) {
+		ranges := []string{}
+		for _, i := range group {
+			if specs[i].IsWorkbook() {
+				return nil, fmt.Errorf("can't get values of a workbook: %v", specs[i].String())
+			}
+			ranges = append(ranges, specs[i].GetInSheetDataSpec())
+		}
+		workbook := specs[group[0]].Workbook
+		resp, err := b.BatchGetValues(ctx, workbook, ranges, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) != len(group) {
+			return nil, fmt.Errorf("asked for %d ranges from %v, got %d", len(group), workbook, len(resp))
+		}
+		for j, i := range group {
+			ret[i] = resp[j]
+		}
+	}
+	return ret, nil
+}
+
+// groupByWorkbook returns the indexes of specs, grouped by workbook in the order each is first seen.
+func groupByWorkbook(specs []*DataSpec) [][]int {
+	ret := [][]int{}
+	groups := map[string]int{}
+	for i, spec := range specs {
+		g, ok := groups[spec.Workbook]
+		if !ok {
+			g = len(ret)
+			groups[spec.Workbook] = g
+			ret = append(ret, nil)
+		}
+		ret[g] = append(ret[g], i)
+	}
+	return ret
+}
+
+// RangeData is data to be written to a range, for WriteDataToRanges.
+type RangeData struct {
+	Spec *DataSpec
+	Data [][]string
+}
+
+// WriteDataToRanges replaces the contents of several fixed-size ranges, with one request per workbook.
+// As with WriteDataToRange, the data must fit in each range, and the rest of the range is cleared.
+// Nothing is written unless all of the data fits. opts may be nil.
+func WriteDataToRanges(ctx context.Context, b Backend, writes []RangeData, opts *WriteOptions) error {
+	return clientWithBackend(b).WriteDataToRanges(ctx, writes, opts)
+}
+
+func (c *Client) WriteDataToRanges(ctx context.Context, writes []RangeData, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	specs := []*DataSpec{}
+	for _, w := range writes {
+		if !w.Spec.IsRange() || !w.Spec.Range.IsFixedSize() {
+			return fmt.Errorf("not a fixed-size range: %v", w.Spec.String())
+		}
+		if err := checkDataFitsInRange(w.Spec, w.Data); err != nil {
+			return fmt.Errorf("can't write to %v: %w", w.Spec.GetInSheetDataSpec(), err)
+		}
+		specs = append(specs, w.Spec)
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groupByWorkbook(specs) {
+		data := []*sheets.ValueRange{}
+		for _, i := range group {
+			vr := valueRangeForWrite(padToRange(writes[i].Spec.Range, writes[i].Data), opts)
+			vr.Range = writes[i].Spec.GetInSheetDataSpec()
+			data = append(data, vr)
+		}
+		workbook := specs[group[0]].Workbook
+		if err := b.BatchUpdateValues(ctx, workbook, data, opts.InputOption); err != nil {
+			return fmt.Errorf("unable to write data to %v: %w", workbook, err)
+		}
+	}
+	return nil
+}
+
+// padToRange pads data with empty cells to fill a fixed-size range, so writing it clears the rest of the range.
+func padToRange(r DataRange, data [][]string) [][]string {
+	cols, rows := r.SizeXY()
+	ret := make([][]string, rows)
+	for i := range ret {
+		ret[i] = make([]string, cols)
+		if i < len(data) {
+			copy(ret[i], data[i])
+		}
+	}
+	return ret
+}
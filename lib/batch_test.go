@@ -0,0 +1,233 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgsToDataSpecs(t *testing.T) {
+	aliases := NewMemoryAliasStore()
+	aliases.SetAlias("wb", &DataSpec{Workbook: "mywb"})
+	aliases.SetAlias("other", &DataSpec{Workbook: "otherwb"})
+	aliases.SetAlias("ws", &DataSpec{Workbook: "otherwb", Worksheet: "myws"})
+	c := &Client{Aliases: aliases}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []*DataSpec
+		wantErr bool
+	}{
+		{
+			name: "Single",
+			args: []string{"mywb", "myws"},
+			want: []*DataSpec{{Workbook: "mywb", Worksheet: "myws"}},
+		},
+		{
+			name: "Workbook",
+			args: []string{"@wb"},
+			want: []*DataSpec{{Workbook: "mywb"}},
+		},
+		{
+			name: "Several",
+			args: []string{"mywb", "a", "b!A1:B2", "=Totals"},
+			want: []*DataSpec{
+				{Workbook: "mywb", Worksheet: "a"},
+				{Workbook: "mywb", Worksheet: "b", Range: MustRangeFromString("A1:B2")},
+				{Workbook: "mywb", NamedRange: "Totals"},
+			},
+		},
+		{
+			name: "SeveralWorkbooks",
+			args: []string{"@wb", "a", "@other", "b", "@ws!A1:A2"},
+			want: []*DataSpec{
+				{Workbook: "mywb", Worksheet: "a"},
+				{Workbook: "otherwb", Worksheet: "b"},
+				{Workbook: "otherwb", Worksheet: "myws", Range: MustRangeFromString("A1:A2")},
+			},
+		},
+		{
+			name: "WorksheetAliasSetsWorkbook",
+			args: []string{"@ws", "b"},
+			want: []*DataSpec{
+				{Workbook: "otherwb", Worksheet: "myws"},
+				{Workbook: "otherwb", Worksheet: "b"},
+			},
+		},
+		{
+			name: "UnusedWorkbook",
+			args: []string{"@wb", "@other", "b"},
+			want: []*DataSpec{
+				{Workbook: "mywb"},
+				{Workbook: "otherwb", Worksheet: "b"},
+			},
+		},
+		{
+			name: "Link",
+			args: []string{"mywb", "a", "https://docs.google.com/spreadsheets/d/linked/edit", "b"},
+			want: []*DataSpec{
+				{Workbook: "mywb", Worksheet: "a"},
+				{Workbook: "linked", Worksheet: "b"},
+			},
+		},
+		{
+			name:    "BadAlias",
+			args:    []string{"mywb", "a", "@nope"},
+			wantErr: true,
+		},
+		{
+			name:    "BadRange",
+			args:    []string{"mywb", "a!A0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ExpandArgsToDataSpecs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandArgsToDataSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandArgsToDataSpecs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveArgsToDataSpecs(t *testing.T) {
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "first", "second")
+	ctx := context.Background()
+	if err := SetNamedRange(ctx, b, "Totals", &DataSpec{Workbook: "wb", Worksheet: "second", Range: MustRangeFromString("A1:B2")}); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+	link := "https://docs.google.com/spreadsheets/d/wb/edit#gid=0&range=C3"
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []*DataSpec
+		wantErr error
+	}{
+		{
+			name: "NamedRange",
+			args: []string{"wb", "first", "=totals"},
+			want: []*DataSpec{
+				{Workbook: "wb", Worksheet: "first"},
+				{Workbook: "wb", Worksheet: "second", Range: MustRangeFromString("A1:B2"), NamedRange: "Totals"},
+			},
+		},
+		{
+			name: "LinkOnItsOwn",
+			args: []string{link},
+			want: []*DataSpec{{Workbook: "wb", Worksheet: "first", Range: MustRangeFromString("C3")}},
+		},
+		{
+			name: "LinkFollowedByRange",
+			args: []string{link, "second!A1"},
+			want: []*DataSpec{{Workbook: "wb", Worksheet: "second", Range: MustRangeFromString("A1")}},
+		},
+		{
+			name:    "NoSuchNamedRange",
+			args:    []string{"wb", "=nope"},
+			wantErr: ErrNamedRangeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveArgsToDataSpecs(ctx, b, tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveArgsToDataSpecs() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveArgsToDataSpecs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchGetValues(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb1", "ws")
+	m.AddWorkbook("wb2", "ws")
+	m.SetValues("wb1", "ws", [][]interface{}{{"a", "b"}, {"c", "d"}})
+	m.SetValues("wb2", "ws", [][]interface{}{{"x", "y"}})
+
+	specs := []*DataSpec{
+		{Workbook: "wb1", Worksheet: "ws", Range: MustRangeFromString("B1:B2")},
+		{Workbook: "wb2", Worksheet: "ws"},
+		{Workbook: "wb1", Worksheet: "ws", Range: MustRangeFromString("A2")},
+	}
+	got, err := BatchGetValues(context.Background(), m, specs, nil)
+	if err != nil {
+		t.Fatalf("BatchGetValues() error = %v", err)
+	}
+	want := [][][]interface{}{{{"b"}, {"d"}}, {{"x", "y"}}, {{"c"}}}
+	if len(got) != len(want) {
+		t.Fatalf("BatchGetValues() returned %d ranges, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i].Values, want[i]) {
+			t.Errorf("BatchGetValues()[%d] = %#v, want %#v", i, got[i].Values, want[i])
+		}
+	}
+
+	if _, err := BatchGetValues(context.Background(), m, []*DataSpec{{Workbook: "wb1"}}, nil); err == nil {
+		t.Errorf("BatchGetValues() of a workbook error = nil, want error")
+	}
+}
+
+func TestWriteDataToRanges(t *testing.T) {
+	old := [][]interface{}{{"old", "old", "old", "old"}, {"old", "old", "old", "old"}}
+	tests := []struct {
+		name    string
+		writes  map[string][][]string
+		want    [][]interface{}
+		wantErr bool
+		// If set, the error must wrap this.
+		wantErrIs error
+	}{
+		{
+			name:   "ClearsRestOfRanges",
+			writes: map[string][][]string{"A1:B1": {{"x"}}, "C2:D2": {{"y", "z"}}},
+			want:   [][]interface{}{{"x", "", "old", "old"}, {"old", "old", "y", "z"}},
+		},
+		{
+			name:      "OverflowWritesNothing",
+			writes:    map[string][][]string{"A1:B1": {{"x"}}, "C2:D2": {{"y", "z", "!"}}},
+			want:      old,
+			wantErr:   true,
+			wantErrIs: ErrDataOverflow,
+		},
+		{
+			name:    "NotFixedSize",
+			writes:  map[string][][]string{"A:B": {{"x"}}},
+			want:    old,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryBackend()
+			m.AddWorkbook("wb", "ws")
+			m.SetValues("wb", "ws", old)
+			writes := []RangeData{}
+			for rng, data := range tt.writes {
+				writes = append(writes, RangeData{Spec: &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString(rng)}, Data: data})
+			}
+			err := WriteDataToRanges(context.Background(), m, writes, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteDataToRanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("WriteDataToRanges() error = %v, want %v", err, tt.wantErrIs)
+			}
+			got, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("after WriteDataToRanges() = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
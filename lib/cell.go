@@ -0,0 +1,175 @@
+package sheet
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+type CellKind int
+
+const (
+	EmptyCell CellKind = iota
+	StringCell
+	NumberCell
+	BoolCell
+	FormulaCell
+	ErrorCell
+)
+
+func (k CellKind) String() string {
+	switch k {
+	case EmptyCell:
+		return "empty"
+	case StringCell:
+		return "string"
+	case NumberCell:
+		return "number"
+	case BoolCell:
+		return "bool"
+	case FormulaCell:
+		return "formula"
+	case ErrorCell:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Cell is a single typed cell value.
+// Text holds the value of string, formula (including the leading '=') and error cells.
+type Cell struct {
+	Kind   CellKind
+	Text   string
+	Number float64
+	Bool   bool
+}
+
+// The error values sheets will show in a cell.
+var cellErrors = map[string]bool{
+	"#NULL!":  true,
+	"#DIV/0!": true,
+	"#VALUE!": true,
+	"#REF!":   true,
+	"#NAME?":  true,
+	"#NUM!":   true,
+	"#N/A":    true,
+	"#ERROR!": true,
+}
+
+// Numbers as we'll accept them from text input. We're stricter than strconv.ParseFloat here,
+// so things like zip codes ("01234"), "NaN" and "0x10" stay as strings.
+var numberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func NewStringCell(s string) Cell  { return Cell{Kind: StringCell, Text: s} }
+func NewNumberCell(n float64) Cell { return Cell{Kind: NumberCell, Number: n} }
+func NewBoolCell(b bool) Cell      { return Cell{Kind: BoolCell, Bool: b} }
+func NewFormulaCell(f string) Cell { return Cell{Kind: FormulaCell, Text: f} }
+func NewErrorCell(e string) Cell   { return Cell{Kind: ErrorCell, Text: e} }
+
+// CellFromValue converts a value as returned by the Sheets API (which depends on the value render option)
+// into a Cell.
+func CellFromValue(v interface{}) Cell {
+	switch val := v.(type) {
+	case nil:
+		return Cell{}
+	case bool:
+		return NewBoolCell(val)
+	case float64:
+		return NewNumberCell(val)
+	case int:
+		return NewNumberCell(float64(val))
+	case int64:
+		return NewNumberCell(float64(val))
+	case string:
+		return textCell(val)
+	default:
+		return NewStringCell(fmt.Sprint(val))
+	}
+}
+
+// ParseCell infers a typed Cell from text input (i.e. from a csv file).
+func ParseCell(s string) Cell {
+	if numberRegex.MatchString(s) {
+		n, err := strconv.ParseFloat(s, 64)
+		if err == nil && !math.IsInf(n, 0) {
+			return NewNumberCell(n)
+		}
+	}
+	switch strings.ToUpper(s) {
+	case "TRUE":
+		return NewBoolCell(true)
+	case "FALSE":
+		return NewBoolCell(false)
+	}
+	return textCell(s)
+}
+
+func textCell(s string) Cell {
+	if s == "" {
+		return Cell{}
+	}
+	if len(s) > 1 && s[0] == '=' {
+		return NewFormulaCell(s)
+	}
+	if cellErrors[s] {
+		return NewErrorCell(s)
+	}
+	return NewStringCell(s)
+}
+
+// String renders the cell as text, as sheets would show it unformatted.
+func (c Cell) String() string {
+	switch c.Kind {
+	case NumberCell:
+		return strconv.FormatFloat(c.Number, 'f', -1, 64)
+	case BoolCell:
+		if c.Bool {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return c.Text
+	}
+}
+
+// Value returns the cell as a value for the Sheets API (or for json output).
+func (c Cell) Value() interface{} {
+	switch c.Kind {
+	case EmptyCell:
+		return ""
+	case NumberCell:
+		return c.Number
+	case BoolCell:
+		return c.Bool
+	default:
+		return c.Text
+	}
+}
+
+// CellsFromValueRange converts all values in a ValueRange to Cells.
+func CellsFromValueRange(v *sheets.ValueRange) [][]Cell {
+	ret := make([][]Cell, len(v.Values))
+	for i, row := range v.Values {
+		ret[i] = make([]Cell, len(row))
+		for j := range row {
+			ret[i][j] = CellFromValue(row[j])
+		}
+	}
+	return ret
+}
+
+func valueRangeFromCells(data [][]Cell) *sheets.ValueRange {
+	sheet_values := make([][]interface{}, len(data))
+	for i, row := range data {
+		sheet_values[i] = make([]interface{}, len(row))
+		for j, cell := range row {
+			sheet_values[i][j] = cell.Value()
+		}
+	}
+	return &sheets.ValueRange{Values: sheet_values}
+}
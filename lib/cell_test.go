@@ -0,0 +1,104 @@
+package sheet
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestCellFromValue(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want Cell
+	}{
+		{name: "Nil", v: nil, want: Cell{}},
+		{name: "EmptyString", v: "", want: Cell{}},
+		{name: "String", v: "hello", want: NewStringCell("hello")},
+		{name: "NumericString", v: "30", want: NewStringCell("30")},
+		{name: "Number", v: 1.5, want: NewNumberCell(1.5)},
+		{name: "Int", v: 3, want: NewNumberCell(3)},
+		{name: "Bool", v: true, want: NewBoolCell(true)},
+		{name: "Formula", v: "=SUM(A1:A3)", want: NewFormulaCell("=SUM(A1:A3)")},
+		{name: "JustEquals", v: "=", want: NewStringCell("=")},
+		{name: "Error", v: "#DIV/0!", want: NewErrorCell("#DIV/0!")},
+		{name: "HashString", v: "#hashtag", want: NewStringCell("#hashtag")},
+		{name: "Other", v: []int{1}, want: NewStringCell("[1]")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CellFromValue(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CellFromValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want Cell
+	}{
+		{name: "Empty", s: "", want: Cell{}},
+		{name: "String", s: "hello", want: NewStringCell("hello")},
+		{name: "Integer", s: "30", want: NewNumberCell(30)},
+		{name: "Negative", s: "-2.5", want: NewNumberCell(-2.5)},
+		{name: "Exponent", s: "1e3", want: NewNumberCell(1000)},
+		{name: "Zero", s: "0", want: NewNumberCell(0)},
+		{name: "LeadingZero", s: "01234", want: NewStringCell("01234")},
+		{name: "LeadingPlus", s: "+5", want: NewStringCell("+5")},
+		{name: "NaN", s: "NaN", want: NewStringCell("NaN")},
+		{name: "Hex", s: "0x10", want: NewStringCell("0x10")},
+		{name: "Huge", s: "1e999", want: NewStringCell("1e999")},
+		{name: "True", s: "TRUE", want: NewBoolCell(true)},
+		{name: "LowerFalse", s: "false", want: NewBoolCell(false)},
+		{name: "Formula", s: "=A1+1", want: NewFormulaCell("=A1+1")},
+		{name: "Error", s: "#N/A", want: NewErrorCell("#N/A")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCell(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCell() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCell_StringAndValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		cell      Cell
+		wantStr   string
+		wantValue interface{}
+	}{
+		{name: "Empty", cell: Cell{}, wantStr: "", wantValue: ""},
+		{name: "String", cell: NewStringCell("x"), wantStr: "x", wantValue: "x"},
+		{name: "Integer", cell: NewNumberCell(30), wantStr: "30", wantValue: float64(30)},
+		{name: "Fraction", cell: NewNumberCell(0.25), wantStr: "0.25", wantValue: 0.25},
+		{name: "Large", cell: NewNumberCell(1e21), wantStr: "1000000000000000000000", wantValue: 1e21},
+		{name: "True", cell: NewBoolCell(true), wantStr: "TRUE", wantValue: true},
+		{name: "False", cell: NewBoolCell(false), wantStr: "FALSE", wantValue: false},
+		{name: "Formula", cell: NewFormulaCell("=1+1"), wantStr: "=1+1", wantValue: "=1+1"},
+		{name: "Error", cell: NewErrorCell("#REF!"), wantStr: "#REF!", wantValue: "#REF!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cell.String(); got != tt.wantStr {
+				t.Errorf("Cell.String() = %v, want %v", got, tt.wantStr)
+			}
+			if got := tt.cell.Value(); !reflect.DeepEqual(got, tt.wantValue) {
+				t.Errorf("Cell.Value() = %#v, want %#v", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestCellsFromValueRange(t *testing.T) {
+	v := &sheets.ValueRange{Values: [][]interface{}{{"a", 1.0}, {true, nil, "=B1"}}}
+	want := [][]Cell{{NewStringCell("a"), NewNumberCell(1)}, {NewBoolCell(true), {}, NewFormulaCell("=B1")}}
+	if got := CellsFromValueRange(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("CellsFromValueRange() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/spf13/viper"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Client holds everything needed to work with sheets: credentials (or a Backend), where aliases
+// are kept, and how worksheets are protected. The package-level functions use DefaultClient,
+// which is configured from the global viper instance as the CLI is. Make your own Clients to
+// work with several accounts or sets of aliases in one process.
+type Client struct {
+	// How to authenticate, when Backend isn't set.
+	Auth AuthConfig
+	// Used for all reads and writes. If nil, a GoogleBackend is created from Auth when first needed.
+	Backend Backend
+	// Where aliases are looked up. If nil, aliases can't be used.
+	Aliases AliasStore
+	// Marks an alias in arguments (e.g. "@mysheet"). Defaults to "@".
+	AliasPrefix string
+	// Refuse to clear or overwrite whole worksheets, unless forced.
+	ProtectWorksheets bool
+	// How many times to try each API request that fails with a quota or transient error
+	// (see RetryTransport). Defaults to DefaultMaxAttempts.
+	MaxAttempts int
+	// Limit API requests to this many a minute (see RateLimiter). 0 means no limit.
+	RequestsPerMinute int
+	// If set, the rate limit is kept in this file and shared with every other process using it.
+	RateLimitFile string
+
+	mu sync.Mutex
+}
+
+// NewClientFromViper returns a Client configured from v, with the same config items as the CLI.
+// Aliases are kept in v's config.
+func NewClientFromViper(v *viper.Viper) *Client {
+	c := &Client{
+		Auth:              authConfigFromViper(v),
+		Aliases:           NewViperAliasStore(v),
+		AliasPrefix:       v.GetString("alias-spec-prefix"),
+		ProtectWorksheets: v.GetBool("protect-worksheets"),
+		MaxAttempts:       v.GetInt("max-attempts"),
+		RequestsPerMinute: v.GetInt("requests-per-minute"),
+	}
+	if v.GetBool("share-rate-limit") {
+		// If there's no home directory, we just don't share.
+		c.RateLimitFile, _ = DefaultRateLimitFile()
+	}
+	return c
+}
+
+// DefaultClient returns a Client configured from the global viper instance.
+// It's created afresh on each call, so it sees the current config.
+func DefaultClient() *Client {
+	return NewClientFromViper(viper.GetViper())
+}
+
+// Service returns a Sheets service, authenticated according to c.Auth.
+// ctx is only used while authenticating (e.g. it can cancel waiting for an oauth flow).
+func (c *Client) Service(ctx context.Context) (*sheets.Service, error) {
+	client, err := c.Auth.HTTPClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Every API call goes through here, so this is where we rate limit and retry.
+	// Each retry waits for the rate limiter too.
+	transport := client.Transport
+	if c.RequestsPerMinute > 0 {
+		limiter := NewRateLimiter(c.RequestsPerMinute)
+		if c.RateLimitFile != "" {
+			limiter = NewSharedRateLimiter(c.RequestsPerMinute, c.RateLimitFile)
+		}
+		transport = &RateLimitTransport{Base: transport, Limiter: limiter}
+	}
+	client = &http.Client{Transport: NewRetryTransport(transport, c.MaxAttempts)}
+
+	srv, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Sheets client: %w", err)
+	}
+	return srv, nil
+}
+
+// GetBackend returns c.Backend, first creating a GoogleBackend from c.Auth if it's not set.
+func (c *Client) GetBackend(ctx context.Context) (Backend, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Backend == nil {
+		srv, err := c.Service(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.Backend = NewGoogleBackend(srv)
+	}
+	return c.Backend, nil
+}
+
+// clientWithBackend is the DefaultClient, using b. It's used by the package-level functions that take a Backend.
+func clientWithBackend(b Backend) *Client {
+	c := DefaultClient()
+	c.Backend = b
+	return c
+}
@@ -0,0 +1,195 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestClient_Independent(t *testing.T) {
+	// Two clients, each with their own backend and aliases, in one process.
+	clients := map[string]*Client{}
+	for _, tenant := range []string{"one", "two"} {
+		b := NewMemoryBackend()
+		b.AddWorkbook("wb", "ws")
+		aliases := NewMemoryAliasStore()
+		if err := aliases.SetAlias("data", &DataSpec{Workbook: "wb", Worksheet: "ws"}); err != nil {
+			t.Fatalf("SetAlias() error = %v", err)
+		}
+		clients[tenant] = &Client{Backend: b, Aliases: aliases, AliasPrefix: "%"}
+	}
+
+	for tenant, c := range clients {
+		spec, err := c.ExpandArgsToDataSpec([]string{"%data"})
+		if err != nil {
+			t.Fatalf("ExpandArgsToDataSpec() error = %v", err)
+		}
+		if err := c.WriteDataToWorksheet(context.Background(), spec, [][]string{{tenant}}, false, nil); err != nil {
+			t.Fatalf("WriteDataToWorksheet() error = %v", err)
+		}
+	}
+
+	for tenant, c := range clients {
+		resp, err := c.Backend.GetValues(context.Background(), "wb", "ws", nil)
+		if err != nil {
+			t.Fatalf("GetValues() error = %v", err)
+		}
+		if got, _ := FormatValues(resp, CsvFormat); got != tenant+"\n" {
+			t.Errorf("client %v has %q, want %q", tenant, got, tenant+"\n")
+		}
+	}
+}
+
+func TestClient_ExpandArgsToDataSpec(t *testing.T) {
+	aliases := NewMemoryAliasStore()
+	aliases.SetAlias("wb", &DataSpec{Workbook: "mywb"})
+	aliases.SetAlias("ws", &DataSpec{Workbook: "mywb", Worksheet: "myws"})
+
+	tests := []struct {
+		name    string
+		client  *Client
+		args    []string
+		want    *DataSpec
+		wantErr error
+	}{
+		{
+			name:   "DefaultPrefix",
+			client: &Client{Aliases: aliases},
+			args:   []string{"@ws!A1:B2"},
+			want:   &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A1:B2")},
+		},
+		{
+			name:   "LongPrefix",
+			client: &Client{Aliases: aliases, AliasPrefix: "alias:"},
+			args:   []string{"alias:wb", "myws"},
+			want:   &DataSpec{Workbook: "mywb", Worksheet: "myws"},
+		},
+		{
+			name:   "OtherPrefixIsWorkbook",
+			client: &Client{Aliases: aliases, AliasPrefix: "%"},
+			args:   []string{"@wb"},
+			want:   &DataSpec{Workbook: "@wb"},
+		},
+		{
+			name:    "NotFound",
+			client:  &Client{Aliases: aliases},
+			args:    []string{"@nope"},
+			wantErr: ErrAliasNotFound,
+		},
+		{
+			name:    "NoAliasStore",
+			client:  &Client{},
+			args:    []string{"@wb"},
+			wantErr: ErrAliasNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.client.ExpandArgsToDataSpec(tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExpandArgsToDataSpec() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandArgsToDataSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ProtectWorksheets(t *testing.T) {
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "ws")
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+
+	c := &Client{Backend: b, ProtectWorksheets: true}
+	if err := c.ClearWorksheet(context.Background(), spec, false); !errors.Is(err, ErrProtected) {
+		t.Errorf("ClearWorksheet() error = %v, want ErrProtected", err)
+	}
+	if err := c.ClearWorksheet(context.Background(), spec, true); err != nil {
+		t.Errorf("ClearWorksheet() with force error = %v", err)
+	}
+}
+
+func TestClient_GetBackendNoCredentials(t *testing.T) {
+	c := &Client{Auth: AuthConfig{Mode: ServiceAccountMode}}
+	if _, err := c.GetBackend(context.Background()); err == nil {
+		t.Errorf("GetBackend() with no key file succeeded, want error")
+	}
+}
+
+func TestNewClientFromViper(t *testing.T) {
+	// A separate viper instance, leaving the global config alone.
+	v := viper.New()
+	v.Set("alias-spec-prefix", "%")
+	v.Set("protect-worksheets", true)
+	v.Set("auth-mode", "service-account")
+	v.Set("keyfile", "testdata/service_account.json")
+	v.Set("aliases.mine.workbook", "mywb")
+	viper.Reset()
+
+	c := NewClientFromViper(v)
+	if !c.ProtectWorksheets || c.AliasPrefix != "%" {
+		t.Errorf("NewClientFromViper() = %+v, want protection and prefix from config", c)
+	}
+	if want := (AuthConfig{Mode: ServiceAccountMode, KeyFile: "testdata/service_account.json"}); c.Auth != want {
+		t.Errorf("NewClientFromViper().Auth = %+v, want %+v", c.Auth, want)
+	}
+	got, err := c.ExpandArgsToDataSpec([]string{"%mine"})
+	if err != nil {
+		t.Fatalf("ExpandArgsToDataSpec() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, &DataSpec{Workbook: "mywb"}) {
+		t.Errorf("ExpandArgsToDataSpec() = %v, want workbook mywb", got)
+	}
+	if _, err := GetAlias("mine"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("GetAlias() from global config error = %v, want ErrAliasNotFound", err)
+	}
+}
+
+func TestMemoryAliasStore(t *testing.T) {
+	s := NewMemoryAliasStore()
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+
+	if err := s.SetAlias("", spec); err == nil {
+		t.Errorf("SetAlias() with no name succeeded, want error")
+	}
+	if err := s.SetAlias("a", spec); err != nil {
+		t.Fatalf("SetAlias() error = %v", err)
+	}
+	// Changing the spec we set shouldn't change the alias.
+	spec.Worksheet = "other"
+
+	got, err := s.GetAlias("a")
+	if err != nil || !reflect.DeepEqual(got, &DataSpec{Workbook: "wb", Worksheet: "ws"}) {
+		t.Errorf("GetAlias() = %v, %v, want wb/ws", got, err)
+	}
+	all, err := s.GetAllAliases()
+	if err != nil || len(all) != 1 {
+		t.Errorf("GetAllAliases() = %v, %v, want 1 alias", all, err)
+	}
+	if err := s.DeleteAlias("a"); err != nil {
+		t.Errorf("DeleteAlias() error = %v", err)
+	}
+	if err := s.DeleteAlias("a"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("DeleteAlias() twice error = %v, want ErrAliasNotFound", err)
+	}
+}
+
+func TestNewClientFromViper_RateLimit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	v := viper.New()
+	v.Set("requests-per-minute", 30)
+	v.Set("share-rate-limit", true)
+
+	c := NewClientFromViper(v)
+	if c.RequestsPerMinute != 30 {
+		t.Errorf("RequestsPerMinute = %v, want 30", c.RequestsPerMinute)
+	}
+	want, _ := DefaultRateLimitFile()
+	if c.RateLimitFile == "" || c.RateLimitFile != want {
+		t.Errorf("RateLimitFile = %q, want %q", c.RateLimitFile, want)
+	}
+}
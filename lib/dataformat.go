@@ -2,56 +2,324 @@ package sheet
 
 import (
 	"bufio"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"google.golang.org/api/sheets/v4"
 )
 
-func PrintValues(v *sheets.ValueRange, f DataFormat) {
-	fmt.Print(FormatValues(v, f))
+// PrintValues writes v to stdout in the given format.
+func PrintValues(v *sheets.ValueRange, f DataFormat) error {
+	w := NewValueWriter(os.Stdout, f, false)
+	if err := w.Write(v); err != nil {
+		return err
+	}
+	return w.Close()
 }
 
-func FormatValues(v *sheets.ValueRange, f DataFormat) string {
-	ret := ""
-
-	sep := ","
-	if f == "tsv" {
-		sep = "\t"
+// FormatValues returns v in the given format.
+func FormatValues(v *sheets.ValueRange, f DataFormat) (string, error) {
+	ret := new(strings.Builder)
+	w := NewValueWriter(ret, f, false)
+	if err := w.Write(v); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
 	}
+	return ret.String(), nil
+}
+
+// ValueWriter writes ValueRanges out in a given DataFormat. Write may be called several times
+// with consecutive chunks of the same data (as 'cat' does), and Close must be called at the end,
+// since some formats (json) need terminating.
+type ValueWriter struct {
+	w io.Writer
+	f DataFormat
+	// For json and ndjson, emit each row as an object keyed by the header row, rather than an array.
+	keyed  bool
+	header []string
+	rows   int
+}
 
+func NewValueWriter(w io.Writer, f DataFormat, keyed bool) *ValueWriter {
+	return &ValueWriter{w: w, f: f, keyed: keyed}
+}
+
+// SetHeader sets the keys used for keyed json output, for when the data being written
+// doesn't start with the header row (e.g. 'tail').
+func (vw *ValueWriter) SetHeader(header []string) {
+	vw.header = header
+}
+
+func (vw *ValueWriter) Write(v *sheets.ValueRange) error {
 	for _, row := range v.Values {
-		for i := range row {
-			ret += row[i].(string)
-			if i != len(row)-1 {
-				ret += sep
+		var err error
+		switch vw.f {
+		case JsonFormat, NdjsonFormat:
+			err = vw.writeJsonRow(row)
+		default:
+			err = vw.writeSeparatedRow(row)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vw *ValueWriter) Close() error {
+	if vw.f != JsonFormat {
+		return nil
+	}
+	if vw.rows == 0 {
+		_, err := io.WriteString(vw.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(vw.w, "\n]\n")
+	return err
+}
+
+// WriteValueRanges writes several ValueRanges (e.g. from BatchGetValues), labelled by their Range.
+// csv and tsv get a "==> range <==" header before each, with a blank line between them, like 'tail'
+// with several files. json is an object keyed by range, with each range as the json format would
+// have it. ndjson has a line per row, as an object with the "range" and its "row".
+func WriteValueRanges(w io.Writer, ranges []*sheets.ValueRange, f DataFormat, keyed bool) error {
+	switch f {
+	case JsonFormat:
+		return writeJsonValueRanges(w, ranges, keyed)
+	case NdjsonFormat:
+		return writeNdjsonValueRanges(w, ranges, keyed)
+	}
+	for i, v := range ranges {
+		sep := ""
+		if i > 0 {
+			sep = "\n"
+		}
+		if _, err := fmt.Fprintf(w, "%v==> %v <==\n", sep, v.Range); err != nil {
+			return err
+		}
+		vw := NewValueWriter(w, f, keyed)
+		if err := vw.Write(v); err != nil {
+			return err
+		}
+		if err := vw.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJsonValueRanges(w io.Writer, ranges []*sheets.ValueRange, keyed bool) error {
+	if len(ranges) == 0 {
+		_, err := io.WriteString(w, "{}\n")
+		return err
+	}
+	// Marshal by hand, to keep the ranges in order.
+	out := "{\n"
+	for i, v := range ranges {
+		key, err := json.Marshal(v.Range)
+		if err != nil {
+			return err
+		}
+		section := new(strings.Builder)
+		vw := NewValueWriter(section, JsonFormat, keyed)
+		if err := vw.Write(v); err != nil {
+			return err
+		}
+		if err := vw.Close(); err != nil {
+			return err
+		}
+		if i > 0 {
+			out += ",\n"
+		}
+		out += string(key) + ": " + strings.TrimSuffix(section.String(), "\n")
+	}
+	_, err := io.WriteString(w, out+"\n}\n")
+	return err
+}
+
+func writeNdjsonValueRanges(w io.Writer, ranges []*sheets.ValueRange, keyed bool) error {
+	for _, v := range ranges {
+		key, err := json.Marshal(v.Range)
+		if err != nil {
+			return err
+		}
+		rows := new(strings.Builder)
+		vw := NewValueWriter(rows, NdjsonFormat, keyed)
+		if err := vw.Write(v); err != nil {
+			return err
+		}
+		for _, row := range strings.SplitAfter(rows.String(), "\n") {
+			if row == "" {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "{\"range\":%s,\"row\":%s}\n", key, strings.TrimSuffix(row, "\n")); err != nil {
+				return err
 			}
 		}
-		ret += "\n"
+	}
+	return nil
+}
+
+func (vw *ValueWriter) writeSeparatedRow(row []interface{}) error {
+	record := make([]string, len(row))
+	for i := range row {
+		record[i] = CellFromValue(row[i]).String()
+	}
+	// csv.Writer takes care of quoting any cells with separators, quotes or newlines in them.
+	cw := csv.NewWriter(vw.w)
+	cw.Comma = vw.f.separatorRune()
+	cw.Write(record)
+	cw.Flush()
+	return cw.Error()
+}
+
+func (vw *ValueWriter) writeJsonRow(row []interface{}) error {
+	if vw.keyed && vw.header == nil {
+		// The first row we see is the header, and isn't output itself.
+		vw.header = make([]string, len(row))
+		for i := range row {
+			vw.header[i] = CellFromValue(row[i]).String()
+		}
+		return nil
+	}
+
+	var b []byte
+	var err error
+	if vw.keyed {
+		b, err = vw.keyedRow(row)
+	} else {
+		b, err = json.Marshal(jsonValues(row))
+	}
+	if err != nil {
+		return err
+	}
+
+	prefix := ""
+	if vw.f == JsonFormat {
+		if vw.rows == 0 {
+			prefix = "[\n"
+		} else {
+			prefix = ",\n"
+		}
+	}
+	suffix := ""
+	if vw.f == NdjsonFormat {
+		suffix = "\n"
+	}
+	vw.rows++
+	_, err = io.WriteString(vw.w, prefix+string(b)+suffix)
+	return err
+}
+
+func (vw *ValueWriter) keyedRow(row []interface{}) ([]byte, error) {
+	// Marshal by hand, since we want keys in column order rather than sorted.
+	// Rows from the API omit trailing empty cells, so pad them out to the width of the header,
+	// and key any cells beyond the header (or under a blank heading) by column letter.
+	width := max(len(row), len(vw.header))
+	b := []byte("{")
+	for i := 0; i < width; i++ {
+		key := ""
+		if i < len(vw.header) {
+			key = vw.header[i]
+		}
+		if key == "" {
+			key = colToLetter(i + 1)
+		}
+		var val interface{} = ""
+		if i < len(row) {
+			val = CellFromValue(row[i]).Value()
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, k...)
+		b = append(b, ':')
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
+}
+
+func jsonValues(row []interface{}) []interface{} {
+	ret := make([]interface{}, len(row))
+	for i := range row {
+		ret[i] = CellFromValue(row[i]).Value()
 	}
 	return ret
 }
 
 func ScanValues(r *bufio.Reader, f DataFormat) ([][]string, error) {
-	ret := [][]string{}
-
-	buf := new(strings.Builder)
-	_, err := io.Copy(buf, r)
+	vr, err := NewValueReader(r, f)
 	if err != nil {
 		return nil, err
 	}
 
-	// Split the buffer into lines. We don't hold truck with any multi-line data format fuckery, for now.
-	lines := strings.Split(buf.String(), "\n")
-
-	for l := range lines {
-		if lines[l] != "" {
-			ret = append(ret, strings.Split(lines[l], f.Separator()))
+	ret := [][]string{}
+	for {
+		rows, err := vr.ReadRows(0)
+		if err == io.EOF {
+			return ret, nil
 		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, rows...)
+	}
+}
+
+// ValueReader reads rows of values in a given DataFormat, a chunk at a time.
+type ValueReader struct {
+	cr *csv.Reader
+}
+
+func NewValueReader(r io.Reader, f DataFormat) (*ValueReader, error) {
+	if f == JsonFormat || f == NdjsonFormat {
+		return nil, fmt.Errorf("unsupported input format: %v", f)
 	}
 
+	// RFC 4180: quoted fields may contain separators, newlines and doubled quotes, and lines may end in CRLF.
+	// Blank lines are skipped.
+	cr := csv.NewReader(r)
+	cr.Comma = f.separatorRune()
+	// Rows from a sheet are ragged (trailing empty cells are dropped).
+	cr.FieldsPerRecord = -1
+	// TSV doesn't really have quoting rules, so be lenient about stray quotes in unquoted fields.
+	cr.LazyQuotes = f == TsvFormat
+
+	return &ValueReader{cr: cr}, nil
+}
+
+// ReadRows reads up to n rows (or all remaining rows, if n <= 0).
+// It returns io.EOF only when there are no rows left at all.
+func (vr *ValueReader) ReadRows(n int) ([][]string, error) {
+	ret := [][]string{}
+	for n <= 0 || len(ret) < n {
+		record, err := vr.cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, record)
+	}
+	if len(ret) == 0 {
+		return nil, io.EOF
+	}
 	return ret, nil
 }
 
@@ -66,21 +334,27 @@ type DataFormatValue interface {
 type DataFormat string
 
 const (
-	CsvFormat DataFormat = "csv"
-	TsvFormat DataFormat = "tsv"
+	CsvFormat    DataFormat = "csv"
+	TsvFormat    DataFormat = "tsv"
+	JsonFormat   DataFormat = "json"
+	NdjsonFormat DataFormat = "ndjson"
 )
 
 func (f *DataFormat) String() string { return string(*f) }
 func (f *DataFormat) Type() string   { return "DataFormat" }
 func (f *DataFormat) Set(v string) error {
 	switch v {
-	case "csv", "tsv":
+	case "csv", "tsv", "json", "ndjson":
 		*f = DataFormat(v)
 		return nil
 	default:
-		return errors.New("invalid DataFormat. Allowed [csv|tsv]")
+		return errors.New("invalid DataFormat. Allowed [csv|tsv|json|ndjson]")
 	}
 }
+func (f *DataFormat) separatorRune() rune {
+	return []rune(f.Separator())[0]
+}
+
 func (f *DataFormat) Separator() string {
 	switch *f {
 	case "csv":
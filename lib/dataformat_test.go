@@ -2,6 +2,8 @@ package sheet
 
 import (
 	"bufio"
+	"io"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,6 +19,8 @@ func TestDataFormat_String(t *testing.T) {
 	}{
 		{name: "Csv", f: CsvFormat, want: "csv"},
 		{name: "Tsv", f: TsvFormat, want: "tsv"},
+		{name: "Json", f: JsonFormat, want: "json"},
+		{name: "Ndjson", f: NdjsonFormat, want: "ndjson"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -51,6 +55,16 @@ func TestDataFormat_Set(t *testing.T) {
 			ftype:   "tsv",
 			wantErr: false,
 		},
+		{
+			name:    "Json",
+			ftype:   "json",
+			wantErr: false,
+		},
+		{
+			name:    "Ndjson",
+			ftype:   "ndjson",
+			wantErr: false,
+		},
 		{
 			name:    "UnknownFType",
 			ftype:   "blah",
@@ -99,9 +113,10 @@ func TestFormatValues(t *testing.T) {
 		f DataFormat
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    string
+		wantErr bool
 	}{
 		{
 			name: "SimpleCsv",
@@ -123,16 +138,187 @@ func TestFormatValues(t *testing.T) {
 			args: args{v: &sheets.ValueRange{Values: [][]interface{}{}}, f: CsvFormat},
 			want: "",
 		},
+		{
+			name: "TypedCsv",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"a", 1.5, true}, {nil, float64(30), false}}}, f: CsvFormat},
+			want: "a,1.5,TRUE\n,30,FALSE\n",
+		},
+		{
+			name: "TypedJson",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"a", 1.5, true, nil}}}, f: JsonFormat},
+			want: "[\n[\"a\",1.5,true,\"\"]\n]\n",
+		},
+		{
+			name: "QuotedCsv",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"Smith, John", "say \"hi\""}, {"line1\nline2", "d"}}}, f: CsvFormat},
+			want: "\"Smith, John\",\"say \"\"hi\"\"\"\n\"line1\nline2\",d\n",
+		},
+		{
+			name: "QuotedTsv",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"a\tb", "c,d"}}}, f: TsvFormat},
+			want: "\"a\tb\"\tc,d\n",
+		},
+		{
+			name: "SimpleJson",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"a", "b"}, {"c", "d"}}}, f: JsonFormat},
+			want: "[\n[\"a\",\"b\"],\n[\"c\",\"d\"]\n]\n",
+		},
+		{
+			name: "EmptyJson",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{}}, f: JsonFormat},
+			want: "[]\n",
+		},
+		{
+			name: "SimpleNdjson",
+			args: args{v: &sheets.ValueRange{Values: [][]interface{}{{"a", "b"}, {"c", 1.5}}}, f: NdjsonFormat},
+			want: "[\"a\",\"b\"]\n[\"c\",1.5]\n",
+		},
+		{
+			name:    "UnencodableJson",
+			args:    args{v: &sheets.ValueRange{Values: [][]interface{}{{math.NaN()}}}, f: JsonFormat},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := FormatValues(tt.args.v, tt.args.f); got != tt.want {
+			got, err := FormatValues(tt.args.v, tt.args.f)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
 				t.Errorf("FormatValues() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestValueWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      DataFormat
+		keyed  bool
+		header []string
+		chunks [][][]interface{}
+		want   string
+	}{
+		{
+			name:   "KeyedJson",
+			f:      JsonFormat,
+			keyed:  true,
+			chunks: [][][]interface{}{{{"name", "age"}, {"alice", "30"}, {"bob"}}},
+			want:   "[\n{\"name\":\"alice\",\"age\":\"30\"},\n{\"name\":\"bob\",\"age\":\"\"}\n]\n",
+		},
+		{
+			name:   "KeyedJsonHeaderOnly",
+			f:      JsonFormat,
+			keyed:  true,
+			chunks: [][][]interface{}{{{"name", "age"}}},
+			want:   "[]\n",
+		},
+		{
+			name:   "KeyedNdjsonChunked",
+			f:      NdjsonFormat,
+			keyed:  true,
+			chunks: [][][]interface{}{{{"name", "age"}, {"alice", "30"}}, {{"bob", "25"}}},
+			want:   "{\"name\":\"alice\",\"age\":\"30\"}\n{\"name\":\"bob\",\"age\":\"25\"}\n",
+		},
+		{
+			name:   "KeyedNdjsonExtraColumns",
+			f:      NdjsonFormat,
+			keyed:  true,
+			chunks: [][][]interface{}{{{"name", ""}, {"alice", "30", "x"}}},
+			want:   "{\"name\":\"alice\",\"B\":\"30\",\"C\":\"x\"}\n",
+		},
+		{
+			name:   "KeyedNdjsonSetHeader",
+			f:      NdjsonFormat,
+			keyed:  true,
+			header: []string{"name", "age"},
+			chunks: [][][]interface{}{{{"carol", "35"}}},
+			want:   "{\"name\":\"carol\",\"age\":\"35\"}\n",
+		},
+		{
+			name:   "ChunkedJson",
+			f:      JsonFormat,
+			chunks: [][][]interface{}{{{"a"}}, {{"b"}}},
+			want:   "[\n[\"a\"],\n[\"b\"]\n]\n",
+		},
+		{
+			name:   "ChunkedCsv",
+			f:      CsvFormat,
+			keyed:  true,
+			chunks: [][][]interface{}{{{"a", "b"}}, {{"c", "d"}}},
+			want:   "a,b\nc,d\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(strings.Builder)
+			w := NewValueWriter(got, tt.f, tt.keyed)
+			if tt.header != nil {
+				w.SetHeader(tt.header)
+			}
+			for _, chunk := range tt.chunks {
+				if err := w.Write(&sheets.ValueRange{Values: chunk}); err != nil {
+					t.Errorf("ValueWriter.Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Errorf("ValueWriter.Close() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ValueWriter output = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteValueRanges(t *testing.T) {
+	ranges := []*sheets.ValueRange{
+		{Range: "a!A1:B2", Values: [][]interface{}{{"name", "age"}, {"alice", "30"}}},
+		{Range: "b", Values: [][]interface{}{{"x"}}},
+	}
+	tests := []struct {
+		name  string
+		f     DataFormat
+		keyed bool
+		want  string
+	}{
+		{
+			name: "Csv",
+			f:    CsvFormat,
+			want: "==> a!A1:B2 <==\nname,age\nalice,30\n\n==> b <==\nx\n",
+		},
+		{
+			name: "Json",
+			f:    JsonFormat,
+			want: "{\n\"a!A1:B2\": [\n[\"name\",\"age\"],\n[\"alice\",\"30\"]\n],\n\"b\": [\n[\"x\"]\n]\n}\n",
+		},
+		{
+			name:  "KeyedJson",
+			f:     JsonFormat,
+			keyed: true,
+			want:  "{\n\"a!A1:B2\": [\n{\"name\":\"alice\",\"age\":\"30\"}\n],\n\"b\": []\n}\n",
+		},
+		{
+			name: "Ndjson",
+			f:    NdjsonFormat,
+			want: "{\"range\":\"a!A1:B2\",\"row\":[\"name\",\"age\"]}\n{\"range\":\"a!A1:B2\",\"row\":[\"alice\",\"30\"]}\n{\"range\":\"b\",\"row\":[\"x\"]}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(strings.Builder)
+			if err := WriteValueRanges(got, ranges, tt.f, tt.keyed); err != nil {
+				t.Fatalf("WriteValueRanges() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("WriteValueRanges() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
 func TestScanValues(t *testing.T) {
 	type args struct {
 		r *bufio.Reader
@@ -168,6 +354,60 @@ func TestScanValues(t *testing.T) {
 			want:    [][]string{{"hello"}},
 			wantErr: false,
 		},
+		{
+			name:    "QuotedComma",
+			args:    args{r: bufio.NewReader(strings.NewReader("\"Smith, John\",b\n")), f: CsvFormat},
+			want:    [][]string{{"Smith, John", "b"}},
+			wantErr: false,
+		},
+		{
+			name:    "EscapedQuotes",
+			args:    args{r: bufio.NewReader(strings.NewReader("\"say \"\"hi\"\"\",b\n")), f: CsvFormat},
+			want:    [][]string{{"say \"hi\"", "b"}},
+			wantErr: false,
+		},
+		{
+			name:    "EmbeddedNewline",
+			args:    args{r: bufio.NewReader(strings.NewReader("\"line1\nline2\",b\nc,d\n")), f: CsvFormat},
+			want:    [][]string{{"line1\nline2", "b"}, {"c", "d"}},
+			wantErr: false,
+		},
+		{
+			name:    "CRLF",
+			args:    args{r: bufio.NewReader(strings.NewReader("a,b\r\nc,d\r\n")), f: CsvFormat},
+			want:    [][]string{{"a", "b"}, {"c", "d"}},
+			wantErr: false,
+		},
+		{
+			name:    "RaggedRows",
+			args:    args{r: bufio.NewReader(strings.NewReader("a,b,c\nd\n")), f: CsvFormat},
+			want:    [][]string{{"a", "b", "c"}, {"d"}},
+			wantErr: false,
+		},
+		{
+			name:    "BlankLinesSkipped",
+			args:    args{r: bufio.NewReader(strings.NewReader("a,b\n\nc,d\n")), f: CsvFormat},
+			want:    [][]string{{"a", "b"}, {"c", "d"}},
+			wantErr: false,
+		},
+		{
+			name:    "UnterminatedQuote",
+			args:    args{r: bufio.NewReader(strings.NewReader("\"a,b\n")), f: CsvFormat},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "TsvStrayQuote",
+			args:    args{r: bufio.NewReader(strings.NewReader("5\" screen\tb\n")), f: TsvFormat},
+			want:    [][]string{{"5\" screen", "b"}},
+			wantErr: false,
+		},
+		{
+			name:    "JsonUnsupported",
+			args:    args{r: bufio.NewReader(strings.NewReader("[]\n")), f: JsonFormat},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -182,3 +422,56 @@ func TestScanValues(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatScanRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data [][]string
+	}{
+		{name: "Simple", data: [][]string{{"a", "b"}, {"c", "d"}}},
+		{name: "Commas", data: [][]string{{"Smith, John", "Doe, Jane"}}},
+		{name: "Quotes", data: [][]string{{"\"quoted\"", "it's"}, {"\"", "x"}}},
+		{name: "Newlines", data: [][]string{{"line1\nline2", "x"}, {"y", "\n"}}},
+		{name: "Tabs", data: [][]string{{"a\tb", "c"}}},
+		{name: "Ragged", data: [][]string{{"a", "b", "c"}, {"d"}}},
+		{name: "EmptyCells", data: [][]string{{"a", "", "c"}}},
+	}
+	for _, f := range []DataFormat{CsvFormat, TsvFormat} {
+		for _, tt := range tests {
+			t.Run(string(f)+tt.name, func(t *testing.T) {
+				formatted, err := FormatValues(valueRangeFromStrings(tt.data), f)
+				if err != nil {
+					t.Fatalf("FormatValues() error = %v", err)
+				}
+				got, err := ScanValues(bufio.NewReader(strings.NewReader(formatted)), f)
+				if err != nil {
+					t.Errorf("ScanValues() error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.data) {
+					t.Errorf("round trip = %q, want %q (formatted: %q)", got, tt.data, formatted)
+				}
+			})
+		}
+	}
+}
+
+func TestValueReader_ReadRows(t *testing.T) {
+	r, err := NewValueReader(strings.NewReader("a,b\nc,d\n\"e\nf\",g\nh\n"), CsvFormat)
+	if err != nil {
+		t.Fatalf("NewValueReader() error = %v", err)
+	}
+	want := [][][]string{{{"a", "b"}, {"c", "d"}}, {{"e\nf", "g"}, {"h"}}}
+	for i, chunk := range want {
+		got, err := r.ReadRows(2)
+		if err != nil {
+			t.Fatalf("ReadRows() chunk %d error = %v", i, err)
+		}
+		if !reflect.DeepEqual(got, chunk) {
+			t.Errorf("ReadRows() chunk %d = %q, want %q", i, got, chunk)
+		}
+	}
+	if _, err := r.ReadRows(2); err != io.EOF {
+		t.Errorf("ReadRows() at end error = %v, want io.EOF", err)
+	}
+}
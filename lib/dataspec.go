@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-
-	"github.com/spf13/viper"
 )
 
 type DataRange struct {
@@ -38,6 +36,10 @@ func letterToCol(letter string) int {
 }
 
 func (d *DataRange) String() string {
+	// A single cell is just e.g. B3, rather than B3:B3.
+	if d.StartRow > 0 && d.StartCol > 0 && d.StartRow == d.EndRow && d.StartCol == d.EndCol {
+		return fmt.Sprintf("%v%v", colToLetter(d.StartCol), d.StartRow)
+	}
 	ret := colToLetter(d.StartCol)
 	if d.StartRow > 0 {
 		ret += fmt.Sprintf("%v", d.StartRow)
@@ -50,58 +52,124 @@ func (d *DataRange) String() string {
 	return ret
 }
 
+// The most columns a worksheet can have is 18278, i.e. up to column ZZZ.
+const maxColLetters = 3
+
 func splitRangeFragment(s string) (int, int, error) {
-	// Given a string like "A1" or "B2", return the column and row.
+	// Given one side of a range like "A1", "$B$2", "c" or "3", return the column and row.
+	// Either may be 0 if it's missing, but not both. '$' (absolute references) are allowed, and ignored.
 	colstr := ""
 	rowstr := ""
-	for _, c := range s {
-		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
-			colstr += string(c)
-		} else if c >= '0' && c <= '9' {
-			rowstr += string(c)
-		} else {
-			return 0, 0, fmt.Errorf("invalid range fragment: %v", s)
-		}
+	rest := strings.TrimPrefix(s, "$")
+	for len(rest) > 0 && isLetter(rest[0]) {
+		colstr += string(rest[0])
+		rest = rest[1:]
+	}
+	if colstr != "" {
+		rest = strings.TrimPrefix(rest, "$")
+	}
+	for len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9' {
+		rowstr += string(rest[0])
+		rest = rest[1:]
+	}
+	if len(rest) > 0 || (colstr == "" && rowstr == "") || strings.HasSuffix(s, "$") {
+		return 0, 0, fmt.Errorf("%w: bad fragment %v", ErrInvalidRange, s)
+	}
+	if len(colstr) > maxColLetters {
+		return 0, 0, fmt.Errorf("%w: column out of range in %v", ErrInvalidRange, s)
 	}
 
-	// Handle "A:D" or "1:2" or "A:2" or "B:A" etc.
 	var row, col int
 	var err error
 	if len(rowstr) > 0 {
 		row, err = strconv.Atoi(rowstr)
 		if err != nil {
-			return 0, 0, err
+			return 0, 0, fmt.Errorf("%w: bad row in %v: %v", ErrInvalidRange, s, err)
+		}
+		if row == 0 {
+			return 0, 0, fmt.Errorf("%w: rows start at 1 in %v", ErrInvalidRange, s)
 		}
 	}
-	if len(colstr) == 0 {
-		col = 0
-	} else {
+	if len(colstr) > 0 {
 		col = letterToCol(strings.ToUpper(colstr))
 	}
 
 	return col, row, nil
 }
 
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// FromString parses A1 notation into d. As well as "A1:B2" this takes single cells ("B3"), whole
+// rows or columns ("1:3", "A:C"), ranges open at the bottom or right ("A2:C", "A2:5"), absolute
+// references ("$A$1:$C$10") and lowercase. The range is normalised so the start is above and to the
+// left of the end, e.g. "B:A" is "A:B".
 func (d *DataRange) FromString(s string) (*DataRange, error) {
 	fragments := strings.Split(s, ":")
-	if len(fragments) != 2 {
-		return nil, fmt.Errorf("invalid range: %v", s)
+	if len(fragments) > 2 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRange, s)
 	}
 	startc, startr, err := splitRangeFragment(fragments[0])
 	if err != nil {
 		return nil, err
 	}
+	if len(fragments) == 1 {
+		// A single cell.
+		if startc == 0 || startr == 0 {
+			return nil, fmt.Errorf("%w: %v is not a cell", ErrInvalidRange, s)
+		}
+		*d = DataRange{StartRow: startr, StartCol: startc, EndRow: startr, EndCol: startc}
+		return d, nil
+	}
 	endc, endr, err := splitRangeFragment(fragments[1])
 	if err != nil {
 		return nil, err
 	}
-	d.StartRow = startr
-	d.StartCol = startc
-	d.EndRow = endr
-	d.EndCol = endc
+
+	switch {
+	case startr == 0 && endc == 0, startc == 0 && endr == 0:
+		// "A:3" or "3:A"
+		return nil, fmt.Errorf("%w: %v mixes rows and columns", ErrInvalidRange, s)
+	case startr == 0 && endr > 0:
+		// "A:C5" is A1:C5
+		startr = 1
+	case startc == 0 && endc > 0:
+		// "2:C5" is A2:C5
+		startc = 1
+	}
+
+	// Open ends (0) stay where they are.
+	if endc > 0 && startc > endc {
+		startc, endc = endc, startc
+	}
+	if endr > 0 && startr > endr {
+		startr, endr = endr, startr
+	}
+
+	*d = DataRange{StartRow: startr, StartCol: startc, EndRow: endr, EndCol: endc}
 	return d, nil
 }
 
+// RangeFromString parses a range like "A1:B2".
+func RangeFromString(s string) (DataRange, error) {
+	ret := DataRange{}
+	if _, err := ret.FromString(s); err != nil {
+		return DataRange{}, err
+	}
+	return ret, nil
+}
+
+// MustRangeFromString is like RangeFromString, but panics if s can't be parsed.
+// It's meant for ranges that are known to be good, e.g. in tests.
+func MustRangeFromString(s string) DataRange {
+	ret, err := RangeFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
 func (d *DataRange) SizeXY() (int, int) {
 	var col, row int
 	// For a whole row or column, return 0 for the end of the range
@@ -124,16 +192,23 @@ type DataSpec struct {
 	Workbook  string
 	Worksheet string
 	Range     DataRange
+	// A named range in the workbook (given as "=Name"). Until it's resolved (see ResolveNamedRange),
+	// Worksheet and Range are empty.
+	NamedRange string
 }
 
 func (d *DataSpec) GetInSheetDataSpec() string {
 	// Return a string that can be used to reference this DataSpec in a sheet.
-	// e.g. "Sheet1!A1:B2"
+	// e.g. "Sheet1!A1:B2", or "'My Sheet'!A1:B2"
+	if d.Worksheet == "" && d.NamedRange != "" {
+		// The API takes named ranges as they are.
+		return d.NamedRange
+	}
 	if d.Worksheet != "" {
 		if d.Range != (DataRange{}) {
-			return fmt.Sprintf("%v!%v", d.Worksheet, d.Range.String())
+			return fmt.Sprintf("%v!%v", QuoteWorksheet(d.Worksheet), d.Range.String())
 		} else {
-			return d.Worksheet
+			return QuoteWorksheet(d.Worksheet)
 		}
 	} else {
 		return d.Range.String()
@@ -141,7 +216,7 @@ func (d *DataSpec) GetInSheetDataSpec() string {
 }
 
 func (d *DataSpec) IsWorkbook() bool {
-	return (d.Workbook != "" && d.Worksheet == "" && d.Range == DataRange{})
+	return (d.Workbook != "" && d.Worksheet == "" && d.Range == DataRange{} && d.NamedRange == "")
 }
 
 func (d *DataSpec) IsWorksheet() bool {
@@ -152,6 +227,11 @@ func (d *DataSpec) IsRange() bool {
 	return (d.Workbook != "" && d.Worksheet != "" && d.Range != DataRange{})
 }
 
+// IsNamedRange returns true if d refers to a named range, whether or not it's been resolved.
+func (d *DataSpec) IsNamedRange() bool {
+	return (d.Workbook != "" && d.NamedRange != "")
+}
+
 func (d *DataSpec) String() string {
 	ret := []string{}
 	if d.Workbook != "" {
@@ -163,28 +243,105 @@ func (d *DataSpec) String() string {
 	if d.IsRange() {
 		ret = append(ret, "Range: "+d.Range.String())
 	}
+	if d.NamedRange != "" {
+		ret = append(ret, "Named range: "+d.NamedRange)
+	}
 	return strings.Join(ret, ", ")
 }
 
 func (d *DataSpec) FromString(s string) (*DataSpec, error) {
-	// This will always be datasheet, or datasheet!range
-	if strings.Contains(s, "!") {
-		fragments := strings.Split(s, "!")
-		d.Worksheet = fragments[0]
-		_, err := d.Range.FromString(fragments[1])
+	// This will always be datasheet, or datasheet!range. The worksheet may be quoted, as in 'My Sheet'!A1:B2
+	// It can also be a named range, as in =MyRange
+	if name, ok := strings.CutPrefix(s, NamedRangePrefix); ok {
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty named range", ErrInvalidRange)
+		}
+		d.NamedRange = name
+		return d, nil
+	}
+	worksheet, rng, hasRange, err := splitWorksheetRange(s)
+	if err != nil {
+		return nil, err
+	}
+	d.Worksheet = worksheet
+	if hasRange {
+		_, err := d.Range.FromString(rng)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		d.Worksheet = s
 	}
 	return d, nil
 }
 
+// QuoteWorksheet returns a worksheet title as it's written in A1 notation: as it is if it's a plain
+// name like "Sheet1", otherwise in single quotes (doubling any in the title), e.g. "My Sheet" is "'My Sheet'".
+func QuoteWorksheet(title string) string {
+	if !worksheetNeedsQuotes(title) {
+		return title
+	}
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
+
+func worksheetNeedsQuotes(title string) bool {
+	if title == "" {
+		return false
+	}
+	for i, c := range title {
+		if c > 0x7f || !(isLetter(byte(c)) || c == '_' || (i > 0 && c >= '0' && c <= '9')) {
+			return true
+		}
+	}
+	// Titles like "A1" or "ab12" would be taken for a range.
+	_, err := RangeFromString(title)
+	return err == nil
+}
+
+// splitWorksheetRange splits "worksheet!range" into its worksheet and range, unquoting the worksheet
+// if it's quoted. hasRange is false if there's no '!'.
+func splitWorksheetRange(s string) (worksheet string, rng string, hasRange bool, err error) {
+	if !strings.HasPrefix(s, "'") {
+		// Ranges never have a '!', so the last one ends the worksheet.
+		if i := strings.LastIndex(s, "!"); i >= 0 {
+			return s[:i], s[i+1:], true, nil
+		}
+		return s, "", false, nil
+	}
+
+	title := strings.Builder{}
+	rest := s[1:]
+	for {
+		i := strings.Index(rest, "'")
+		if i < 0 {
+			return "", "", false, fmt.Errorf("%w: unterminated quote in %v", ErrInvalidRange, s)
+		}
+		title.WriteString(rest[:i])
+		rest = rest[i+1:]
+		if !strings.HasPrefix(rest, "'") {
+			break
+		}
+		// '' is a quote in the title.
+		title.WriteString("'")
+		rest = rest[1:]
+	}
+
+	if rest == "" {
+		return title.String(), "", false, nil
+	}
+	if !strings.HasPrefix(rest, "!") {
+		return "", "", false, fmt.Errorf("%w: expected ! after quoted worksheet in %v", ErrInvalidRange, s)
+	}
+	return title.String(), rest[1:], true, nil
+}
+
 func ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
+	return DefaultClient().ExpandArgsToDataSpec(args)
+}
+
+func (c *Client) ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
 	// Expands an alias within an argument list (if it exists).
 	// 'args' is the set of arguments that should represent a DataSpec (see dataspec.go)
 	// If the first argument is an alias, it is expanded into a DataSpec and returned.
+	// This doesn't talk to the API, so only the workbook ID is taken from a link (see ResolveArgsToDataSpec).
 	if len(args) > 2 {
 		return nil, fmt.Errorf("too many arguments when expanding DataSpec: %v", args)
 	}
@@ -193,7 +350,7 @@ func ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
 		return &DataSpec{}, nil
 	}
 
-	alias_prefix := viper.GetString("alias-spec-prefix")
+	alias_prefix := c.AliasPrefix
 
 	if alias_prefix == "" {
 		alias_prefix = "@"
@@ -203,10 +360,14 @@ func ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
 		// If there's only one argument, it can be an alias or a workbook ID.
 		if strings.HasPrefix(args[0], alias_prefix) {
 			// Expand alias, if it exists.
-			return dataSpecFromAlias(args[0][1:])
+			return c.dataSpecFromAlias(strings.TrimPrefix(args[0], alias_prefix))
 		} else {
-			// First non-alias argument is always a workbook ID.
-			return &DataSpec{Workbook: args[0]}, nil
+			// First non-alias argument is always a workbook ID (or a link to one).
+			workbook, err := workbookFromArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return &DataSpec{Workbook: workbook}, nil
 		}
 	}
 	// If there are two arguments, each of them may be an alias. We partially populate a DataSpec
@@ -221,7 +382,7 @@ func ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
 	specs := []*DataSpec{}
 	for i, arg := range args {
 		if strings.HasPrefix(arg, alias_prefix) {
-			spec, err := dataSpecFromAlias(args[0][1:])
+			spec, err := c.dataSpecFromAlias(strings.TrimPrefix(arg, alias_prefix))
 			if err != nil {
 				return nil, err
 			}
@@ -229,7 +390,11 @@ func ExpandArgsToDataSpec(args []string) (*DataSpec, error) {
 		} else {
 			spec := &DataSpec{}
 			if i == 0 {
-				spec.Workbook = arg
+				workbook, err := workbookFromArg(arg)
+				if err != nil {
+					return nil, err
+				}
+				spec.Workbook = workbook
 			} else {
 				_, err := spec.FromString(arg)
 				if err != nil {
@@ -265,55 +430,47 @@ func mergeDataSpecs(specs []*DataSpec) (*DataSpec, error) {
 			}
 			ret.Range = spec.Range
 		}
+		if spec.NamedRange != "" {
+			if ret.NamedRange != "" {
+				return nil, fmt.Errorf("multiple named ranges in DataSpecs: %v", specs)
+			}
+			ret.NamedRange = spec.NamedRange
+		}
+	}
+	if ret.NamedRange != "" && (ret.Worksheet != "" || ret.Range != DataRange{}) {
+		return nil, fmt.Errorf("a named range can't have a worksheet or range too: %v", specs)
 	}
 	return &ret, nil
 }
 
-func dataSpecFromAlias(aliasname string) (*DataSpec, error) {
-	ret := DataSpec{}
+func (c *Client) dataSpecFromAlias(aliasname string) (*DataSpec, error) {
+	rng := DataRange{}
 
 	// Handle @myalias!range
-	if strings.Contains(aliasname, "!") {
-		fragments := strings.Split(aliasname, "!")
-		aliasname = fragments[0]
-		_, err := ret.Range.FromString(fragments[1])
+	if name, r, found := strings.Cut(aliasname, "!"); found {
+		aliasname = name
+		_, err := rng.FromString(r)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	all := viper.GetStringMap("aliases")
-	alias := map[string]interface{}{}
-	for k := range all {
-		if k == aliasname {
-			alias = all[k].(map[string]interface{})
-		}
-	}
-	if len(alias) == 0 {
-		return nil, fmt.Errorf("alias not found: %v", aliasname)
+	if c.Aliases == nil {
+		return nil, fmt.Errorf("%w: %v (no alias store)", ErrAliasNotFound, aliasname)
 	}
 
-	for k, v := range alias {
-		if k == "workbook" {
-			ret.Workbook = v.(string)
-		}
-		if k == "worksheet" {
-			ret.Worksheet = v.(string)
-		}
-		if k == "range" {
-			_, err := ret.Range.FromString(v.(string))
-			if err != nil {
-				return nil, err
-			}
-		}
+	ret, err := c.Aliases.GetAlias(aliasname)
+	if err != nil {
+		return nil, err
 	}
 
 	// Do this check at the end -- if somehow we're specifying @myalias!range
 	// and @myalias is a workbook alias, we've got an incomplete dataspec.
-	if (ret.Range != DataRange{}) {
+	if (rng != DataRange{}) {
 		if ret.Worksheet == "" {
 			return nil, fmt.Errorf("invalid alias for ! notation: %v", aliasname)
 		}
+		ret.Range = rng
 	}
-	return &ret, nil
+	return ret, nil
 }
@@ -9,9 +9,10 @@ import (
 
 func TestDataSpec_GetInSheetDataSpec(t *testing.T) {
 	type fields struct {
-		Workbook  string
-		Worksheet string
-		Range     DataRange
+		Workbook   string
+		Worksheet  string
+		Range      DataRange
+		NamedRange string
 	}
 	tests := []struct {
 		name   string
@@ -25,26 +26,47 @@ func TestDataSpec_GetInSheetDataSpec(t *testing.T) {
 		},
 		{
 			name:   "BareRange",
-			fields: fields{Range: RangeFromString("A2:C10")},
+			fields: fields{Range: MustRangeFromString("A2:C10")},
 			want:   "A2:C10",
 		},
 		{
 			name:   "BareRangeLowercase",
-			fields: fields{Range: RangeFromString("A2:c10")},
+			fields: fields{Range: MustRangeFromString("A2:c10")},
 			want:   "A2:C10",
 		},
 		{
 			name:   "Combined",
-			fields: fields{Worksheet: "mysheet", Range: RangeFromString("A1:B10")},
+			fields: fields{Worksheet: "mysheet", Range: MustRangeFromString("A1:B10")},
+			want:   "mysheet!A1:B10",
+		},
+		{
+			name:   "QuotedWorksheet",
+			fields: fields{Worksheet: "My Sheet"},
+			want:   "'My Sheet'",
+		},
+		{
+			name:   "NamedRange",
+			fields: fields{NamedRange: "Totals"},
+			want:   "Totals",
+		},
+		{
+			name:   "ResolvedNamedRange",
+			fields: fields{Worksheet: "mysheet", Range: MustRangeFromString("A1:B10"), NamedRange: "Totals"},
 			want:   "mysheet!A1:B10",
 		},
+		{
+			name:   "QuotedCombined",
+			fields: fields{Worksheet: "Q1 'Sales'", Range: MustRangeFromString("A1:B10")},
+			want:   "'Q1 ''Sales'''!A1:B10",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := &DataSpec{
-				Workbook:  tt.fields.Workbook,
-				Worksheet: tt.fields.Worksheet,
-				Range:     tt.fields.Range,
+				Workbook:   tt.fields.Workbook,
+				Worksheet:  tt.fields.Worksheet,
+				Range:      tt.fields.Range,
+				NamedRange: tt.fields.NamedRange,
 			}
 			if got := d.GetInSheetDataSpec(); got != tt.want {
 				t.Errorf("DataSpec.GetInSheetDataSpec() = %v, want %v", got, tt.want)
@@ -84,9 +106,81 @@ func TestDataSpec_FromString(t *testing.T) {
 		{
 			name:    "WithRange",
 			args:    args{s: "mysheet!A1:B100"},
-			want:    &DataSpec{Worksheet: "mysheet", Range: RangeFromString("A1:B100")},
+			want:    &DataSpec{Worksheet: "mysheet", Range: MustRangeFromString("A1:B100")},
+			wantErr: false,
+		},
+		{
+			name:    "Quoted",
+			args:    args{s: "'My Sheet'"},
+			want:    &DataSpec{Worksheet: "My Sheet"},
+			wantErr: false,
+		},
+		{
+			name:    "QuotedWithRange",
+			args:    args{s: "'My Sheet'!A1:B2"},
+			want:    &DataSpec{Worksheet: "My Sheet", Range: MustRangeFromString("A1:B2")},
+			wantErr: false,
+		},
+		{
+			name:    "QuotedWithApostrophes",
+			args:    args{s: "'Q1 ''Sales'''!A1:B2"},
+			want:    &DataSpec{Worksheet: "Q1 'Sales'", Range: MustRangeFromString("A1:B2")},
+			wantErr: false,
+		},
+		{
+			name:    "QuotedWithBang",
+			args:    args{s: "'Wow!'!B3"},
+			want:    &DataSpec{Worksheet: "Wow!", Range: MustRangeFromString("B3")},
 			wantErr: false,
 		},
+		{
+			name:    "QuotedJustApostrophe",
+			args:    args{s: "''''"},
+			want:    &DataSpec{Worksheet: "'"},
+			wantErr: false,
+		},
+		{
+			name:    "UnquotedWithSpaces",
+			args:    args{s: "My Sheet!A1:B2"},
+			want:    &DataSpec{Worksheet: "My Sheet", Range: MustRangeFromString("A1:B2")},
+			wantErr: false,
+		},
+		{
+			name:    "UnquotedWithBang",
+			args:    args{s: "Wow!!A1:B2"},
+			want:    &DataSpec{Worksheet: "Wow!", Range: MustRangeFromString("A1:B2")},
+			wantErr: false,
+		},
+		{
+			name:    "Unterminated",
+			args:    args{s: "'My Sheet!A1:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "JunkAfterQuote",
+			args:    args{s: "'My Sheet'A1:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "EmptyRange",
+			args:    args{s: "mysheet!"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "NamedRange",
+			args:    args{s: "=Totals"},
+			want:    &DataSpec{NamedRange: "Totals"},
+			wantErr: false,
+		},
+		{
+			name:    "EmptyNamedRange",
+			args:    args{s: "="},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -106,6 +200,36 @@ func TestDataSpec_FromString(t *testing.T) {
 	}
 }
 
+func TestQuoteWorksheet(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Sheet1", want: "Sheet1"},
+		{title: "my_sheet", want: "my_sheet"},
+		{title: "My Sheet", want: "'My Sheet'"},
+		{title: "Q1 'Sales'", want: "'Q1 ''Sales'''"},
+		{title: "Wow!", want: "'Wow!'"},
+		{title: "A1", want: "'A1'"},
+		{title: "2024", want: "'2024'"},
+		{title: "Café", want: "'Café'"},
+		{title: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := QuoteWorksheet(tt.title)
+			if got != tt.want {
+				t.Errorf("QuoteWorksheet(%q) = %v, want %v", tt.title, got, tt.want)
+			}
+			// And back again.
+			spec, err := (&DataSpec{}).FromString(got + "!A1:B2")
+			if err != nil || spec.Worksheet != tt.title {
+				t.Errorf("FromString(%q) = %v, %v, want worksheet %q", got+"!A1:B2", spec, err, tt.title)
+			}
+		})
+	}
+}
+
 func Test_mergeDataSpecs(t *testing.T) {
 	type args struct {
 		specs []*DataSpec
@@ -124,14 +248,14 @@ func Test_mergeDataSpecs(t *testing.T) {
 		},
 		{
 			name:    "FullNoClashes",
-			args:    args{specs: []*DataSpec{{Workbook: "mybook"}, {Worksheet: "mysheet"}, {Range: RangeFromString("A1:B100")}}},
-			want:    &DataSpec{Workbook: "mybook", Worksheet: "mysheet", Range: RangeFromString("A1:B100")},
+			args:    args{specs: []*DataSpec{{Workbook: "mybook"}, {Worksheet: "mysheet"}, {Range: MustRangeFromString("A1:B100")}}},
+			want:    &DataSpec{Workbook: "mybook", Worksheet: "mysheet", Range: MustRangeFromString("A1:B100")},
 			wantErr: false,
 		},
 		{
 			name:    "PartialNoClashes",
-			args:    args{specs: []*DataSpec{{}, {Worksheet: "mysheet"}, {Range: RangeFromString("A1:B100")}}},
-			want:    &DataSpec{Worksheet: "mysheet", Range: RangeFromString("A1:B100")},
+			args:    args{specs: []*DataSpec{{}, {Worksheet: "mysheet"}, {Range: MustRangeFromString("A1:B100")}}},
+			want:    &DataSpec{Worksheet: "mysheet", Range: MustRangeFromString("A1:B100")},
 			wantErr: false,
 		},
 		{
@@ -146,7 +270,23 @@ func Test_mergeDataSpecs(t *testing.T) {
 		},
 		{
 			name:    "SimpleRangeClash",
-			args:    args{specs: []*DataSpec{{}, {Range: RangeFromString("A1:B100")}, {Range: RangeFromString("C1:D100")}}},
+			args:    args{specs: []*DataSpec{{}, {Range: MustRangeFromString("A1:B100")}, {Range: MustRangeFromString("C1:D100")}}},
+			wantErr: true,
+		},
+		{
+			name:    "NamedRange",
+			args:    args{specs: []*DataSpec{{Workbook: "mybook"}, {NamedRange: "Totals"}}},
+			want:    &DataSpec{Workbook: "mybook", NamedRange: "Totals"},
+			wantErr: false,
+		},
+		{
+			name:    "NamedRangeClash",
+			args:    args{specs: []*DataSpec{{NamedRange: "Totals"}, {NamedRange: "Others"}}},
+			wantErr: true,
+		},
+		{
+			name:    "NamedRangeWithWorksheet",
+			args:    args{specs: []*DataSpec{{Workbook: "mybook", Worksheet: "mysheet"}, {NamedRange: "Totals"}}},
 			wantErr: true,
 		},
 	}
@@ -219,13 +359,13 @@ func TestExpandArgsToDataSpec(t *testing.T) {
 		{
 			name:    "AliasedWorksheetWithRange",
 			args:    args{args: []string{"@myworksheet!A3:F6"}},
-			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: RangeFromString("A3:F6")},
+			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A3:F6")},
 			wantErr: false,
 		},
 		{
 			name:    "BareWorkbookAndSheetWithRange",
 			args:    args{args: []string{"myworkbook", "myworksheet!A1:B100"}},
-			want:    &DataSpec{Workbook: "myworkbook", Worksheet: "myworksheet", Range: RangeFromString("A1:B100")},
+			want:    &DataSpec{Workbook: "myworkbook", Worksheet: "myworksheet", Range: MustRangeFromString("A1:B100")},
 			wantErr: false,
 		},
 		{
@@ -237,7 +377,7 @@ func TestExpandArgsToDataSpec(t *testing.T) {
 		{
 			name:    "AliasedWorkbookAndSheetWithRange",
 			args:    args{args: []string{"@myworkbook", "myworksheet!A1:B100"}},
-			want:    &DataSpec{Workbook: "mywb", Worksheet: "myworksheet", Range: RangeFromString("A1:B100")},
+			want:    &DataSpec{Workbook: "mywb", Worksheet: "myworksheet", Range: MustRangeFromString("A1:B100")},
 			wantErr: false,
 		},
 		{
@@ -303,13 +443,13 @@ func Test_dataSpecFromAlias(t *testing.T) {
 		{
 			name:    "RangeAlias",
 			args:    args{aliasname: "myrange"},
-			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: RangeFromString("A2:B3")},
+			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A2:B3")},
 			wantErr: false,
 		},
 		{
 			name:    "BangNotationWithWorksheetAlias",
 			args:    args{aliasname: "myworksheet!A1:C5"},
-			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: RangeFromString("A1:C5")},
+			want:    &DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A1:C5")},
 			wantErr: false,
 		},
 		{
@@ -330,7 +470,7 @@ func Test_dataSpecFromAlias(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := dataSpecFromAlias(tt.args.aliasname)
+			got, err := DefaultClient().dataSpecFromAlias(tt.args.aliasname)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("dataSpecFromAlias() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -350,6 +490,7 @@ func TestDataSpec_IsWorkbook(t *testing.T) {
 	}{
 		{name: "JustWorkbook", spec: DataSpec{Workbook: "wb"}, want: true},
 		{name: "WithWorksheet", spec: DataSpec{Workbook: "wb", Worksheet: "ws"}, want: false},
+		{name: "WithNamedRange", spec: DataSpec{Workbook: "wb", NamedRange: "Totals"}, want: false},
 		{name: "Empty", spec: DataSpec{}, want: false},
 	}
 	for _, tt := range tests {
@@ -369,7 +510,7 @@ func TestDataSpec_IsWorksheet(t *testing.T) {
 	}{
 		{name: "WorkbookAndWorksheet", spec: DataSpec{Workbook: "wb", Worksheet: "ws"}, want: true},
 		{name: "JustWorkbook", spec: DataSpec{Workbook: "wb"}, want: false},
-		{name: "WithRange", spec: DataSpec{Workbook: "wb", Worksheet: "ws", Range: RangeFromString("A1:B2")}, want: false},
+		{name: "WithRange", spec: DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:B2")}, want: false},
 		{name: "Empty", spec: DataSpec{}, want: false},
 	}
 	for _, tt := range tests {
@@ -387,9 +528,9 @@ func TestDataSpec_IsRange(t *testing.T) {
 		spec DataSpec
 		want bool
 	}{
-		{name: "FullSpec", spec: DataSpec{Workbook: "wb", Worksheet: "ws", Range: RangeFromString("A1:B2")}, want: true},
+		{name: "FullSpec", spec: DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:B2")}, want: true},
 		{name: "NoRange", spec: DataSpec{Workbook: "wb", Worksheet: "ws"}, want: false},
-		{name: "NoWorksheet", spec: DataSpec{Workbook: "wb", Range: RangeFromString("A1:B2")}, want: false},
+		{name: "NoWorksheet", spec: DataSpec{Workbook: "wb", Range: MustRangeFromString("A1:B2")}, want: false},
 		{name: "Empty", spec: DataSpec{}, want: false},
 	}
 	for _, tt := range tests {
@@ -419,7 +560,7 @@ func TestDataSpec_String(t *testing.T) {
 		},
 		{
 			name: "FullSpec",
-			spec: DataSpec{Workbook: "mywb", Worksheet: "myws", Range: RangeFromString("A1:B2")},
+			spec: DataSpec{Workbook: "mywb", Worksheet: "myws", Range: MustRangeFromString("A1:B2")},
 			want: "Workbook: mywb, Worksheet: myws, Range: A1:B2",
 		},
 	}
@@ -462,7 +603,17 @@ func TestDataRange_String(t *testing.T) {
 		{
 			name:   "SingleCell",
 			fields: fields{StartRow: 1, StartCol: 1, EndRow: 1, EndCol: 1},
-			want:   "A1:A1",
+			want:   "A1",
+		},
+		{
+			name:   "OpenEndedRows",
+			fields: fields{StartRow: 2, StartCol: 1, EndRow: 0, EndCol: 3},
+			want:   "A2:C",
+		},
+		{
+			name:   "OpenEndedColumns",
+			fields: fields{StartRow: 2, StartCol: 2, EndRow: 5, EndCol: 0},
+			want:   "B2:5",
 		},
 	}
 	for _, tt := range tests {
@@ -512,12 +663,208 @@ func TestDataRange_FromString(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "ImproperSingleCellNoColon",
+			name:    "SingleCellNoColon",
+			fields:  fields{},
+			args:    args{s: "B3"},
+			want:    &DataRange{StartRow: 3, StartCol: 2, EndRow: 3, EndCol: 2},
+			wantErr: false,
+		},
+		{
+			name:    "ColumnIsNotACell",
+			fields:  fields{},
+			args:    args{s: "B"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "RowIsNotACell",
+			fields:  fields{},
+			args:    args{s: "3"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "WholeColumns",
+			fields:  fields{},
+			args:    args{s: "A:C"},
+			want:    &DataRange{StartCol: 1, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "WholeRows",
+			fields:  fields{},
+			args:    args{s: "2:5"},
+			want:    &DataRange{StartRow: 2, EndRow: 5},
+			wantErr: false,
+		},
+		{
+			name:    "OpenEndedRows",
+			fields:  fields{},
+			args:    args{s: "A2:C"},
+			want:    &DataRange{StartRow: 2, StartCol: 1, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "OpenEndedColumns",
+			fields:  fields{},
+			args:    args{s: "B2:5"},
+			want:    &DataRange{StartRow: 2, StartCol: 2, EndRow: 5},
+			wantErr: false,
+		},
+		{
+			name:    "ColumnToCell",
+			fields:  fields{},
+			args:    args{s: "A:C5"},
+			want:    &DataRange{StartRow: 1, StartCol: 1, EndRow: 5, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "RowToCell",
+			fields:  fields{},
+			args:    args{s: "2:C5"},
+			want:    &DataRange{StartRow: 2, StartCol: 1, EndRow: 5, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "MixedRowsAndColumns",
+			fields:  fields{},
+			args:    args{s: "A:3"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "MixedColumnsAndRows",
+			fields:  fields{},
+			args:    args{s: "3:A"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "Absolute",
+			fields:  fields{},
+			args:    args{s: "$A$1:$C$10"},
+			want:    &DataRange{StartRow: 1, StartCol: 1, EndRow: 10, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "PartlyAbsolute",
+			fields:  fields{},
+			args:    args{s: "A$1:$C10"},
+			want:    &DataRange{StartRow: 1, StartCol: 1, EndRow: 10, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "AbsoluteColumnsAndRows",
+			fields:  fields{},
+			args:    args{s: "$A:$C"},
+			want:    &DataRange{StartCol: 1, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "AbsoluteSingleCell",
+			fields:  fields{},
+			args:    args{s: "$B$3"},
+			want:    &DataRange{StartRow: 3, StartCol: 2, EndRow: 3, EndCol: 2},
+			wantErr: false,
+		},
+		{
+			name:    "DanglingDollar",
+			fields:  fields{},
+			args:    args{s: "A$:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "DoubleDollar",
+			fields:  fields{},
+			args:    args{s: "$$A1:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "Lowercase",
+			fields:  fields{},
+			args:    args{s: "aa1:ab10"},
+			want:    &DataRange{StartRow: 1, StartCol: 27, EndRow: 10, EndCol: 28},
+			wantErr: false,
+		},
+		{
+			name:    "MixedCase",
+			fields:  fields{},
+			args:    args{s: "aB1:Ac10"},
+			want:    &DataRange{StartRow: 1, StartCol: 28, EndRow: 10, EndCol: 29},
+			wantErr: false,
+		},
+		{
+			name:    "BackwardsColumns",
+			fields:  fields{},
+			args:    args{s: "B:A"},
+			want:    &DataRange{StartCol: 1, EndCol: 2},
+			wantErr: false,
+		},
+		{
+			name:    "BackwardsCells",
+			fields:  fields{},
+			args:    args{s: "C10:A1"},
+			want:    &DataRange{StartRow: 1, StartCol: 1, EndRow: 10, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "Diagonal",
+			fields:  fields{},
+			args:    args{s: "C1:A10"},
+			want:    &DataRange{StartRow: 1, StartCol: 1, EndRow: 10, EndCol: 3},
+			wantErr: false,
+		},
+		{
+			name:    "BackwardsOpenEnded",
+			fields:  fields{},
+			args:    args{s: "B5:2"},
+			want:    &DataRange{StartRow: 2, StartCol: 2, EndRow: 5},
+			wantErr: false,
+		},
+		{
+			name:    "RowZero",
+			fields:  fields{},
+			args:    args{s: "A0:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "RowBeforeColumn",
+			fields:  fields{},
+			args:    args{s: "1A:B2"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "TooManyColumnLetters",
 			fields:  fields{},
-			args:    args{s: "A1"},
+			args:    args{s: "AAAA1:B2"},
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name:    "Empty",
+			fields:  fields{},
+			args:    args{s: ""},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "EmptyEnd",
+			fields:  fields{},
+			args:    args{s: "A1:"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "Replaces",
+			fields:  fields{StartRow: 7, StartCol: 7, EndRow: 8, EndCol: 8},
+			args:    args{s: "A:B"},
+			want:    &DataRange{StartCol: 1, EndCol: 2},
+			wantErr: false,
+		},
 		{
 			name:    "SimpleError",
 			fields:  fields{},
@@ -567,6 +914,40 @@ func TestDataRange_FromString(t *testing.T) {
 	}
 }
 
+func TestDataRange_RoundTrip(t *testing.T) {
+	// Everything FromString accepts comes out of String as normalised A1 notation, which parses back the same.
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "A1:J10", want: "A1:J10"},
+		{in: "b3", want: "B3"},
+		{in: "B3:B3", want: "B3"},
+		{in: "$A$1:$C$10", want: "A1:C10"},
+		{in: "B:A", want: "A:B"},
+		{in: "5:2", want: "2:5"},
+		{in: "a2:c", want: "A2:C"},
+		{in: "B5:2", want: "B2:5"},
+		{in: "A:C5", want: "A1:C5"},
+		{in: "ZZZ1:A1", want: "A1:ZZZ1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := RangeFromString(tt.in)
+			if err != nil {
+				t.Fatalf("RangeFromString(%q) error = %v", tt.in, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("RangeFromString(%q).String() = %v, want %v", tt.in, got, tt.want)
+			}
+			again, err := RangeFromString(r.String())
+			if err != nil || again != r {
+				t.Errorf("RangeFromString(%q) = %v, %v, want %v", r.String(), again, err, r)
+			}
+		})
+	}
+}
+
 func Test_colToLetter(t *testing.T) {
 	type args struct {
 		col int
@@ -635,19 +1016,19 @@ func TestDataRange_SizeXY(t *testing.T) {
 	}{
 		{
 			name: "Simple",
-			rng:  RangeFromString("A1:T10"),
+			rng:  MustRangeFromString("A1:T10"),
 			cols: 20,
 			rows: 10,
 		},
 		{
 			name: "SingleCell",
-			rng:  RangeFromString("A1:A1"),
+			rng:  MustRangeFromString("A1:A1"),
 			cols: 1,
 			rows: 1,
 		},
 		{
 			name: "SeveralColumns",
-			rng:  RangeFromString("A:D"),
+			rng:  MustRangeFromString("A:D"),
 			cols: 4,
 			rows: 0,
 		},
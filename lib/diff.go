@@ -0,0 +1,223 @@
+package sheet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// CellChange is a cell whose value differs between two sets of values.
+type CellChange struct {
+	// The cell's address, e.g. "B3".
+	Cell string `json:"cell"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// RowChange is a row that's only in one of two sets of values, when comparing by key.
+type RowChange struct {
+	Key string `json:"key"`
+	// The row's number in the worksheet (or file) it's in.
+	Row    int      `json:"row"`
+	Values []string `json:"values"`
+}
+
+// Diff is the difference between two sets of values, as returned by DiffValues.
+type Diff struct {
+	// Cells that differ, in the first set's coordinates, ordered by row and column.
+	Changed []CellChange `json:"changed"`
+	// For keyed diffs, rows whose keys are only in the second set, or only in the first.
+	Added   []RowChange `json:"added,omitempty"`
+	Removed []RowChange `json:"removed,omitempty"`
+}
+
+// Empty returns true if there are no differences.
+func (d *Diff) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffOptions controls how DiffValues compares values.
+type DiffOptions struct {
+	// If set, the first row of each set is a header, and the rest are matched up by their value
+	// in the column with this header, rather than by position. Rows with a blank key (like blank rows)
+	// can't be matched, so are skipped. Columns are still compared by position.
+	Key string
+	// Where the first set of values is in its worksheet, for cell addresses. The zero value is A1.
+	ARange DataRange
+	// Likewise for the second set of values, for the row numbers of added rows.
+	BRange DataRange
+}
+
+// cellAddress returns the A1 address of a cell, given 1-based coordinates.
+func cellAddress(row int, col int) string {
+	return colToLetter(col) + strconv.Itoa(row)
+}
+
+// rangeStart returns the 1-based row and column a range starts at.
+func rangeStart(r DataRange) (int, int) {
+	return max(r.StartRow, 1), max(r.StartCol, 1)
+}
+
+// StringsFromValueRange converts the values in a ValueRange to text, as they'd be output as csv.
+func StringsFromValueRange(v *sheets.ValueRange) [][]string {
+	ret := make([][]string, len(v.Values))
+	for i, row := range v.Values {
+		ret[i] = make([]string, len(row))
+		for j := range row {
+			ret[i][j] = CellFromValue(row[j]).String()
+		}
+	}
+	return ret
+}
+
+func cellAt(data [][]string, i int, j int) string {
+	if i >= len(data) || j >= len(data[i]) {
+		return ""
+	}
+	return data[i][j]
+}
+
+// diffRows appends the cells that differ between row ai of a and row bi of b to d.
+func diffRows(d *Diff, a [][]string, ai int, b [][]string, bi int, row int, col int) {
+	width := 0
+	if ai < len(a) {
+		width = len(a[ai])
+	}
+	if bi < len(b) {
+		width = max(width, len(b[bi]))
+	}
+	for j := 0; j < width; j++ {
+		was, now := cellAt(a, ai, j), cellAt(b, bi, j)
+		if was != now {
+			d.Changed = append(d.Changed, CellChange{Cell: cellAddress(row+ai, col+j), Old: was, New: now})
+		}
+	}
+}
+
+// keyColumn returns the index of the column with the given header, or an error if there isn't exactly one.
+func keyColumn(header []string, key string) (int, error) {
+	ret := -1
+	for i, h := range header {
+		if h == key {
+			if ret >= 0 {
+				return 0, fmt.Errorf("more than one %v column in header", key)
+			}
+			ret = i
+		}
+	}
+	if ret < 0 {
+		return 0, fmt.Errorf("no %v column in header: %v", key, header)
+	}
+	return ret, nil
+}
+
+// keyRows returns the index of each row of data (after the header) by its value in column col. Rows with
+// no key (e.g. blank rows between sections) are left out, rather than being duplicates.
+func keyRows(data [][]string, col int) (map[string]int, error) {
+	ret := map[string]int{}
+	for i := 1; i < len(data); i++ {
+		key := cellAt(data, i, col)
+		if key == "" {
+			continue
+		}
+		if _, ok := ret[key]; ok {
+			return nil, fmt.Errorf("duplicate key %q in rows %d and %d", key, ret[key]+1, i+1)
+		}
+		ret[key] = i
+	}
+	return ret, nil
+}
+
+// DiffValues compares two sets of values, e.g. from StringsFromValueRange or ScanValues, returning
+// what would need to change in a to make it the same as b. opts may be nil.
+func DiffValues(a [][]string, b [][]string, opts *DiffOptions) (*Diff, error) {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	row, col := rangeStart(opts.ARange)
+	ret := &Diff{Changed: []CellChange{}}
+
+	if opts.Key == "" {
+		for i := 0; i < max(len(a), len(b)); i++ {
+			diffRows(ret, a, i, b, i, row, col)
+		}
+		return ret, nil
+	}
+
+	if len(a) == 0 || len(b) == 0 {
+		return nil, fmt.Errorf("can't compare by key without a header row")
+	}
+	akey, err := keyColumn(a[0], opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	bkey, err := keyColumn(b[0], opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	arows, err := keyRows(a, akey)
+	if err != nil {
+		return nil, err
+	}
+	brows, err := keyRows(b, bkey)
+	if err != nil {
+		return nil, err
+	}
+
+	diffRows(ret, a, 0, b, 0, row, col)
+	for i := 1; i < len(a); i++ {
+		key := cellAt(a, i, akey)
+		if key == "" {
+			continue
+		}
+		if bi, ok := brows[key]; ok {
+			diffRows(ret, a, i, b, bi, row, col)
+		} else {
+			ret.Removed = append(ret.Removed, RowChange{Key: key, Row: row + i, Values: a[i]})
+		}
+	}
+	brow, _ := rangeStart(opts.BRange)
+	for i := 1; i < len(b); i++ {
+		key := cellAt(b, i, bkey)
+		if _, ok := arows[key]; !ok && key != "" {
+			ret.Added = append(ret.Added, RowChange{Key: key, Row: brow + i, Values: b[i]})
+		}
+	}
+	return ret, nil
+}
+
+// quoteCells formats a row for WriteDiff.
+func quoteCells(row []string) string {
+	ret := make([]string, len(row))
+	for i, c := range row {
+		ret[i] = strconv.Quote(c)
+	}
+	return strings.Join(ret, ",")
+}
+
+// WriteDiff writes a Diff as text, a line per change:
+//
+//	B3: "30" → "31"
+//	- bob (row 4): "bob","25"
+//	+ dave (row 5): "dave","40"
+func WriteDiff(w io.Writer, d *Diff) error {
+	for _, c := range d.Changed {
+		if _, err := fmt.Fprintf(w, "%v: %q → %q\n", c.Cell, c.Old, c.New); err != nil {
+			return err
+		}
+	}
+	for _, r := range d.Removed {
+		if _, err := fmt.Fprintf(w, "- %v (row %d): %v\n", r.Key, r.Row, quoteCells(r.Values)); err != nil {
+			return err
+		}
+	}
+	for _, r := range d.Added {
+		if _, err := fmt.Fprintf(w, "+ %v (row %d): %v\n", r.Key, r.Row, quoteCells(r.Values)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package sheet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffValues(t *testing.T) {
+	people := [][]string{{"name", "age"}, {"alice", "30"}, {"bob", "25"}, {"carol", "35"}}
+	tests := []struct {
+		name    string
+		a       [][]string
+		b       [][]string
+		opts    *DiffOptions
+		want    *Diff
+		wantErr bool
+	}{
+		{
+			name: "Same",
+			a:    people,
+			b:    people,
+			want: &Diff{Changed: []CellChange{}},
+		},
+		{
+			name: "Positional",
+			a:    people,
+			b:    [][]string{{"name", "age"}, {"alice", "31"}, {"bob", "25", "x"}},
+			want: &Diff{Changed: []CellChange{
+				{Cell: "B2", Old: "30", New: "31"},
+				{Cell: "C3", Old: "", New: "x"},
+				{Cell: "A4", Old: "carol", New: ""},
+				{Cell: "B4", Old: "35", New: ""},
+			}},
+		},
+		{
+			name: "PositionalOffset",
+			a:    [][]string{{"1", "2"}},
+			b:    [][]string{{"1", "3"}},
+			opts: &DiffOptions{ARange: MustRangeFromString("C5:D5")},
+			want: &Diff{Changed: []CellChange{{Cell: "D5", Old: "2", New: "3"}}},
+		},
+		{
+			name: "Keyed",
+			a:    people,
+			b:    [][]string{{"name", "age"}, {"dave", "40"}, {"carol", "36"}, {"alice", "30"}},
+			opts: &DiffOptions{Key: "name"},
+			want: &Diff{
+				Changed: []CellChange{{Cell: "B4", Old: "35", New: "36"}},
+				Added:   []RowChange{{Key: "dave", Row: 2, Values: []string{"dave", "40"}}},
+				Removed: []RowChange{{Key: "bob", Row: 3, Values: []string{"bob", "25"}}},
+			},
+		},
+		{
+			name: "KeyedMovedKeyColumn",
+			a:    people,
+			b:    [][]string{{"age", "name"}, {"30", "alice"}},
+			opts: &DiffOptions{Key: "name", ARange: MustRangeFromString("A10:B13"), BRange: MustRangeFromString("A5:B6")},
+			want: &Diff{
+				Changed: []CellChange{
+					{Cell: "A10", Old: "name", New: "age"},
+					{Cell: "B10", Old: "age", New: "name"},
+					{Cell: "A11", Old: "alice", New: "30"},
+					{Cell: "B11", Old: "30", New: "alice"},
+				},
+				Removed: []RowChange{
+					{Key: "bob", Row: 12, Values: []string{"bob", "25"}},
+					{Key: "carol", Row: 13, Values: []string{"carol", "35"}},
+				},
+			},
+		},
+		{
+			name: "KeyedBlankRows",
+			a:    [][]string{{"name", "age"}, {"alice", "30"}, {}, {}, {"", "note"}},
+			b:    [][]string{{"name", "age"}, {}, {"alice", "31"}, {"", "other"}},
+			opts: &DiffOptions{Key: "name"},
+			want: &Diff{Changed: []CellChange{{Cell: "B2", Old: "30", New: "31"}}},
+		},
+		{
+			name:    "NoKeyColumn",
+			a:       people,
+			b:       people,
+			opts:    &DiffOptions{Key: "sku"},
+			wantErr: true,
+		},
+		{
+			name:    "DuplicateKey",
+			a:       people,
+			b:       [][]string{{"name", "age"}, {"bob", "1"}, {"bob", "2"}},
+			opts:    &DiffOptions{Key: "name"},
+			wantErr: true,
+		},
+		{
+			name:    "KeyedNoHeader",
+			a:       people,
+			b:       [][]string{},
+			opts:    &DiffOptions{Key: "name"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffValues(tt.a, tt.b, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DiffValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffValues() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	d := &Diff{
+		Changed: []CellChange{{Cell: "B4", Old: "35", New: "36"}},
+		Added:   []RowChange{{Key: "dave", Row: 2, Values: []string{"dave", "40"}}},
+		Removed: []RowChange{{Key: "bob", Row: 3, Values: []string{"bob", "25, or so"}}},
+	}
+	got := new(strings.Builder)
+	if err := WriteDiff(got, d); err != nil {
+		t.Fatalf("WriteDiff() error = %v", err)
+	}
+	want := "B4: \"35\" → \"36\"\n- bob (row 3): \"bob\",\"25, or so\"\n+ dave (row 2): \"dave\",\"40\"\n"
+	if got.String() != want {
+		t.Errorf("WriteDiff() = %q, want %q", got.String(), want)
+	}
+}
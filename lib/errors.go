@@ -0,0 +1,45 @@
+package sheet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned (usually wrapped) by this package, for use with errors.Is.
+var (
+	// An alias was referred to that isn't in the config.
+	ErrAliasNotFound = errors.New("alias not found")
+	// A worksheet is protected (with protect-worksheets), and the operation wasn't forced.
+	ErrProtected = errors.New("protected")
+	// Data doesn't fit in the range it's being written to. See also DataOverflowError.
+	ErrDataOverflow = errors.New("data overflow")
+	// A worksheet was referred to that isn't in the workbook.
+	ErrWorksheetNotFound = errors.New("worksheet not found")
+	// A named range was referred to that isn't in the workbook.
+	ErrNamedRangeNotFound = errors.New("named range not found")
+	// A range (e.g. "A1:B2") couldn't be parsed.
+	ErrInvalidRange = errors.New("invalid range")
+	// A cell didn't have the value a patch expected it to have, so the patch wasn't applied.
+	ErrConflict = errors.New("conflict")
+)
+
+// DataOverflowError gives the details of an ErrDataOverflow.
+type DataOverflowError struct {
+	// The size of the range being written to.
+	RangeRows int
+	RangeCols int
+	// The size of the data being written.
+	DataRows int
+	DataCols int
+}
+
+func (e *DataOverflowError) Error() string {
+	if e.DataRows > e.RangeRows {
+		return fmt.Sprintf("data overflow: %d rows in range, %d in data", e.RangeRows, e.DataRows)
+	}
+	return fmt.Sprintf("data overflow: %d columns in range, %d in data", e.RangeCols, e.DataCols)
+}
+
+func (e *DataOverflowError) Unwrap() error {
+	return ErrDataOverflow
+}
@@ -0,0 +1,100 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	SetupTempConfig(t, "alias_small")
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "ws")
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{
+			name: "GetAlias",
+			call: func() error { _, err := GetAlias("nope"); return err },
+			want: ErrAliasNotFound,
+		},
+		{
+			name: "ExpandArgsToDataSpec",
+			call: func() error { _, err := ExpandArgsToDataSpec([]string{"@nope"}); return err },
+			want: ErrAliasNotFound,
+		},
+		{
+			name: "ClearWorksheet",
+			call: func() error {
+				return ClearWorksheet(context.Background(), b, &DataSpec{Workbook: "wb", Worksheet: "ws"}, true, false)
+			},
+			want: ErrProtected,
+		},
+		{
+			name: "WriteDataToRange",
+			call: func() error {
+				spec := &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:A1")}
+				return WriteDataToRange(context.Background(), b, spec, [][]string{{"a", "b"}}, nil)
+			},
+			want: ErrDataOverflow,
+		},
+		{
+			name: "FindWorksheet",
+			call: func() error { _, err := FindWorksheet(context.Background(), b, "wb", "nope"); return err },
+			want: ErrWorksheetNotFound,
+		},
+		{
+			name: "GetValues",
+			call: func() error { _, err := b.GetValues(context.Background(), "wb", "nope!A1:B2", nil); return err },
+			want: ErrWorksheetNotFound,
+		},
+		{
+			name: "FindNamedRange",
+			call: func() error { _, err := FindNamedRange(context.Background(), b, "wb", "nope"); return err },
+			want: ErrNamedRangeNotFound,
+		},
+		{
+			name: "RangeFromString",
+			call: func() error { _, err := RangeFromString("A1-B2"); return err },
+			want: ErrInvalidRange,
+		},
+		{
+			name: "DataSpecFromString",
+			call: func() error { _, err := (&DataSpec{}).FromString("ws!A1:B2:C3"); return err },
+			want: ErrInvalidRange,
+		},
+		{
+			name: "PatchCells",
+			call: func() error {
+				spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+				old := "was"
+				return PatchCells(context.Background(), b, spec, []CellPatch{{Cell: "A1", New: "now", Old: &old}}, &PatchOptions{Verify: true})
+			},
+			want: ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("%v() error = %v, want %v", tt.name, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataOverflowError(t *testing.T) {
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:B2")}
+	err := checkDataFitsInRange(spec, [][]string{{"1", "2"}, {"3", "4"}, {"5", "6"}})
+
+	var overflow *DataOverflowError
+	if !errors.As(err, &overflow) {
+		t.Fatalf("checkDataFitsInRange() error = %v, want a DataOverflowError", err)
+	}
+	want := DataOverflowError{RangeRows: 2, RangeCols: 2, DataRows: 3, DataCols: 2}
+	if *overflow != want {
+		t.Errorf("checkDataFitsInRange() error = %+v, want %+v", *overflow, want)
+	}
+}
@@ -0,0 +1,209 @@
+package sheet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Predicate is a condition on one column of a row, e.g. Status=active or Age>30.
+type Predicate struct {
+	// A header, or a column letter.
+	Column string
+	// One of =, !=, <, <=, >, >=
+	Op    string
+	Value string
+}
+
+// The operators a Predicate can have, longest first so "<=" isn't read as "<".
+var predicateOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// ParsePredicate parses a condition like 'Status=active', 'Age>30' or 'Name != bob'.
+func ParsePredicate(s string) (*Predicate, error) {
+	for i := 0; i < len(s); i++ {
+		for _, op := range predicateOps {
+			if strings.HasPrefix(s[i:], op) {
+				column := strings.TrimSpace(s[:i])
+				if column == "" {
+					return nil, fmt.Errorf("no column in condition: %v", s)
+				}
+				return &Predicate{Column: column, Op: op, Value: strings.TrimSpace(s[i+len(op):])}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no operator (%v) in condition: %v", strings.Join(predicateOps, " "), s)
+}
+
+// compareCells compares two values as numbers if they both are, or as text otherwise.
+func compareCells(a Cell, b Cell) int {
+	if a.Kind == StringCell {
+		a = ParseCell(a.Text)
+	}
+	if b.Kind == StringCell {
+		b = ParseCell(b.Text)
+	}
+	if a.Kind == NumberCell && b.Kind == NumberCell {
+		switch {
+		case a.Number < b.Number:
+			return -1
+		case a.Number > b.Number:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+// Match returns true if v, a value in the predicate's column, satisfies it.
+func (p *Predicate) Match(v interface{}) bool {
+	return compareOp(p.Op, CellFromValue(v), ParseCell(p.Value))
+}
+
+// compareOp compares a and b with one of the Predicate operators (or <>, for !=). Empty cells are only
+// equal to (or not equal to) things, so an empty Age doesn't match Age<30.
+func compareOp(op string, a Cell, b Cell) bool {
+	if (a.Kind == EmptyCell) != (b.Kind == EmptyCell) && op != "=" && op != "!=" && op != "<>" {
+		return false
+	}
+	c := compareCells(a, b)
+	switch op {
+	case "=":
+		return c == 0
+	case "!=", "<>":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// columnIndex returns the 0-based index of a column in a row, given its header or its letter. Letters must be
+// uppercase, so they aren't mistaken for headers like "age", and headers win, so a column headed "B"
+// can still be picked by name. startCol is the column the row starts at (1 for A), so that C is still C in a
+// range like C1:F9.
+func columnIndex(header []string, column string, startCol int) (int, error) {
+	for i, h := range header {
+		if h == column {
+			return i, nil
+		}
+	}
+	if column != "" && strings.Trim(column, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		if i := letterToCol(column) - startCol; i >= 0 && i < len(header) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no %v column in header: %v", column, header)
+}
+
+// RowFilter picks columns and rows out of values whose first row is a header. It can be given the values
+// a chunk at a time, as 'cat' reads them.
+type RowFilter struct {
+	columns []string
+	where   []*Predicate
+	// The column the values start at, for column letters.
+	startCol int
+
+	// Set from the header row, once it's been seen.
+	seen      bool
+	cols      []int
+	whereCols []int
+}
+
+// NewRowFilter returns a filter keeping the given columns (headers or letters; all of them if there are none),
+// of the rows matching all of the conditions in where (see ParsePredicate).
+func NewRowFilter(columns []string, where []string) (*RowFilter, error) {
+	ret := &RowFilter{columns: columns, startCol: 1}
+	for _, w := range where {
+		p, err := ParsePredicate(w)
+		if err != nil {
+			return nil, err
+		}
+		ret.where = append(ret.where, p)
+	}
+	return ret, nil
+}
+
+// SetStartColumn says which column the values start at (1 for A, as in DataRange), so column letters refer to
+// the worksheet's columns rather than the values'. 0 is taken as A, as for a range like 1:5.
+func (f *RowFilter) SetStartColumn(col int) {
+	f.startCol = max(col, 1)
+}
+
+// Reset forgets the header, so the filter can be used on another set of values.
+func (f *RowFilter) Reset() {
+	f.seen = false
+	f.cols = nil
+	f.whereCols = nil
+}
+
+func (f *RowFilter) setHeader(row []interface{}) error {
+	header := make([]string, len(row))
+	for i, v := range row {
+		header[i] = CellFromValue(v).String()
+	}
+	for _, c := range f.columns {
+		i, err := columnIndex(header, c, f.startCol)
+		if err != nil {
+			return err
+		}
+		f.cols = append(f.cols, i)
+	}
+	for _, p := range f.where {
+		i, err := columnIndex(header, p.Column, f.startCol)
+		if err != nil {
+			return err
+		}
+		f.whereCols = append(f.whereCols, i)
+	}
+	f.seen = true
+	return nil
+}
+
+func valueAt(row []interface{}, i int) interface{} {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func (f *RowFilter) project(row []interface{}) []interface{} {
+	if len(f.columns) == 0 {
+		return row
+	}
+	ret := make([]interface{}, len(f.cols))
+	for i, col := range f.cols {
+		ret[i] = valueAt(row, col)
+	}
+	return ret
+}
+
+// Filter returns the rows of values that match, with just the chosen columns. The first values given
+// (since the filter was made or Reset) must start with the header row, which is always kept.
+func (f *RowFilter) Filter(values [][]interface{}) ([][]interface{}, error) {
+	ret := [][]interface{}{}
+	for _, row := range values {
+		if !f.seen {
+			if err := f.setHeader(row); err != nil {
+				return nil, err
+			}
+			ret = append(ret, f.project(row))
+			continue
+		}
+		match := true
+		for i, p := range f.where {
+			if !p.Match(valueAt(row, f.whereCols[i])) {
+				match = false
+				break
+			}
+		}
+		if match {
+			ret = append(ret, f.project(row))
+		}
+	}
+	return ret, nil
+}
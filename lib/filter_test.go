@@ -0,0 +1,200 @@
+package sheet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePredicate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *Predicate
+		wantErr bool
+	}{
+		{in: "Status=active", want: &Predicate{Column: "Status", Op: "=", Value: "active"}},
+		{in: "Age>30", want: &Predicate{Column: "Age", Op: ">", Value: "30"}},
+		{in: "Age >= 30", want: &Predicate{Column: "Age", Op: ">=", Value: "30"}},
+		{in: "Name!=bob", want: &Predicate{Column: "Name", Op: "!=", Value: "bob"}},
+		{in: "C<=2.5", want: &Predicate{Column: "C", Op: "<=", Value: "2.5"}},
+		{in: "Notes=a=b", want: &Predicate{Column: "Notes", Op: "=", Value: "a=b"}},
+		{in: "Name=", want: &Predicate{Column: "Name", Op: "=", Value: ""}},
+		{in: "Status", wantErr: true},
+		{in: "=active", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePredicate(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePredicate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePredicate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredicate_Match(t *testing.T) {
+	tests := []struct {
+		pred  string
+		value interface{}
+		want  bool
+	}{
+		{pred: "Age>30", value: "31", want: true},
+		{pred: "Age>30", value: 31.0, want: true},
+		// As numbers, not text.
+		{pred: "Age>30", value: "4", want: false},
+		{pred: "Age<=30", value: "30", want: true},
+		{pred: "Age=30", value: "30.0", want: true},
+		{pred: "Age>30", value: "", want: false},
+		{pred: "Age<30", value: "", want: false},
+		{pred: "Age!=30", value: "", want: true},
+		{pred: "Status=active", value: "active", want: true},
+		{pred: "Status!=active", value: "active", want: false},
+		{pred: "Name<m", value: "bob", want: true},
+		{pred: "Done=TRUE", value: true, want: true},
+		{pred: "Notes=", value: "", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pred, func(t *testing.T) {
+			p, err := ParsePredicate(tt.pred)
+			if err != nil {
+				t.Fatalf("ParsePredicate() error = %v", err)
+			}
+			if got := p.Match(tt.value); got != tt.want {
+				t.Errorf("Predicate(%v).Match(%#v) = %v, want %v", tt.pred, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowFilter(t *testing.T) {
+	values := [][]interface{}{
+		{"Name", "Age", "Status"},
+		{"alice", 30.0, "active"},
+		{"bob", 25.0, "gone"},
+		{"carol", 35.0, "active"},
+		{"dave"},
+	}
+	tests := []struct {
+		name    string
+		columns []string
+		where   []string
+		// How many rows to give the filter at a time, or all of them if 0.
+		chunk int
+		// The column the values start at, if not A.
+		startCol int
+		want     [][]interface{}
+		wantErr  bool
+	}{
+		{
+			name: "Nothing",
+			want: values,
+		},
+		{
+			name:    "Columns",
+			columns: []string{"Status", "Name"},
+			want:    [][]interface{}{{"Status", "Name"}, {"active", "alice"}, {"gone", "bob"}, {"active", "carol"}, {"", "dave"}},
+		},
+		{
+			name:    "ColumnLetters",
+			columns: []string{"A", "C"},
+			want:    [][]interface{}{{"Name", "Status"}, {"alice", "active"}, {"bob", "gone"}, {"carol", "active"}, {"dave", ""}},
+		},
+		{
+			name:    "Where",
+			columns: []string{"Name"},
+			where:   []string{"Status=active", "Age>30"},
+			want:    [][]interface{}{{"Name"}, {"carol"}},
+		},
+		{
+			name:  "Chunked",
+			where: []string{"B<30"},
+			chunk: 2,
+			want:  [][]interface{}{{"Name", "Age", "Status"}, {"bob", 25.0, "gone"}},
+		},
+		{
+			name:     "LettersFromStartColumn",
+			columns:  []string{"E", "C"},
+			where:    []string{"D<30"},
+			startCol: 3,
+			want:     [][]interface{}{{"Status", "Name"}, {"gone", "bob"}},
+		},
+		{
+			name:     "LetterBeforeStartColumn",
+			columns:  []string{"A"},
+			startCol: 3,
+			wantErr:  true,
+		},
+		{
+			name:    "NoSuchColumn",
+			columns: []string{"Email"},
+			wantErr: true,
+		},
+		{
+			name:    "LowercaseLetter",
+			columns: []string{"a"},
+			wantErr: true,
+		},
+		{
+			name:    "LetterPastHeader",
+			columns: []string{"D"},
+			wantErr: true,
+		},
+		{
+			name:    "NoSuchWhereColumn",
+			where:   []string{"Email=x"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewRowFilter(tt.columns, tt.where)
+			if err != nil {
+				t.Fatalf("NewRowFilter() error = %v", err)
+			}
+			if tt.startCol > 0 {
+				f.SetStartColumn(tt.startCol)
+			}
+			chunk := tt.chunk
+			if chunk == 0 {
+				chunk = len(values)
+			}
+			got := [][]interface{}{}
+			for i := 0; i < len(values); i += chunk {
+				rows, err := f.Filter(values[i:min(i+chunk, len(values))])
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatalf("RowFilter.Filter() error = %v", err)
+					}
+					return
+				}
+				got = append(got, rows...)
+			}
+			if tt.wantErr {
+				t.Fatalf("RowFilter.Filter() error = nil, want error")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RowFilter.Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowFilter_Reset(t *testing.T) {
+	f, err := NewRowFilter([]string{"Name"}, nil)
+	if err != nil {
+		t.Fatalf("NewRowFilter() error = %v", err)
+	}
+	if _, err := f.Filter([][]interface{}{{"Age", "Name"}, {30.0, "alice"}}); err != nil {
+		t.Fatalf("RowFilter.Filter() error = %v", err)
+	}
+	f.Reset()
+	got, err := f.Filter([][]interface{}{{"Name", "Age"}, {"bob", 25.0}})
+	if err != nil {
+		t.Fatalf("RowFilter.Filter() error = %v", err)
+	}
+	if want := [][]interface{}{{"Name"}, {"bob"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Reset, RowFilter.Filter() = %v, want %v", got, want)
+	}
+}
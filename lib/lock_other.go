@@ -0,0 +1,38 @@
+//go:build !unix
+
+package sheet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// How long a lock file can be around before we assume its owner died holding it.
+const staleLockAge = 10 * time.Second
+
+// lockFile takes an exclusive lock on f, shared with other processes, and returns a function to release it.
+// Without flock(), this is done by creating a lock file beside f.
+func lockFile(f *os.File) (func(), error) {
+	path := f.Name() + ".lock"
+	deadline := time.Now().Add(2 * staleLockAge)
+	for {
+		l, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			l.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %v", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package sheet
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on f, shared with other processes, and returns a function to release it.
+func lockFile(f *os.File) (func(), error) {
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, err
+	}
+	return func() { syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }, nil
+}
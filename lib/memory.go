@@ -0,0 +1,707 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// The grid size of new worksheets, same as sheets.
+const (
+	defaultRowCount    = 1000
+	defaultColumnCount = 26
+)
+
+// MemoryBackend is an in-memory Backend, modelling workbooks, worksheets and their grids.
+// It's for testing code that uses a Backend without talking to Google.
+//
+// Values written as user-entered are typed the way ParseCell does it. Formulas are stored,
+// but not evaluated -- reading a formula cell gives the formula, whatever the render option.
+// Calls fail with the context's error if it's already done, as API calls would.
+// Named ranges can be added, updated and deleted with BatchUpdate, and read or written by name.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	workbooks map[string]*memWorkbook
+	created   int
+}
+
+type memWorkbook struct {
+	id          string
+	title       string
+	sheets      []*memSheet
+	nextSheetID int64
+	namedRanges []*sheets.NamedRange
+	// For named range IDs, which are strings in the API.
+	nextNamedRangeID int
+}
+
+type memSheet struct {
+	id    int64
+	title string
+	rows  int
+	cols  int
+	// cells[row][col], 0-based, only as big as the data written.
+	cells [][]Cell
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{workbooks: map[string]*memWorkbook{}}
+}
+
+// AddWorkbook adds an (empty) workbook with the given ID and worksheets.
+func (m *MemoryBackend) AddWorkbook(id string, worksheets ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wb := &memWorkbook{id: id, title: id}
+	for _, ws := range worksheets {
+		wb.addSheet(ws, defaultRowCount, defaultColumnCount)
+	}
+	m.workbooks[id] = wb
+}
+
+// SetValues sets the values of a worksheet, from its top-left cell, as if written raw.
+// The worksheet is created if it doesn't exist, and grown to fit the values.
+func (m *MemoryBackend) SetValues(workbook string, worksheet string, values [][]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return err
+	}
+	sh := wb.sheet(worksheet)
+	if sh == nil {
+		sh = wb.addSheet(worksheet, defaultRowCount, defaultColumnCount)
+	}
+	sh.cells = nil
+	for r, row := range values {
+		for c, v := range row {
+			sh.set(r+1, c+1, CellFromValue(v))
+		}
+	}
+	sh.rows = max(sh.rows, len(values))
+	for _, row := range values {
+		sh.cols = max(sh.cols, len(row))
+	}
+	return nil
+}
+
+func (m *MemoryBackend) workbook(id string) (*memWorkbook, error) {
+	wb, ok := m.workbooks[id]
+	if !ok {
+		return nil, fmt.Errorf("workbook not found: %v", id)
+	}
+	return wb, nil
+}
+
+func (wb *memWorkbook) addSheet(title string, rows int, cols int) *memSheet {
+	sh := &memSheet{id: wb.nextSheetID, title: title, rows: rows, cols: cols}
+	wb.nextSheetID++
+	wb.sheets = append(wb.sheets, sh)
+	return sh
+}
+
+func (wb *memWorkbook) sheet(title string) *memSheet {
+	for _, sh := range wb.sheets {
+		if sh.title == title {
+			return sh
+		}
+	}
+	return nil
+}
+
+func (wb *memWorkbook) sheetByID(id int64) (int, *memSheet) {
+	for i, sh := range wb.sheets {
+		if sh.id == id {
+			return i, sh
+		}
+	}
+	return -1, nil
+}
+
+// get and set take 1-based coordinates.
+func (sh *memSheet) get(row int, col int) Cell {
+	if row > len(sh.cells) || col > len(sh.cells[row-1]) {
+		return Cell{}
+	}
+	return sh.cells[row-1][col-1]
+}
+
+func (sh *memSheet) set(row int, col int, c Cell) {
+	for len(sh.cells) < row {
+		sh.cells = append(sh.cells, nil)
+	}
+	for len(sh.cells[row-1]) < col {
+		sh.cells[row-1] = append(sh.cells[row-1], Cell{})
+	}
+	sh.cells[row-1][col-1] = c
+}
+
+// lastDataRow returns the last row with any data between columns c1 and c2, or 0 if there's none.
+func (sh *memSheet) lastDataRow(c1 int, c2 int) int {
+	for r := len(sh.cells); r > 0; r-- {
+		for c := c1; c <= c2; c++ {
+			if sh.get(r, c).Kind != EmptyCell {
+				return r
+			}
+		}
+	}
+	return 0
+}
+
+func (sh *memSheet) properties(index int) *sheets.SheetProperties {
+	return &sheets.SheetProperties{
+		SheetId: sh.id,
+		Title:   sh.title,
+		Index:   int64(index),
+		GridProperties: &sheets.GridProperties{
+			RowCount:    int64(sh.rows),
+			ColumnCount: int64(sh.cols),
+		},
+	}
+}
+
+// memRange is a resolved range: a worksheet and 1-based, inclusive bounds, clipped to the grid.
+type memRange struct {
+	sheet          *memSheet
+	r1, c1, r2, c2 int
+}
+
+func (r *memRange) String() string {
+	rng := DataRange{StartRow: r.r1, StartCol: r.c1, EndRow: r.r2, EndCol: r.c2}
+	spec := DataSpec{Worksheet: r.sheet.title, Range: rng}
+	return spec.GetInSheetDataSpec()
+}
+
+func (wb *memWorkbook) resolve(rng string) (*memRange, error) {
+	spec := &DataSpec{}
+	if _, nr := wb.namedRange(rng); nr != nil && wb.sheet(rng) == nil {
+		// Named ranges can be used as they are, like the API.
+		_, sh := wb.sheetByID(nr.Range.SheetId)
+		spec.Worksheet = sh.title
+		spec.Range = dataRangeFromGridRange(nr.Range)
+	} else if !strings.Contains(rng, "!") && wb.sheet(rng) == nil {
+		// A bare range refers to the first worksheet.
+		r := DataRange{}
+		if _, err := r.FromString(rng); err == nil && len(wb.sheets) > 0 {
+			spec.Worksheet = wb.sheets[0].title
+			spec.Range = r
+		}
+	}
+	if spec.Worksheet == "" {
+		if _, err := spec.FromString(rng); err != nil {
+			return nil, err
+		}
+	}
+
+	sh := wb.sheet(spec.Worksheet)
+	if sh == nil {
+		return nil, fmt.Errorf("%w: %v", ErrWorksheetNotFound, spec.Worksheet)
+	}
+
+	ret := &memRange{sheet: sh, r1: 1, c1: 1, r2: sh.rows, c2: sh.cols}
+	if spec.Range.StartRow > 0 {
+		ret.r1 = spec.Range.StartRow
+	}
+	if spec.Range.StartCol > 0 {
+		ret.c1 = spec.Range.StartCol
+	}
+	if spec.Range.EndRow > 0 {
+		ret.r2 = spec.Range.EndRow
+	}
+	if spec.Range.EndCol > 0 {
+		ret.c2 = spec.Range.EndCol
+	}
+	if ret.r1 > sh.rows || ret.c1 > sh.cols {
+		return nil, fmt.Errorf("range (%v) exceeds grid limits. Max rows: %d, max columns: %d", rng, sh.rows, sh.cols)
+	}
+	ret.r2 = min(ret.r2, sh.rows)
+	ret.c2 = min(ret.c2, sh.cols)
+	return ret, nil
+}
+
+// render returns a cell's value as the API would return it.
+func (c Cell) render(opts *ReadOptions) interface{} {
+	switch opts.ValueRender {
+	case UnformattedRender, FormulaRender:
+		return c.Value()
+	default:
+		return c.String()
+	}
+}
+
+func (m *MemoryBackend) GetValues(ctx context.Context, workbook string, rng string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+	return wb.getValues(rng, opts)
+}
+
+func (m *MemoryBackend) BatchGetValues(ctx context.Context, workbook string, ranges []string, opts *ReadOptions) ([]*sheets.ValueRange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &ReadOptions{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*sheets.ValueRange{}
+	for _, rng := range ranges {
+		vr, err := wb.getValues(rng, opts)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, vr)
+	}
+	return ret, nil
+}
+
+func (wb *memWorkbook) getValues(rng string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	r, err := wb.resolve(rng)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &sheets.ValueRange{Range: r.String(), MajorDimension: "ROWS"}
+	// Like the API, leave out trailing empty rows, and trailing empty cells in each row.
+	last := min(r.r2, r.sheet.lastDataRow(r.c1, r.c2))
+	for row := r.r1; row <= last; row++ {
+		values := []interface{}{}
+		width := 0
+		for col := r.c1; col <= r.c2; col++ {
+			if r.sheet.get(row, col).Kind != EmptyCell {
+				width = col - r.c1 + 1
+			}
+		}
+		for col := r.c1; col < r.c1+width; col++ {
+			values = append(values, r.sheet.get(row, col).render(opts))
+		}
+		ret.Values = append(ret.Values, values)
+	}
+	return ret, nil
+}
+
+// inputCell converts a value written to the API into a Cell, according to the input option.
+func inputCell(v interface{}, input InputOption) Cell {
+	s, ok := v.(string)
+	if !ok {
+		return CellFromValue(v)
+	}
+	if input == RawInput {
+		if s == "" {
+			return Cell{}
+		}
+		return NewStringCell(s)
+	}
+	if strings.HasPrefix(s, "'") {
+		// A leading apostrophe forces text, and isn't part of the value.
+		return NewStringCell(s[1:])
+	}
+	return ParseCell(s)
+}
+
+func (m *MemoryBackend) write(r *memRange, row int, values [][]interface{}, input InputOption) {
+	for i, vals := range values {
+		for j, v := range vals {
+			r.sheet.set(row+i, r.c1+j, inputCell(v, input))
+		}
+	}
+}
+
+func (m *MemoryBackend) UpdateValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return err
+	}
+	r, err := wb.resolveForUpdate(rng, values)
+	if err != nil {
+		return err
+	}
+	m.write(r, r.r1, values.Values, input)
+	return nil
+}
+
+// BatchUpdateValues checks all of the ranges before writing any, so nothing is written if one is bad.
+// Each ValueRange's Range says where it's written.
+func (m *MemoryBackend) BatchUpdateValues(ctx context.Context, workbook string, data []*sheets.ValueRange, input InputOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return err
+	}
+	ranges := []*memRange{}
+	for _, vr := range data {
+		r, err := wb.resolveForUpdate(vr.Range, vr)
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, r)
+	}
+	for i, r := range ranges {
+		m.write(r, r.r1, data[i].Values, input)
+	}
+	return nil
+}
+
+// resolveForUpdate resolves rng, checking that values fit in it.
+func (wb *memWorkbook) resolveForUpdate(rng string, values *sheets.ValueRange) (*memRange, error) {
+	r, err := wb.resolve(rng)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range values.Values {
+		if r.r1+i > r.r2 || r.c1+len(row)-1 > r.c2 {
+			return nil, fmt.Errorf("requested writing within range %v, but tried writing beyond it (or beyond the grid)", r.String())
+		}
+	}
+	return r, nil
+}
+
+func (m *MemoryBackend) AppendValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return "", err
+	}
+	r, err := wb.resolve(rng)
+	if err != nil {
+		return "", err
+	}
+
+	width := 0
+	for _, row := range values.Values {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return "", nil
+	}
+
+	// Insert new rows after the last row of data (INSERT_ROWS), shifting anything below them down.
+	start := max(r.r1, r.sheet.lastDataRow(r.c1, r.c2)+1)
+	if start-1 < len(r.sheet.cells) {
+		inserted := make([][]Cell, len(values.Values))
+		r.sheet.cells = append(r.sheet.cells[:start-1], append(inserted, r.sheet.cells[start-1:]...)...)
+	}
+	r.sheet.rows = max(r.sheet.rows+len(values.Values), start+len(values.Values)-1)
+	r.sheet.cols = max(r.sheet.cols, r.c1+width-1)
+	m.write(r, start, values.Values, input)
+
+	written := &memRange{sheet: r.sheet, r1: start, c1: r.c1, r2: start + len(values.Values) - 1, c2: r.c1 + width - 1}
+	return written.String(), nil
+}
+
+func (m *MemoryBackend) ClearValues(ctx context.Context, workbook string, rng string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return err
+	}
+	r, err := wb.resolve(rng)
+	if err != nil {
+		return err
+	}
+	for row := r.r1; row <= min(r.r2, len(r.sheet.cells)); row++ {
+		for col := r.c1; col <= min(r.c2, len(r.sheet.cells[row-1])); col++ {
+			r.sheet.set(row, col, Cell{})
+		}
+	}
+	return nil
+}
+
+func (m *MemoryBackend) GetWorkbook(ctx context.Context, workbook string) (*sheets.Spreadsheet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+	return wb.spreadsheet(), nil
+}
+
+func (wb *memWorkbook) spreadsheet() *sheets.Spreadsheet {
+	ret := &sheets.Spreadsheet{
+		SpreadsheetId: wb.id,
+		Properties:    &sheets.SpreadsheetProperties{Title: wb.title},
+	}
+	for i, sh := range wb.sheets {
+		ret.Sheets = append(ret.Sheets, &sheets.Sheet{Properties: sh.properties(i)})
+	}
+	for _, nr := range wb.namedRanges {
+		// Copies, so they can't be changed behind our back.
+		rng := *nr.Range
+		ret.NamedRanges = append(ret.NamedRanges, &sheets.NamedRange{NamedRangeId: nr.NamedRangeId, Name: nr.Name, Range: &rng})
+	}
+	return ret
+}
+
+func (wb *memWorkbook) namedRange(name string) (int, *sheets.NamedRange) {
+	for i, nr := range wb.namedRanges {
+		if strings.EqualFold(nr.Name, name) {
+			return i, nr
+		}
+	}
+	return -1, nil
+}
+
+func (wb *memWorkbook) namedRangeByID(id string) (int, *sheets.NamedRange) {
+	for i, nr := range wb.namedRanges {
+		if nr.NamedRangeId == id {
+			return i, nr
+		}
+	}
+	return -1, nil
+}
+
+func (wb *memWorkbook) checkNamedRange(nr *sheets.NamedRange) error {
+	if nr == nil || nr.Name == "" || nr.Range == nil {
+		return fmt.Errorf("a named range needs a name and a range")
+	}
+	if _, sh := wb.sheetByID(nr.Range.SheetId); sh == nil {
+		return fmt.Errorf("no sheet with id %v", nr.Range.SheetId)
+	}
+	return nil
+}
+
+func (m *MemoryBackend) CreateWorkbook(ctx context.Context, title string) (*sheets.Spreadsheet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.created++
+	id := fmt.Sprintf("memory-workbook-%d", m.created)
+	if title == "" {
+		title = "Untitled spreadsheet"
+	}
+	wb := &memWorkbook{id: id, title: title}
+	wb.addSheet("Sheet1", defaultRowCount, defaultColumnCount)
+	m.workbooks[id] = wb
+	return wb.spreadsheet(), nil
+}
+
+func (m *MemoryBackend) BatchUpdate(ctx context.Context, workbook string, requests []*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wb, err := m.workbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API applies all of the requests or none of them, so work on a copy, and keep it only if they all work.
+	wb = wb.clone()
+	ret := &sheets.BatchUpdateSpreadsheetResponse{SpreadsheetId: workbook}
+	for _, req := range requests {
+		reply, err := wb.apply(req)
+		if err != nil {
+			return nil, err
+		}
+		ret.Replies = append(ret.Replies, reply)
+	}
+	m.workbooks[workbook] = wb
+	return ret, nil
+}
+
+// clone returns a deep copy of a workbook.
+func (wb *memWorkbook) clone() *memWorkbook {
+	ret := *wb
+	ret.sheets = make([]*memSheet, len(wb.sheets))
+	for i, sh := range wb.sheets {
+		c := *sh
+		c.cells = make([][]Cell, len(sh.cells))
+		for j, row := range sh.cells {
+			c.cells[j] = append([]Cell(nil), row...)
+		}
+		ret.sheets[i] = &c
+	}
+	ret.namedRanges = make([]*sheets.NamedRange, len(wb.namedRanges))
+	for i, nr := range wb.namedRanges {
+		c := *nr
+		if nr.Range != nil {
+			rng := *nr.Range
+			c.Range = &rng
+		}
+		ret.namedRanges[i] = &c
+	}
+	return &ret
+}
+
+func (wb *memWorkbook) apply(req *sheets.Request) (*sheets.Response, error) {
+	switch {
+	case req.AddSheet != nil:
+		props := req.AddSheet.Properties
+		if props == nil || props.Title == "" {
+			return nil, fmt.Errorf("AddSheet requires a title")
+		}
+		if wb.sheet(props.Title) != nil {
+			return nil, fmt.Errorf("a sheet with the name %v already exists", props.Title)
+		}
+		rows, cols := defaultRowCount, defaultColumnCount
+		if props.GridProperties != nil {
+			if props.GridProperties.RowCount > 0 {
+				rows = int(props.GridProperties.RowCount)
+			}
+			if props.GridProperties.ColumnCount > 0 {
+				cols = int(props.GridProperties.ColumnCount)
+			}
+		}
+		sh := wb.addSheet(props.Title, rows, cols)
+		return &sheets.Response{AddSheet: &sheets.AddSheetResponse{Properties: sh.properties(len(wb.sheets) - 1)}}, nil
+	case req.DeleteSheet != nil:
+		i, sh := wb.sheetByID(req.DeleteSheet.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("no sheet with id %v", req.DeleteSheet.SheetId)
+		}
+		if len(wb.sheets) == 1 {
+			return nil, fmt.Errorf("can't delete the only sheet in a workbook")
+		}
+		wb.sheets = append(wb.sheets[:i], wb.sheets[i+1:]...)
+		// Named ranges go with their sheet.
+		kept := []*sheets.NamedRange{}
+		for _, nr := range wb.namedRanges {
+			if nr.Range.SheetId != sh.id {
+				kept = append(kept, nr)
+			}
+		}
+		wb.namedRanges = kept
+		return &sheets.Response{}, nil
+	case req.AppendDimension != nil:
+		_, sh := wb.sheetByID(req.AppendDimension.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("no sheet with id %v", req.AppendDimension.SheetId)
+		}
+		switch req.AppendDimension.Dimension {
+		case "ROWS":
+			sh.rows += int(req.AppendDimension.Length)
+		case "COLUMNS":
+			sh.cols += int(req.AppendDimension.Length)
+		default:
+			return nil, fmt.Errorf("unknown dimension: %v", req.AppendDimension.Dimension)
+		}
+		return &sheets.Response{}, nil
+	case req.DeleteDimension != nil:
+		rng := req.DeleteDimension.Range
+		if rng == nil {
+			return nil, fmt.Errorf("DeleteDimension requires a range")
+		}
+		_, sh := wb.sheetByID(rng.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("no sheet with id %v", rng.SheetId)
+		}
+		start, end := int(rng.StartIndex), int(rng.EndIndex)
+		switch rng.Dimension {
+		case "ROWS":
+			if start < 0 || end <= start || end > sh.rows {
+				return nil, fmt.Errorf("can't delete rows %d-%d of %d", start, end, sh.rows)
+			}
+			if start < len(sh.cells) {
+				sh.cells = append(sh.cells[:start], sh.cells[min(end, len(sh.cells)):]...)
+			}
+			sh.rows -= end - start
+		case "COLUMNS":
+			if start < 0 || end <= start || end > sh.cols {
+				return nil, fmt.Errorf("can't delete columns %d-%d of %d", start, end, sh.cols)
+			}
+			for i, row := range sh.cells {
+				if start < len(row) {
+					sh.cells[i] = append(row[:start], row[min(end, len(row)):]...)
+				}
+			}
+			sh.cols -= end - start
+		default:
+			return nil, fmt.Errorf("unknown dimension: %v", rng.Dimension)
+		}
+		return &sheets.Response{}, nil
+	case req.AddNamedRange != nil:
+		nr := req.AddNamedRange.NamedRange
+		if err := wb.checkNamedRange(nr); err != nil {
+			return nil, err
+		}
+		if _, existing := wb.namedRange(nr.Name); existing != nil {
+			return nil, fmt.Errorf("a named range with the name %v already exists", nr.Name)
+		}
+		wb.nextNamedRangeID++
+		rng := *nr.Range
+		added := &sheets.NamedRange{NamedRangeId: fmt.Sprintf("memory-named-range-%d", wb.nextNamedRangeID), Name: nr.Name, Range: &rng}
+		wb.namedRanges = append(wb.namedRanges, added)
+		return &sheets.Response{AddNamedRange: &sheets.AddNamedRangeResponse{NamedRange: added}}, nil
+	case req.UpdateNamedRange != nil:
+		nr := req.UpdateNamedRange.NamedRange
+		if err := wb.checkNamedRange(nr); err != nil {
+			return nil, err
+		}
+		_, existing := wb.namedRangeByID(nr.NamedRangeId)
+		if existing == nil {
+			return nil, fmt.Errorf("no named range with id %v", nr.NamedRangeId)
+		}
+		// Only the fields we use are supported.
+		for _, field := range strings.Split(req.UpdateNamedRange.Fields, ",") {
+			switch field {
+			case "name":
+				existing.Name = nr.Name
+			case "range":
+				rng := *nr.Range
+				existing.Range = &rng
+			default:
+				return nil, fmt.Errorf("unsupported field in UpdateNamedRange: %v", field)
+			}
+		}
+		return &sheets.Response{}, nil
+	case req.DeleteNamedRange != nil:
+		i, existing := wb.namedRangeByID(req.DeleteNamedRange.NamedRangeId)
+		if existing == nil {
+			return nil, fmt.Errorf("no named range with id %v", req.DeleteNamedRange.NamedRangeId)
+		}
+		wb.namedRanges = append(wb.namedRanges[:i], wb.namedRanges[i+1:]...)
+		return &sheets.Response{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported request in MemoryBackend: %+v", req)
+	}
+}
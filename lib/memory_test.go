@@ -0,0 +1,370 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func newTestMemoryBackend(t *testing.T) *MemoryBackend {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "data", "other")
+	err := m.SetValues("wb", "data", [][]interface{}{
+		{"name", "age"},
+		{"alice", 30.0},
+		{"bob", 25.0, "", "x"},
+	})
+	if err != nil {
+		t.Fatalf("SetValues() error = %v", err)
+	}
+	return m
+}
+
+func TestMemoryBackend_GetValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		workbook  string
+		rng       string
+		opts      *ReadOptions
+		want      [][]interface{}
+		wantRange string
+		wantErr   bool
+	}{
+		{
+			name:      "WholeWorksheet",
+			workbook:  "wb",
+			rng:       "data",
+			want:      [][]interface{}{{"name", "age"}, {"alice", "30"}, {"bob", "25", "", "x"}},
+			wantRange: "data!A1:Z1000",
+		},
+		{
+			name:      "Unformatted",
+			workbook:  "wb",
+			rng:       "data!A2:B3",
+			opts:      &ReadOptions{ValueRender: UnformattedRender},
+			want:      [][]interface{}{{"alice", 30.0}, {"bob", 25.0}},
+			wantRange: "data!A2:B3",
+		},
+		{
+			name:      "Rows",
+			workbook:  "wb",
+			rng:       "data!2:500",
+			want:      [][]interface{}{{"alice", "30"}, {"bob", "25", "", "x"}},
+			wantRange: "data!A2:Z500",
+		},
+		{
+			name:      "BareRangeIsFirstSheet",
+			workbook:  "wb",
+			rng:       "B1:B2",
+			want:      [][]interface{}{{"age"}, {"30"}},
+			wantRange: "data!B1:B2",
+		},
+		{
+			name:      "EmptyRange",
+			workbook:  "wb",
+			rng:       "data!A10:B20",
+			want:      nil,
+			wantRange: "data!A10:B20",
+		},
+		{
+			name:      "LeadingEmptyCells",
+			workbook:  "wb",
+			rng:       "data!C3:D3",
+			want:      [][]interface{}{{"", "x"}},
+			wantRange: "data!C3:D3",
+		},
+		{
+			name:      "EmptyWorksheet",
+			workbook:  "wb",
+			rng:       "other",
+			want:      nil,
+			wantRange: "other!A1:Z1000",
+		},
+		{
+			name:     "NoSuchWorkbook",
+			workbook: "nope",
+			rng:      "data",
+			wantErr:  true,
+		},
+		{
+			name:     "NoSuchWorksheet",
+			workbook: "wb",
+			rng:      "nope!A1:B2",
+			wantErr:  true,
+		},
+		{
+			name:     "BeyondGrid",
+			workbook: "wb",
+			rng:      "data!1001:2000",
+			wantErr:  true,
+		},
+	}
+	m := newTestMemoryBackend(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.GetValues(context.Background(), tt.workbook, tt.rng, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MemoryBackend.GetValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("MemoryBackend.GetValues() = %#v, want %#v", got.Values, tt.want)
+			}
+			if got.Range != tt.wantRange {
+				t.Errorf("MemoryBackend.GetValues() range = %v, want %v", got.Range, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestMemoryBackend_UpdateValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		rng     string
+		values  [][]interface{}
+		input   InputOption
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "UserEntered",
+			rng:    "other!B2:D3",
+			values: [][]interface{}{{"1.5", "TRUE", "'=1+1"}, {"=A1", "x"}},
+			input:  UserEnteredInput,
+			want:   [][]interface{}{{}, {"", 1.5, true, "=1+1"}, {"", "=A1", "x"}},
+		},
+		{
+			name:   "Raw",
+			rng:    "other!A1:B1",
+			values: [][]interface{}{{"1.5", "'x"}},
+			input:  RawInput,
+			want:   [][]interface{}{{"1.5", "'x"}},
+		},
+		{
+			name:    "TooManyRows",
+			rng:     "other!A1:B1",
+			values:  [][]interface{}{{"a"}, {"b"}},
+			wantErr: true,
+		},
+		{
+			name:    "TooManyCols",
+			rng:     "other!A1:B1",
+			values:  [][]interface{}{{"a", "b", "c"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemoryBackend(t)
+			err := m.UpdateValues(context.Background(), "wb", tt.rng, &sheets.ValueRange{Values: tt.values}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MemoryBackend.UpdateValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := m.GetValues(context.Background(), "wb", "other!A1:D3", &ReadOptions{ValueRender: UnformattedRender})
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("after UpdateValues() = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryBackend_AppendValues(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	got, err := m.AppendValues(context.Background(), "wb", "data", &sheets.ValueRange{Values: [][]interface{}{{"carol", "35"}, {"dave"}}}, UserEnteredInput)
+	if err != nil {
+		t.Fatalf("MemoryBackend.AppendValues() error = %v", err)
+	}
+	if got != "data!A4:B5" {
+		t.Errorf("MemoryBackend.AppendValues() = %v, want data!A4:B5", got)
+	}
+	values, _ := m.GetValues(context.Background(), "wb", "data!A4:B5", &ReadOptions{ValueRender: UnformattedRender})
+	want := [][]interface{}{{"carol", 35.0}, {"dave"}}
+	if !reflect.DeepEqual(values.Values, want) {
+		t.Errorf("after AppendValues() = %#v, want %#v", values.Values, want)
+	}
+	ws, _ := FindWorksheet(context.Background(), m, "wb", "data")
+	if ws.GridProperties.RowCount != 1002 {
+		t.Errorf("after AppendValues() row count = %v, want 1002", ws.GridProperties.RowCount)
+	}
+}
+
+func TestMemoryBackend_BatchValues(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	ctx := context.Background()
+	err := m.BatchUpdateValues(ctx, "wb", []*sheets.ValueRange{
+		{Range: "data!A2", Values: [][]interface{}{{"carol"}}},
+		{Range: "other!B1:B2", Values: [][]interface{}{{"x"}, {"y"}}},
+	}, RawInput)
+	if err != nil {
+		t.Fatalf("MemoryBackend.BatchUpdateValues() error = %v", err)
+	}
+	got, err := m.BatchGetValues(ctx, "wb", []string{"other", "data!A1:A2"}, nil)
+	if err != nil {
+		t.Fatalf("MemoryBackend.BatchGetValues() error = %v", err)
+	}
+	want := [][][]interface{}{{{"", "x"}, {"", "y"}}, {{"name"}, {"carol"}}}
+	for i := range want {
+		if !reflect.DeepEqual(got[i].Values, want[i]) {
+			t.Errorf("MemoryBackend.BatchGetValues()[%d] = %#v, want %#v", i, got[i].Values, want[i])
+		}
+	}
+
+	// Nothing is written if any of the ranges is bad.
+	err = m.BatchUpdateValues(ctx, "wb", []*sheets.ValueRange{
+		{Range: "data!A2", Values: [][]interface{}{{"dave"}}},
+		{Range: "other!A1", Values: [][]interface{}{{"too", "wide"}}},
+	}, RawInput)
+	if err == nil {
+		t.Errorf("MemoryBackend.BatchUpdateValues() beyond range error = nil, want error")
+	}
+	if _, err := m.BatchGetValues(ctx, "wb", []string{"data", "nope"}, nil); !errors.Is(err, ErrWorksheetNotFound) {
+		t.Errorf("MemoryBackend.BatchGetValues() of missing worksheet error = %v, want %v", err, ErrWorksheetNotFound)
+	}
+	values, _ := m.GetValues(ctx, "wb", "data!A2", nil)
+	if !reflect.DeepEqual(values.Values, [][]interface{}{{"carol"}}) {
+		t.Errorf("after failed BatchUpdateValues() = %#v, want carol untouched", values.Values)
+	}
+}
+
+func TestMemoryBackend_ClearValues(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	if err := m.ClearValues(context.Background(), "wb", "data!B1:B3"); err != nil {
+		t.Fatalf("MemoryBackend.ClearValues() error = %v", err)
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "data", nil)
+	want := [][]interface{}{{"name"}, {"alice"}, {"bob", "", "", "x"}}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after ClearValues() = %#v, want %#v", got.Values, want)
+	}
+}
+
+func TestMemoryBackend_Workbooks(t *testing.T) {
+	m := NewMemoryBackend()
+	wb, err := m.CreateWorkbook(context.Background(), "My Workbook")
+	if err != nil {
+		t.Fatalf("MemoryBackend.CreateWorkbook() error = %v", err)
+	}
+	if wb.Properties.Title != "My Workbook" || len(wb.Sheets) != 1 {
+		t.Errorf("MemoryBackend.CreateWorkbook() = %+v", wb)
+	}
+
+	resp, err := m.BatchUpdate(context.Background(), wb.SpreadsheetId, []*sheets.Request{
+		{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: "new"}}},
+		{AppendDimension: &sheets.AppendDimensionRequest{SheetId: 0, Dimension: "ROWS", Length: 10}},
+	})
+	if err != nil {
+		t.Fatalf("MemoryBackend.BatchUpdate() error = %v", err)
+	}
+	newID := resp.Replies[0].AddSheet.Properties.SheetId
+
+	got, err := m.GetWorkbook(context.Background(), wb.SpreadsheetId)
+	if err != nil {
+		t.Fatalf("MemoryBackend.GetWorkbook() error = %v", err)
+	}
+	if len(got.Sheets) != 2 || got.Sheets[1].Properties.Title != "new" {
+		t.Errorf("after AddSheet, sheets = %+v", got.Sheets)
+	}
+	if got.Sheets[0].Properties.GridProperties.RowCount != 1010 {
+		t.Errorf("after AppendDimension, row count = %v, want 1010", got.Sheets[0].Properties.GridProperties.RowCount)
+	}
+
+	if _, err := m.BatchUpdate(context.Background(), wb.SpreadsheetId, []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: "new"}}}}); err == nil {
+		t.Errorf("AddSheet with existing title error = nil, want error")
+	}
+
+	if _, err := m.BatchUpdate(context.Background(), wb.SpreadsheetId, []*sheets.Request{{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: newID}}}); err != nil {
+		t.Errorf("DeleteSheet error = %v", err)
+	}
+	got, _ = m.GetWorkbook(context.Background(), wb.SpreadsheetId)
+	if len(got.Sheets) != 1 {
+		t.Errorf("after DeleteSheet, sheets = %+v", got.Sheets)
+	}
+}
+
+func TestMemoryBackend_DeleteDimension(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	ws, err := FindWorksheet(context.Background(), m, "wb", "data")
+	if err != nil {
+		t.Fatalf("FindWorksheet() error = %v", err)
+	}
+	_, err = m.BatchUpdate(context.Background(), "wb", []*sheets.Request{
+		{DeleteDimension: &sheets.DeleteDimensionRequest{Range: &sheets.DimensionRange{SheetId: ws.SheetId, Dimension: "ROWS", StartIndex: 1, EndIndex: 2}}},
+		{DeleteDimension: &sheets.DeleteDimensionRequest{Range: &sheets.DimensionRange{SheetId: ws.SheetId, Dimension: "COLUMNS", StartIndex: 1, EndIndex: 3}}},
+	})
+	if err != nil {
+		t.Fatalf("MemoryBackend.BatchUpdate() error = %v", err)
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "data", nil)
+	if want := [][]interface{}{{"name"}, {"bob", "x"}}; !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after DeleteDimension, values = %#v, want %#v", got.Values, want)
+	}
+	ws, _ = FindWorksheet(context.Background(), m, "wb", "data")
+	if ws.GridProperties.RowCount != defaultRowCount-1 || ws.GridProperties.ColumnCount != defaultColumnCount-2 {
+		t.Errorf("after DeleteDimension, grid = %+v", ws.GridProperties)
+	}
+
+	if _, err := m.BatchUpdate(context.Background(), "wb", []*sheets.Request{
+		{DeleteDimension: &sheets.DeleteDimensionRequest{Range: &sheets.DimensionRange{SheetId: ws.SheetId, Dimension: "ROWS", StartIndex: 0, EndIndex: defaultRowCount}}},
+	}); err == nil {
+		t.Errorf("DeleteDimension past the end of the sheet error = nil, want error")
+	}
+}
+
+func TestMemoryBackend_BatchUpdateAllOrNothing(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	ws, err := FindWorksheet(context.Background(), m, "wb", "data")
+	if err != nil {
+		t.Fatalf("FindWorksheet() error = %v", err)
+	}
+	before, _ := m.GetWorkbook(context.Background(), "wb")
+	beforeValues, _ := m.GetValues(context.Background(), "wb", "data", nil)
+
+	_, err = m.BatchUpdate(context.Background(), "wb", []*sheets.Request{
+		{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: "new"}}},
+		{DeleteDimension: &sheets.DeleteDimensionRequest{Range: &sheets.DimensionRange{SheetId: ws.SheetId, Dimension: "ROWS", StartIndex: 1, EndIndex: 2}}},
+		{AddNamedRange: &sheets.AddNamedRangeRequest{NamedRange: &sheets.NamedRange{Name: "r", Range: &sheets.GridRange{SheetId: ws.SheetId}}}},
+		{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: 12345}},
+	})
+	if err == nil {
+		t.Fatalf("MemoryBackend.BatchUpdate() error = nil, want error")
+	}
+
+	// None of the requests before the failing one were applied.
+	after, _ := m.GetWorkbook(context.Background(), "wb")
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("after failed BatchUpdate, workbook = %+v, want %+v", after, before)
+	}
+	afterValues, _ := m.GetValues(context.Background(), "wb", "data", nil)
+	if !reflect.DeepEqual(afterValues.Values, beforeValues.Values) {
+		t.Errorf("after failed BatchUpdate, values = %#v, want %#v", afterValues.Values, beforeValues.Values)
+	}
+}
+
+func TestMemoryBackend_Cancelled(t *testing.T) {
+	m := newTestMemoryBackend(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.GetValues(ctx, "wb", "data", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("MemoryBackend.GetValues() error = %v, want context.Canceled", err)
+	}
+	spec := &DataSpec{Workbook: "wb", Worksheet: "data"}
+	if err := WriteDataToWorksheet(ctx, m, spec, [][]string{{"x"}}, false, false, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteDataToWorksheet() error = %v, want context.Canceled", err)
+	}
+	// Nothing was cleared or written.
+	got, _ := m.GetValues(context.Background(), "wb", "data!A1:A1", nil)
+	if !reflect.DeepEqual(got.Values, [][]interface{}{{"name"}}) {
+		t.Errorf("after cancelled write = %#v, want the original data", got.Values)
+	}
+}
@@ -0,0 +1,177 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// NamedRangePrefix marks a named range in arguments, e.g. "=MyNamedRange".
+const NamedRangePrefix = "="
+
+func namedRangeValid(name string) error {
+	// Sheets is fussier still, but these are the ones people trip over.
+	if name == "" {
+		return fmt.Errorf("named range name cannot be empty")
+	}
+	for i, c := range name {
+		if c > 0x7f || !(isLetter(byte(c)) || c == '_' || (i > 0 && (c >= '0' && c <= '9' || c == '.'))) {
+			return fmt.Errorf("invalid named range name %v: use letters, numbers, underscores and periods", name)
+		}
+	}
+	if _, err := RangeFromString(name); err == nil {
+		return fmt.Errorf("invalid named range name %v: it looks like a range", name)
+	}
+	return nil
+}
+
+// gridRange converts a range in a worksheet to the API's 0-based, half-open GridRange.
+func gridRange(sheetID int64, r DataRange) *sheets.GridRange {
+	ret := &sheets.GridRange{SheetId: sheetID}
+	if r.StartRow > 0 {
+		ret.StartRowIndex = int64(r.StartRow - 1)
+	}
+	ret.EndRowIndex = int64(r.EndRow)
+	if r.StartCol > 0 {
+		ret.StartColumnIndex = int64(r.StartCol - 1)
+	}
+	ret.EndColumnIndex = int64(r.EndCol)
+	return ret
+}
+
+// dataRangeFromGridRange is the reverse of gridRange. Missing (0) ends are unbounded.
+func dataRangeFromGridRange(g *sheets.GridRange) DataRange {
+	ret := DataRange{}
+	if g.EndRowIndex > 0 || g.StartRowIndex > 0 {
+		ret.StartRow = int(g.StartRowIndex) + 1
+		ret.EndRow = int(g.EndRowIndex)
+	}
+	if g.EndColumnIndex > 0 || g.StartColumnIndex > 0 {
+		ret.StartCol = int(g.StartColumnIndex) + 1
+		ret.EndCol = int(g.EndColumnIndex)
+	}
+	return ret
+}
+
+// FindNamedRange looks up a named range in a workbook. Names are case-insensitive, as in Sheets.
+func FindNamedRange(ctx context.Context, b Backend, workbook string, name string) (*sheets.NamedRange, error) {
+	wb, err := b.GetWorkbook(ctx, workbook)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve workbook %v: %w", workbook, err)
+	}
+	for _, nr := range wb.NamedRanges {
+		if strings.EqualFold(nr.Name, name) {
+			return nr, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v in workbook %v", ErrNamedRangeNotFound, name, workbook)
+}
+
+// GetNamedRanges returns the named ranges in a workbook, as DataSpecs of their worksheets and ranges.
+func GetNamedRanges(ctx context.Context, b Backend, workbook string) (map[string]*DataSpec, error) {
+	wb, err := b.GetWorkbook(ctx, workbook)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve workbook %v: %w", workbook, err)
+	}
+	ret := map[string]*DataSpec{}
+	for _, nr := range wb.NamedRanges {
+		spec, err := namedRangeDataSpec(wb, nr)
+		if err != nil {
+			return nil, err
+		}
+		ret[nr.Name] = spec
+	}
+	return ret, nil
+}
+
+func namedRangeDataSpec(wb *sheets.Spreadsheet, nr *sheets.NamedRange) (*DataSpec, error) {
+	ret := &DataSpec{Workbook: wb.SpreadsheetId, NamedRange: nr.Name}
+	if nr.Range == nil {
+		return nil, fmt.Errorf("named range %v has no range", nr.Name)
+	}
+	for _, sh := range wb.Sheets {
+		if sh.Properties.SheetId == nr.Range.SheetId {
+			ret.Worksheet = sh.Properties.Title
+			ret.Range = dataRangeFromGridRange(nr.Range)
+			return ret, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id %v, for named range %v", ErrWorksheetNotFound, nr.Range.SheetId, nr.Name)
+}
+
+// ResolveNamedRange fills in the worksheet and range of a DataSpec that refers to a named range.
+// Other DataSpecs are returned as they are. A named range covering a whole worksheet resolves to a range
+// of all of its cells, so that writing to it or removing it only touches those, rather than replacing or
+// deleting the worksheet.
+func ResolveNamedRange(ctx context.Context, b Backend, spec *DataSpec) (*DataSpec, error) {
+	if spec.NamedRange == "" || spec.Worksheet != "" {
+		return spec, nil
+	}
+	wb, err := b.GetWorkbook(ctx, spec.Workbook)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve workbook %v: %w", spec.Workbook, err)
+	}
+	for _, nr := range wb.NamedRanges {
+		if strings.EqualFold(nr.Name, spec.NamedRange) {
+			ret, err := namedRangeDataSpec(wb, nr)
+			if err != nil || ret.Range != (DataRange{}) {
+				return ret, err
+			}
+			for _, sh := range wb.Sheets {
+				if grid := sh.Properties.GridProperties; sh.Properties.SheetId == nr.Range.SheetId && grid != nil {
+					ret.Range = DataRange{StartRow: 1, StartCol: 1, EndRow: int(grid.RowCount), EndCol: int(grid.ColumnCount)}
+					return ret, nil
+				}
+			}
+			return nil, fmt.Errorf("no grid size for worksheet %v, for named range %v", ret.Worksheet, nr.Name)
+		}
+	}
+	return nil, fmt.Errorf("%w: %v in workbook %v", ErrNamedRangeNotFound, spec.NamedRange, spec.Workbook)
+}
+
+// SetNamedRange creates a named range, or moves an existing one, to the worksheet or range in spec.
+func SetNamedRange(ctx context.Context, b Backend, name string, spec *DataSpec) error {
+	if err := namedRangeValid(name); err != nil {
+		return err
+	}
+	if !spec.IsWorksheet() && !spec.IsRange() {
+		return fmt.Errorf("named range %v must be a worksheet or range, not %v", name, spec.String())
+	}
+	ws, err := FindWorksheet(ctx, b, spec.Workbook, spec.Worksheet)
+	if err != nil {
+		return err
+	}
+	rng := gridRange(ws.SheetId, spec.Range)
+
+	req := &sheets.Request{AddNamedRange: &sheets.AddNamedRangeRequest{
+		NamedRange: &sheets.NamedRange{Name: name, Range: rng},
+	}}
+	existing, err := FindNamedRange(ctx, b, spec.Workbook, name)
+	if err == nil {
+		req = &sheets.Request{UpdateNamedRange: &sheets.UpdateNamedRangeRequest{
+			NamedRange: &sheets.NamedRange{NamedRangeId: existing.NamedRangeId, Name: name, Range: rng},
+			Fields:     "name,range",
+		}}
+	}
+	if _, err := b.BatchUpdate(ctx, spec.Workbook, []*sheets.Request{req}); err != nil {
+		return fmt.Errorf("unable to set named range %v: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteNamedRange deletes a named range. The data in it is left alone.
+func DeleteNamedRange(ctx context.Context, b Backend, workbook string, name string) error {
+	nr, err := FindNamedRange(ctx, b, workbook, name)
+	if err != nil {
+		return err
+	}
+	_, err = b.BatchUpdate(ctx, workbook, []*sheets.Request{
+		{DeleteNamedRange: &sheets.DeleteNamedRangeRequest{NamedRangeId: nr.NamedRangeId}},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete named range %v: %w", name, err)
+	}
+	return nil
+}
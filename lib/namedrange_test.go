@@ -0,0 +1,162 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_gridRange(t *testing.T) {
+	// Each converts to a GridRange and back again.
+	tests := []string{"A1:C4", "B3", "A:C", "2:5", "A2:C", "B2:5"}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			r := MustRangeFromString(tt)
+			if got := dataRangeFromGridRange(gridRange(7, r)); got != r {
+				t.Errorf("dataRangeFromGridRange(gridRange(%v)) = %v, want %v", tt, got.String(), r.String())
+			}
+		})
+	}
+
+	g := gridRange(7, MustRangeFromString("B3:C4"))
+	if g.SheetId != 7 || g.StartRowIndex != 2 || g.EndRowIndex != 4 || g.StartColumnIndex != 1 || g.EndColumnIndex != 3 {
+		t.Errorf("gridRange(B3:C4) = %+v", g)
+	}
+	// The whole worksheet.
+	if got := dataRangeFromGridRange(gridRange(7, DataRange{})); got != (DataRange{}) {
+		t.Errorf("dataRangeFromGridRange(gridRange({})) = %v, want {}", got)
+	}
+}
+
+func Test_namedRangeValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "Totals", wantErr: false},
+		{name: "q1_sales.2024", wantErr: false},
+		{name: "", wantErr: true},
+		{name: "1st", wantErr: true},
+		{name: "my range", wantErr: true},
+		{name: "AB12", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := namedRangeValid(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("namedRangeValid(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNamedRanges(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "first", "My Sheet")
+	b.SetValues("wb", "My Sheet", [][]interface{}{{"a", "b"}, {"c", "d"}})
+
+	if err := SetNamedRange(ctx, b, "Stuff", &DataSpec{Workbook: "wb", Worksheet: "My Sheet", Range: MustRangeFromString("A2:B2")}); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+	if err := SetNamedRange(ctx, b, "Everything", &DataSpec{Workbook: "wb", Worksheet: "first"}); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+
+	got, err := GetNamedRanges(ctx, b, "wb")
+	if err != nil {
+		t.Fatalf("GetNamedRanges() error = %v", err)
+	}
+	want := map[string]*DataSpec{
+		"Stuff":      {Workbook: "wb", Worksheet: "My Sheet", Range: MustRangeFromString("A2:B2"), NamedRange: "Stuff"},
+		"Everything": {Workbook: "wb", Worksheet: "first", NamedRange: "Everything"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNamedRanges() = %v, want %v", got, want)
+	}
+
+	// Named ranges can be read by name, like the API.
+	resp, err := b.GetValues(ctx, "wb", "Stuff", nil)
+	if err != nil {
+		t.Fatalf("GetValues() error = %v", err)
+	}
+	if got, _ := FormatValues(resp, CsvFormat); got != "c,d\n" {
+		t.Errorf("GetValues(Stuff) = %q, want %q", got, "c,d\n")
+	}
+
+	// Setting one again moves it.
+	if err := SetNamedRange(ctx, b, "stuff", &DataSpec{Workbook: "wb", Worksheet: "My Sheet", Range: MustRangeFromString("A1:B1")}); err != nil {
+		t.Fatalf("SetNamedRange() error = %v", err)
+	}
+	spec, err := ResolveNamedRange(ctx, b, &DataSpec{Workbook: "wb", NamedRange: "Stuff"})
+	if err != nil {
+		t.Fatalf("ResolveNamedRange() error = %v", err)
+	}
+	if want := (&DataSpec{Workbook: "wb", Worksheet: "My Sheet", Range: MustRangeFromString("A1:B1"), NamedRange: "stuff"}); !reflect.DeepEqual(spec, want) {
+		t.Errorf("ResolveNamedRange() = %v, want %v", spec, want)
+	}
+
+	if err := DeleteNamedRange(ctx, b, "wb", "STUFF"); err != nil {
+		t.Fatalf("DeleteNamedRange() error = %v", err)
+	}
+	if _, err := ResolveNamedRange(ctx, b, &DataSpec{Workbook: "wb", NamedRange: "Stuff"}); !errors.Is(err, ErrNamedRangeNotFound) {
+		t.Errorf("ResolveNamedRange() after delete error = %v, want ErrNamedRangeNotFound", err)
+	}
+	if err := DeleteNamedRange(ctx, b, "wb", "Stuff"); !errors.Is(err, ErrNamedRangeNotFound) {
+		t.Errorf("DeleteNamedRange() again error = %v, want ErrNamedRangeNotFound", err)
+	}
+}
+
+func TestSetNamedRange_Errors(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "ws")
+
+	tests := []struct {
+		name      string
+		rangeName string
+		spec      *DataSpec
+		want      error
+	}{
+		{name: "Workbook", rangeName: "Stuff", spec: &DataSpec{Workbook: "wb"}},
+		{name: "BadName", rangeName: "A1", spec: &DataSpec{Workbook: "wb", Worksheet: "ws"}},
+		{name: "NoSuchWorksheet", rangeName: "Stuff", spec: &DataSpec{Workbook: "wb", Worksheet: "nope"}, want: ErrWorksheetNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetNamedRange(ctx, b, tt.rangeName, tt.spec)
+			if err == nil {
+				t.Fatalf("SetNamedRange() error = nil, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("SetNamedRange() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ResolveArgsToDataSpec_NamedRange(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBackend()
+	b.AddWorkbook("wb", "ws")
+	SetNamedRange(ctx, b, "Stuff", &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:B2")})
+
+	aliases := NewMemoryAliasStore()
+	aliases.SetAlias("stuff", &DataSpec{Workbook: "wb", NamedRange: "Stuff"})
+	c := &Client{Backend: b, Aliases: aliases}
+
+	want := &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:B2"), NamedRange: "Stuff"}
+	for _, args := range [][]string{{"wb", "=Stuff"}, {"@stuff"}} {
+		got, err := c.ResolveArgsToDataSpec(ctx, args)
+		if err != nil {
+			t.Fatalf("ResolveArgsToDataSpec(%v) error = %v", args, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveArgsToDataSpec(%v) = %v, want %v", args, got, want)
+		}
+	}
+
+	if _, err := c.ResolveArgsToDataSpec(ctx, []string{"wb", "=Nope"}); !errors.Is(err, ErrNamedRangeNotFound) {
+		t.Errorf("ResolveArgsToDataSpec() error = %v, want ErrNamedRangeNotFound", err)
+	}
+}
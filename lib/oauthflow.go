@@ -0,0 +1,245 @@
+package sheet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Implement an enum-a-like for the --oauth-flow flag
+type OAuthFlow string
+
+const (
+	// Listen on a local port for the redirect from the consent page, and pick the code up from that.
+	LoopbackFlow OAuthFlow = "loopback"
+	// Have the user paste the code (or the URL they were redirected to). For headless machines.
+	ManualFlow OAuthFlow = "manual"
+)
+
+func (f *OAuthFlow) String() string { return string(*f) }
+func (f *OAuthFlow) Type() string   { return "OAuthFlow" }
+func (f *OAuthFlow) Set(v string) error {
+	switch v {
+	case "loopback", "manual":
+		*f = OAuthFlow(v)
+		return nil
+	default:
+		return errors.New("invalid OAuthFlow. Allowed [loopback|manual]")
+	}
+}
+
+// How long we'll wait for the user to get through the consent page.
+const loopbackTimeout = 5 * time.Minute
+
+// Where prompts for the oauth flows go. Not stdout, since that's where our data goes.
+var authPromptOutput io.Writer = os.Stderr
+
+// Request a token from the web, then returns the retrieved token.
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config, flow OAuthFlow) (*oauth2.Token, error) {
+	if flow == ManualFlow {
+		return getTokenManually(ctx, config, os.Stdin)
+	}
+	// If there's someone at a terminal, they can paste the redirect URL instead, for when the browser
+	// is on another machine and can't reach our listener. Piped input is data, so leave that alone.
+	var pasted io.Reader
+	if isTerminal(os.Stdin) {
+		pasted = os.Stdin
+	}
+	return getTokenViaLoopback(ctx, config, func(authURL string) {
+		fmt.Fprintf(authPromptOutput, "Go to the following link in your browser to authorize sheet:\n%v\n", authURL)
+		if pasted != nil {
+			fmt.Fprintf(authPromptOutput, "If the browser is on another machine, paste the localhost URL it "+
+				"fails to load (or just its 'code' parameter) here.\n")
+		}
+		fmt.Fprintf(authPromptOutput, "Waiting for the authorization to complete...\n")
+	}, pasted)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate oauth state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// getTokenViaLoopback runs the authorization code flow with a redirect to a temporary listener on
+// 127.0.0.1, which picks up the code. prompt is given the URL the user needs to visit. If pasted isn't
+// nil, the code (or the redirect URL) can also be pasted there, whichever comes first.
+func getTokenViaLoopback(ctx context.Context, config *oauth2.Config, prompt func(authURL string), pasted io.Reader) (*oauth2.Token, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for oauth redirect: %w", err)
+	}
+
+	// Don't modify the caller's config.
+	cfg := *config
+	cfg.RedirectURL = fmt.Sprintf("http://%v/", l.Addr().String())
+
+	state, err := randomState()
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	results := make(chan loopbackResult, 1)
+	srv := &http.Server{Handler: newLoopbackHandler(state, results)}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	prompt(cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)))
+
+	if pasted != nil {
+		// This can't be interrupted, so it's left behind if the listener gets there first.
+		go func() {
+			var res loopbackResult
+			var p string
+			if _, err := fmt.Fscan(pasted, &p); err != nil {
+				res.err = fmt.Errorf("unable to read authorization code: %w", err)
+			} else {
+				res.code, res.err = parsePastedCode(p, state)
+			}
+			trySend(results, res)
+		}()
+	}
+
+	var res loopbackResult
+	select {
+	case res = <-results:
+	case <-time.After(loopbackTimeout):
+		return nil, fmt.Errorf("timed out waiting for oauth authorization (use --oauth-flow=manual " +
+			"if the browser can't reach this machine)")
+	case <-ctx.Done():
+		return nil, fmt.Errorf("gave up waiting for oauth authorization: %w", ctx.Err())
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	tok, err := cfg.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
+	}
+	return tok, nil
+}
+
+type loopbackResult struct {
+	code string
+	err  error
+}
+
+// trySend sends res to results, unless a result is already waiting there: the first one wins.
+func trySend(results chan<- loopbackResult, res loopbackResult) {
+	select {
+	case results <- res:
+	default:
+	}
+}
+
+// newLoopbackHandler returns a handler for the oauth redirect, which sends the first result
+// (a code, or an error) it sees to results. Only requests with the right state are the redirect: anything
+// else (a port scan, or a stale tab from an earlier login) is refused without ending the flow.
+func newLoopbackHandler(state string, results chan<- loopbackResult) http.Handler {
+	var once sync.Once
+	send := func(res loopbackResult) {
+		once.Do(func() { trySend(results, res) })
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			// e.g. /favicon.ico
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "Authorization failed: state mismatch", http.StatusBadRequest)
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			http.Error(w, "Authorization failed: "+e, http.StatusBadRequest)
+			send(loopbackResult{err: fmt.Errorf("authorization failed: %v", e)})
+			return
+		}
+		code := q.Get("code")
+		if code == "" {
+			http.Error(w, "Authorization failed: no code", http.StatusBadRequest)
+			send(loopbackResult{err: fmt.Errorf("no authorization code in redirect")})
+			return
+		}
+		fmt.Fprintln(w, "Authorization complete, you can close this window and return to sheet.")
+		send(loopbackResult{code: code})
+	})
+}
+
+// getTokenManually runs the authorization code flow with the redirect URL from the client secret,
+// and has the user paste the code (or the whole URL they were redirected to) from input.
+func getTokenManually(ctx context.Context, config *oauth2.Config, input io.Reader) (*oauth2.Token, error) {
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	fmt.Fprintf(authPromptOutput, "Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL)
+	fmt.Fprintf(authPromptOutput, "Note: If the auth flow redirects to localhost, paste the whole "+
+		"localhost URL (or just its 'code' parameter) here.\n")
+
+	var pasted string
+	if _, err := fmt.Fscan(input, &pasted); err != nil {
+		return nil, fmt.Errorf("unable to read authorization code: %w", err)
+	}
+
+	code, err := parsePastedCode(pasted, state)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
+	}
+	return tok, nil
+}
+
+// parsePastedCode gets the authorization code from what the user pasted: either the code itself,
+// or the redirect URL, in which case we can check the state too.
+func parsePastedCode(pasted string, state string) (string, error) {
+	if !strings.Contains(pasted, "://") {
+		return pasted, nil
+	}
+	u, err := url.Parse(pasted)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse redirect URL: %w", err)
+	}
+	q := u.Query()
+	if e := q.Get("error"); e != "" {
+		return "", fmt.Errorf("authorization failed: %v", e)
+	}
+	if q.Get("state") != state {
+		return "", fmt.Errorf("oauth state mismatch in redirect")
+	}
+	if q.Get("code") == "" {
+		return "", fmt.Errorf("no authorization code in redirect URL")
+	}
+	return q.Get("code"), nil
+}
@@ -0,0 +1,263 @@
+package sheet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func Test_newLoopbackHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		// Whether the request ends the flow, with a code or an error.
+		wantResult bool
+		wantCode   string
+		wantErr    bool
+	}{
+		{name: "Good", query: "state=s3cret&code=abc", wantStatus: http.StatusOK, wantResult: true, wantCode: "abc"},
+		{name: "StateMismatch", query: "state=other&code=abc", wantStatus: http.StatusBadRequest},
+		{name: "NoState", query: "code=abc", wantStatus: http.StatusBadRequest},
+		{name: "Probe", query: "", wantStatus: http.StatusBadRequest},
+		{name: "ErrorWithoutState", query: "error=access_denied", wantStatus: http.StatusBadRequest},
+		{name: "NoCode", query: "state=s3cret", wantStatus: http.StatusBadRequest, wantResult: true, wantErr: true},
+		{name: "Denied", query: "error=access_denied&state=s3cret", wantStatus: http.StatusBadRequest, wantResult: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan loopbackResult, 1)
+			h := newLoopbackHandler("s3cret", results)
+
+			// Stray requests are ignored.
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/favicon.ico", nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("favicon status = %v, want %v", rec.Code, http.StatusNotFound)
+			}
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			if !tt.wantResult {
+				select {
+				case res := <-results:
+					t.Fatalf("unexpected result: %v", res)
+				default:
+				}
+				// The real redirect still gets through.
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?state=s3cret&code=real", nil))
+				if res := <-results; res.err != nil || res.code != "real" {
+					t.Errorf("result after ignored request = %v, want code real", res)
+				}
+				return
+			}
+
+			res := <-results
+			if (res.err != nil) != tt.wantErr {
+				t.Errorf("result error = %v, wantErr %v", res.err, tt.wantErr)
+			}
+			if res.code != tt.wantCode {
+				t.Errorf("result code = %v, want %v", res.code, tt.wantCode)
+			}
+
+			// Only the first result is delivered.
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?state=s3cret&code=again", nil))
+			select {
+			case res := <-results:
+				t.Errorf("unexpected second result: %v", res)
+			default:
+			}
+		})
+	}
+}
+
+func Test_parsePastedCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		pasted  string
+		want    string
+		wantErr bool
+	}{
+		{name: "BareCode", pasted: "4/abc", want: "4/abc"},
+		{name: "URL", pasted: "http://localhost/?state=s3cret&code=4/abc&scope=x", want: "4/abc"},
+		{name: "URLStateMismatch", pasted: "http://localhost/?state=nope&code=4/abc", wantErr: true},
+		{name: "URLNoCode", pasted: "http://localhost/?state=s3cret", wantErr: true},
+		{name: "URLError", pasted: "http://localhost/?state=s3cret&error=access_denied", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePastedCode(tt.pasted, "s3cret")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePastedCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parsePastedCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTokenServer returns a token endpoint that checks it's given the expected code and a PKCE verifier.
+func fakeTokenServer(t *testing.T, wantCode string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != wantCode {
+			t.Errorf("token request code = %v, want %v", r.Form.Get("code"), wantCode)
+		}
+		if r.Form.Get("code_verifier") == "" {
+			t.Errorf("token request has no PKCE code_verifier")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok", "token_type": "Bearer", "refresh_token": "refresh"})
+	}))
+}
+
+func Test_getTokenViaLoopback(t *testing.T) {
+	authPromptOutput = io.Discard
+	tokenServer := fakeTokenServer(t, "thecode")
+	defer tokenServer.Close()
+
+	config := &oauth2.Config{
+		ClientID: "id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+	}
+
+	// Play the part of the user's browser, following the redirect back to our listener.
+	browser := func(authURL string) {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("bad auth URL: %v", err)
+			return
+		}
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+			t.Errorf("auth URL has no PKCE challenge: %v", authURL)
+		}
+		if q.Get("state") == "" || q.Get("state") == "state-token" {
+			t.Errorf("auth URL has no random state: %v", authURL)
+		}
+		redirect := q.Get("redirect_uri")
+		if !strings.HasPrefix(redirect, "http://127.0.0.1:") {
+			t.Errorf("redirect_uri = %v, want a loopback address", redirect)
+		}
+		go func() {
+			resp, err := http.Get(redirect + "?state=" + url.QueryEscape(q.Get("state")) + "&code=thecode")
+			if err != nil {
+				t.Errorf("redirect failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+
+	tok, err := getTokenViaLoopback(context.Background(), config, browser, nil)
+	if err != nil {
+		t.Fatalf("getTokenViaLoopback() error = %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("getTokenViaLoopback() token = %v, want tok", tok.AccessToken)
+	}
+	if config.RedirectURL != "" {
+		t.Errorf("getTokenViaLoopback() modified the caller's config")
+	}
+}
+
+func Test_getTokenViaLoopbackCancelled(t *testing.T) {
+	authPromptOutput = io.Discard
+	config := &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth"}}
+
+	// The user never shows up, and we're interrupted instead.
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := getTokenViaLoopback(ctx, config, func(string) { cancel() }, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("getTokenViaLoopback() error = %v, want context.Canceled", err)
+	}
+}
+
+func Test_getTokenViaLoopbackPasted(t *testing.T) {
+	authPromptOutput = io.Discard
+	tokenServer := fakeTokenServer(t, "thecode")
+	defer tokenServer.Close()
+
+	config := &oauth2.Config{
+		ClientID: "id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+	}
+
+	// The browser is somewhere that can't reach our listener, so the user pastes the URL it ended up on.
+	pr, pw := io.Pipe()
+	user := func(authURL string) {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("bad auth URL: %v", err)
+			return
+		}
+		q := u.Query()
+		go func() {
+			io.WriteString(pw, q.Get("redirect_uri")+"?state="+url.QueryEscape(q.Get("state"))+"&code=thecode\n")
+		}()
+	}
+
+	tok, err := getTokenViaLoopback(context.Background(), config, user, pr)
+	if err != nil {
+		t.Fatalf("getTokenViaLoopback() error = %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("getTokenViaLoopback() token = %v, want tok", tok.AccessToken)
+	}
+
+	// A paste with the wrong state ends the flow with an error.
+	pr, pw = io.Pipe()
+	bad := func(authURL string) {
+		go io.WriteString(pw, "http://127.0.0.1/?state=nope&code=thecode\n")
+	}
+	if _, err := getTokenViaLoopback(context.Background(), config, bad, pr); err == nil {
+		t.Errorf("getTokenViaLoopback() with a mismatched state error = nil, want an error")
+	}
+}
+
+func Test_getTokenManually(t *testing.T) {
+	authPromptOutput = io.Discard
+	tokenServer := fakeTokenServer(t, "pastedcode")
+	defer tokenServer.Close()
+
+	config := &oauth2.Config{
+		ClientID:    "id",
+		RedirectURL: "http://localhost",
+		Endpoint:    oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+	}
+
+	tok, err := getTokenManually(context.Background(), config, strings.NewReader("pastedcode\n"))
+	if err != nil {
+		t.Fatalf("getTokenManually() error = %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("getTokenManually() token = %v, want tok", tok.AccessToken)
+	}
+}
+
+func TestOAuthFlow_Set(t *testing.T) {
+	var f OAuthFlow
+	if err := f.Set("loopback"); err != nil {
+		t.Errorf("OAuthFlow.Set(loopback) error = %v", err)
+	}
+	if err := f.Set("manual"); err != nil {
+		t.Errorf("OAuthFlow.Set(manual) error = %v", err)
+	}
+	if err := f.Set("carrier-pigeon"); err == nil {
+		t.Errorf("OAuthFlow.Set(carrier-pigeon) error = nil, want error")
+	}
+}
@@ -0,0 +1,484 @@
+package sheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// CellPatch is a change to one cell. It's the same shape as a CellChange, so the changes in a Diff
+// can be applied as they are.
+type CellPatch struct {
+	// The cell's address, e.g. "B3".
+	Cell string `json:"cell"`
+	// The value to write.
+	New string `json:"new"`
+	// If set, the value the cell should have before it's changed. See PatchOptions.Verify.
+	Old *string `json:"old,omitempty"`
+}
+
+// Patch is a set of changes to a worksheet: either changes to cells, or rows to update or insert
+// by their value in a Key column.
+type Patch struct {
+	Cells []CellPatch
+	// The header of the column that identifies rows.
+	Key string
+	// Rows, keyed by the headers of the columns to set.
+	Rows []map[string]string
+}
+
+// PatchOptions controls how patches are applied. The zero value writes values as if typed in by a user,
+// without checking what's there first.
+type PatchOptions struct {
+	WriteOptions
+	// Check that cells with an Old value still have it before writing anything, and fail with
+	// ErrConflict if any don't.
+	Verify bool
+	// How values are read, to compare with Old values and rows' current values. nil gives the defaults for
+	// cells, and for rows, reads them unformatted (with dates as strings), so that a number shown as "1,000"
+	// matches 1000 rather than being rewritten every time.
+	ReadOptions *ReadOptions
+}
+
+// UpsertResult counts the rows changed by PatchRows or UpsertRows.
+type UpsertResult struct {
+	Inserted int
+	Updated  int
+	Deleted  int
+}
+
+// patchValue converts a value from a json patch to text. Numbers and booleans are allowed, and null clears a cell.
+func patchValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string, float64, bool:
+		return CellFromValue(v).String(), nil
+	default:
+		return "", fmt.Errorf("patch values must be strings, numbers, booleans or null, not %v", v)
+	}
+}
+
+// ParsePatch reads a patch in json, which is one of:
+//
+//	{"B3": "31", "C4": 2}                                 -- cells and their new values
+//	[{"cell": "B3", "new": "31", "old": "30"}, ...]       -- cell changes (as in a diff's "changed")
+//	{"changed": [{"cell": "B3", "new": "31", ...}, ...]}  -- a diff, from 'sheet diff --output-format=json'
+//	{"key": "name", "rows": [{"name": "bob", "age": 26}]} -- rows to update or insert, by key
+func ParsePatch(r io.Reader) (*Patch, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read patch: %w", err)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return parseCellList(data)
+	}
+
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("bad patch: %w", err)
+	}
+
+	if _, ok := obj["rows"]; ok {
+		keyed := struct {
+			Key  string                   `json:"key"`
+			Rows []map[string]interface{} `json:"rows"`
+		}{}
+		if err := json.Unmarshal(data, &keyed); err != nil {
+			return nil, fmt.Errorf("bad patch: %w", err)
+		}
+		if keyed.Key == "" {
+			return nil, fmt.Errorf("bad patch: rows need a key")
+		}
+		ret := &Patch{Key: keyed.Key}
+		for _, row := range keyed.Rows {
+			values := map[string]string{}
+			for col, v := range row {
+				if values[col], err = patchValue(v); err != nil {
+					return nil, fmt.Errorf("bad patch: %w", err)
+				}
+			}
+			ret.Rows = append(ret.Rows, values)
+		}
+		return ret, nil
+	}
+
+	if changed, ok := obj["changed"]; ok {
+		for _, other := range []string{"added", "removed"} {
+			if rows, ok := obj[other]; ok && string(bytes.TrimSpace(rows)) != "[]" && string(bytes.TrimSpace(rows)) != "null" {
+				return nil, fmt.Errorf("bad patch: can't apply %v rows from a diff -- use a patch with a key and rows", other)
+			}
+		}
+		return parseCellList(changed)
+	}
+
+	ret := &Patch{}
+	for cell, raw := range obj {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("bad patch: %w", err)
+		}
+		value, err := patchValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad patch: %v: %w", cell, err)
+		}
+		ret.Cells = append(ret.Cells, CellPatch{Cell: cell, New: value})
+	}
+	return ret, nil
+}
+
+func parseCellList(data []byte) (*Patch, error) {
+	list := []struct {
+		Cell string      `json:"cell"`
+		New  interface{} `json:"new"`
+		Old  interface{} `json:"old"`
+	}{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("bad patch: %w", err)
+	}
+	ret := &Patch{Cells: []CellPatch{}}
+	for _, c := range list {
+		p := CellPatch{Cell: c.Cell}
+		var err error
+		if p.New, err = patchValue(c.New); err != nil {
+			return nil, fmt.Errorf("bad patch: %v: %w", c.Cell, err)
+		}
+		if c.Old != nil {
+			old, err := patchValue(c.Old)
+			if err != nil {
+				return nil, fmt.Errorf("bad patch: %v: %w", c.Cell, err)
+			}
+			p.Old = &old
+		}
+		ret.Cells = append(ret.Cells, p)
+	}
+	return ret, nil
+}
+
+// patchCell is a CellPatch with its address parsed.
+type patchCell struct {
+	row, col int
+	CellPatch
+}
+
+// parseCell parses a single cell's address, e.g. "B3".
+func parseCell(cell string) (int, int, error) {
+	r, err := RangeFromString(cell)
+	if err != nil {
+		return 0, 0, err
+	}
+	if r.StartRow == 0 || r.StartCol == 0 || r.StartRow != r.EndRow || r.StartCol != r.EndCol {
+		return 0, 0, fmt.Errorf("%w: not a single cell: %v", ErrInvalidRange, cell)
+	}
+	return r.StartRow, r.StartCol, nil
+}
+
+// inRange returns true if a cell is inside r, whose missing ends are unbounded.
+func inRange(r DataRange, row int, col int) bool {
+	return (r.StartRow == 0 || row >= r.StartRow) && (r.EndRow == 0 || row <= r.EndRow) &&
+		(r.StartCol == 0 || col >= r.StartCol) && (r.EndCol == 0 || col <= r.EndCol)
+}
+
+// cellRuns groups cells (sorted by row and column) into runs of adjacent cells in the same row,
+// so they can be written with as few ranges as possible.
+func cellRuns(cells []patchCell) [][]patchCell {
+	ret := [][]patchCell{}
+	for i, c := range cells {
+		if i > 0 && c.row == cells[i-1].row && c.col == cells[i-1].col+1 {
+			ret[len(ret)-1] = append(ret[len(ret)-1], c)
+		} else {
+			ret = append(ret, []patchCell{c})
+		}
+	}
+	return ret
+}
+
+func runRange(worksheet string, run []patchCell) string {
+	first, last := run[0], run[len(run)-1]
+	spec := DataSpec{Worksheet: worksheet, Range: DataRange{StartRow: first.row, StartCol: first.col, EndRow: last.row, EndCol: last.col}}
+	return spec.GetInSheetDataSpec()
+}
+
+// PatchCells changes just the given cells of a worksheet, in one request. If spec is a range, the cells
+// must be inside it. Cell addresses are in the worksheet, not relative to the range. opts may be nil.
+func PatchCells(ctx context.Context, b Backend, spec *DataSpec, cells []CellPatch, opts *PatchOptions) error {
+	return clientWithBackend(b).PatchCells(ctx, spec, cells, opts)
+}
+
+func (c *Client) PatchCells(ctx context.Context, spec *DataSpec, cells []CellPatch, opts *PatchOptions) error {
+	if opts == nil {
+		opts = &PatchOptions{}
+	}
+	if !spec.IsWorksheet() && !spec.IsRange() {
+		return fmt.Errorf("can only patch a worksheet or range, not %v", spec.String())
+	}
+
+	parsed := []patchCell{}
+	seen := map[string]bool{}
+	for _, cell := range cells {
+		row, col, err := parseCell(cell.Cell)
+		if err != nil {
+			return err
+		}
+		if !inRange(spec.Range, row, col) {
+			return fmt.Errorf("cell %v is outside %v", cell.Cell, spec.GetInSheetDataSpec())
+		}
+		addr := cellAddress(row, col)
+		if seen[addr] {
+			return fmt.Errorf("cell %v is in the patch more than once", addr)
+		}
+		seen[addr] = true
+		parsed = append(parsed, patchCell{row: row, col: col, CellPatch: cell})
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		if parsed[i].row != parsed[j].row {
+			return parsed[i].row < parsed[j].row
+		}
+		return parsed[i].col < parsed[j].col
+	})
+	runs := cellRuns(parsed)
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.Verify {
+		if err := verifyCells(ctx, b, spec, runs, opts.ReadOptions); err != nil {
+			return err
+		}
+	}
+
+	data := []*sheets.ValueRange{}
+	for _, run := range runs {
+		values := []string{}
+		for _, cell := range run {
+			values = append(values, cell.New)
+		}
+		vr := valueRangeForWrite([][]string{values}, &opts.WriteOptions)
+		vr.Range = runRange(spec.Worksheet, run)
+		data = append(data, vr)
+	}
+	if err := b.BatchUpdateValues(ctx, spec.Workbook, data, opts.InputOption); err != nil {
+		return fmt.Errorf("unable to patch %v: %w", spec.String(), err)
+	}
+	return nil
+}
+
+// verifyCells checks that cells with an Old value have it, reading only the runs that have any.
+func verifyCells(ctx context.Context, b Backend, spec *DataSpec, runs [][]patchCell, ropts *ReadOptions) error {
+	ranges := []string{}
+	checked := [][]patchCell{}
+	for _, run := range runs {
+		for _, cell := range run {
+			if cell.Old != nil {
+				ranges = append(ranges, runRange(spec.Worksheet, run))
+				checked = append(checked, run)
+				break
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	resp, err := b.BatchGetValues(ctx, spec.Workbook, ranges, ropts)
+	if err != nil {
+		return err
+	}
+	conflicts := []string{}
+	for i, run := range checked {
+		current := [][]string{}
+		if i < len(resp) {
+			current = StringsFromValueRange(resp[i])
+		}
+		for j, cell := range run {
+			if cell.Old != nil && cellAt(current, 0, j) != *cell.Old {
+				conflicts = append(conflicts, fmt.Sprintf("%v is %q, not %q", cellAddress(cell.row, cell.col), cellAt(current, 0, j), *cell.Old))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %v", ErrConflict, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// PatchRows updates rows of a worksheet whose first row is a header, matching them by their value in the
+// key column. Only the columns given for each row are changed, and only cells whose values differ are written.
+// Rows with keys that aren't in the worksheet are inserted after the last row, growing the worksheet if need be.
+// Worksheet rows with a blank key are ignored. Everything is written in one request. opts may be nil.
+func PatchRows(ctx context.Context, b Backend, spec *DataSpec, key string, rows []map[string]string, opts *PatchOptions) (*UpsertResult, error) {
+	return clientWithBackend(b).PatchRows(ctx, spec, key, rows, opts)
+}
+
+func (c *Client) PatchRows(ctx context.Context, spec *DataSpec, key string, rows []map[string]string, opts *PatchOptions) (*UpsertResult, error) {
+	return c.upsertRows(ctx, spec, key, rows, false, opts)
+}
+
+// upsertRows does the work of PatchRows, and with prune, deletes rows whose keys aren't in rows first.
+func (c *Client) upsertRows(ctx context.Context, spec *DataSpec, key string, rows []map[string]string, prune bool, opts *PatchOptions) (*UpsertResult, error) {
+	if opts == nil {
+		opts = &PatchOptions{}
+	}
+	if !spec.IsWorksheet() {
+		return nil, fmt.Errorf("can only patch rows of a worksheet, not %v", spec.String())
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ropts := opts.ReadOptions
+	if ropts == nil {
+		ropts = &ReadOptions{ValueRender: UnformattedRender, DateRender: StringDateRender}
+	}
+	resp, err := b.GetValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), ropts)
+	if err != nil {
+		return nil, err
+	}
+	current := StringsFromValueRange(resp)
+	if len(current) == 0 {
+		return nil, fmt.Errorf("no header row in %v", spec.String())
+	}
+	header := current[0]
+	keyCol, err := keyColumn(header, key)
+	if err != nil {
+		return nil, err
+	}
+	// Rows without a key can't be patched, and aren't pruned.
+	existing, err := keyRows(current, keyCol)
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[h] = i
+	}
+
+	ret := &UpsertResult{}
+	changed := []patchCell{}
+	// 0-based indexes of rows to delete.
+	deleted := []int{}
+	inserted := [][]string{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		k, ok := row[key]
+		if !ok || k == "" {
+			return nil, fmt.Errorf("row has no %v: %v", key, row)
+		}
+		if seen[k] {
+			return nil, fmt.Errorf("key %q is in the patch more than once", k)
+		}
+		seen[k] = true
+		for col := range row {
+			if _, ok := cols[col]; !ok {
+				return nil, fmt.Errorf("no %v column in header: %v", col, header)
+			}
+		}
+
+		i, ok := existing[k]
+		if !ok {
+			values := make([]string, len(header))
+			for col, v := range row {
+				values[cols[col]] = v
+			}
+			inserted = append(inserted, values)
+			ret.Inserted++
+			continue
+		}
+		updated := false
+		for col, v := range row {
+			j := cols[col]
+			if cellAt(current, i, j) != v {
+				changed = append(changed, patchCell{row: i + 1, col: j + 1, CellPatch: CellPatch{New: v}})
+				updated = true
+			}
+		}
+		if updated {
+			ret.Updated++
+		}
+	}
+	if prune {
+		for k, i := range existing {
+			if !seen[k] {
+				deleted = append(deleted, i)
+			}
+		}
+		ret.Deleted = len(deleted)
+	}
+	if len(changed) == 0 && len(inserted) == 0 && len(deleted) == 0 {
+		return ret, nil
+	}
+
+	// Deletions go first, in one request, so the worksheet never has the new values without the
+	// deletions, and the rest are written to where the rows end up afterwards.
+	sort.Ints(deleted)
+	shifted := func(row int) int {
+		return row - sort.SearchInts(deleted, row-1)
+	}
+	for i := range changed {
+		changed[i].row = shifted(changed[i].row)
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		if changed[i].row != changed[j].row {
+			return changed[i].row < changed[j].row
+		}
+		return changed[i].col < changed[j].col
+	})
+	data := []*sheets.ValueRange{}
+	for _, run := range cellRuns(changed) {
+		values := []string{}
+		for _, cell := range run {
+			values = append(values, cell.New)
+		}
+		vr := valueRangeForWrite([][]string{values}, &opts.WriteOptions)
+		vr.Range = runRange(spec.Worksheet, run)
+		data = append(data, vr)
+	}
+
+	if len(deleted) > 0 {
+		if err := deleteRows(ctx, b, spec, append([]int{}, deleted...)); err != nil {
+			return nil, err
+		}
+	}
+	// If writing fails after the deletions, say what's been done, since it can't be undone.
+	partial := func(err error) error {
+		if len(deleted) > 0 {
+			return fmt.Errorf("deleted %d rows from %v, but then %w", len(deleted), spec.String(), err)
+		}
+		return err
+	}
+	if len(inserted) > 0 {
+		first := len(current) - len(deleted) + 1
+		last := first + len(inserted) - 1
+		ws, err := FindWorksheet(ctx, b, spec.Workbook, spec.Worksheet)
+		if err != nil {
+			return nil, partial(err)
+		}
+		if err := growGrid(ctx, b, spec.Workbook, ws, last, len(header)); err != nil {
+			return nil, partial(err)
+		}
+		vr := valueRangeForWrite(inserted, &opts.WriteOptions)
+		rng := DataSpec{Worksheet: spec.Worksheet, Range: DataRange{StartRow: first, StartCol: 1, EndRow: last, EndCol: len(header)}}
+		vr.Range = rng.GetInSheetDataSpec()
+		data = append(data, vr)
+	}
+
+	if len(data) > 0 {
+		if err := b.BatchUpdateValues(ctx, spec.Workbook, data, opts.InputOption); err != nil {
+			return nil, partial(fmt.Errorf("unable to patch %v: %w", spec.String(), err))
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,294 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// recordingBackend records the ranges written by each BatchUpdateValues call.
+type recordingBackend struct {
+	*MemoryBackend
+	batches [][]string
+}
+
+func (r *recordingBackend) BatchUpdateValues(ctx context.Context, workbook string, data []*sheets.ValueRange, input InputOption) error {
+	ranges := []string{}
+	for _, vr := range data {
+		ranges = append(ranges, vr.Range)
+	}
+	r.batches = append(r.batches, ranges)
+	return r.MemoryBackend.BatchUpdateValues(ctx, workbook, data, input)
+}
+
+func newPeopleBackend() *recordingBackend {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}})
+	return &recordingBackend{MemoryBackend: m}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    *Patch
+		wantErr bool
+	}{
+		{
+			name: "Object",
+			in:   `{"B3": "31", "C4": 2, "D1": true, "E2": null}`,
+			want: &Patch{Cells: []CellPatch{{Cell: "B3", New: "31"}, {Cell: "C4", New: "2"}, {Cell: "D1", New: "TRUE"}, {Cell: "E2", New: ""}}},
+		},
+		{
+			name: "List",
+			in:   `[{"cell": "B3", "new": "31", "old": "30"}, {"cell": "A1", "new": 5}]`,
+			want: &Patch{Cells: []CellPatch{{Cell: "B3", New: "31", Old: strPtr("30")}, {Cell: "A1", New: "5"}}},
+		},
+		{
+			name: "Diff",
+			in:   `{"changed":[{"cell":"B3","old":"25","new":"26"}]}`,
+			want: &Patch{Cells: []CellPatch{{Cell: "B3", New: "26", Old: strPtr("25")}}},
+		},
+		{
+			name: "Rows",
+			in:   `{"key": "name", "rows": [{"name": "bob", "age": 26}]}`,
+			want: &Patch{Key: "name", Rows: []map[string]string{{"name": "bob", "age": "26"}}},
+		},
+		{
+			name:    "DiffWithAddedRows",
+			in:      `{"changed":[],"added":[{"key":"dave","row":3,"values":["dave","40"]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "RowsWithoutKey",
+			in:      `{"rows": [{"name": "bob"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "NestedValue",
+			in:      `{"B3": [1, 2]}`,
+			wantErr: true,
+		},
+		{
+			name:    "NotJson",
+			in:      `B3,31`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePatch(strings.NewReader(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != nil {
+				// Cells from an object come out in no particular order.
+				for _, c := range tt.want.Cells {
+					found := false
+					for _, g := range got.Cells {
+						found = found || reflect.DeepEqual(c, g)
+					}
+					if !found {
+						t.Errorf("ParsePatch() = %+v, missing %+v", got.Cells, c)
+					}
+				}
+				if len(got.Cells) != len(tt.want.Cells) || got.Key != tt.want.Key || !reflect.DeepEqual(got.Rows, tt.want.Rows) {
+					t.Errorf("ParsePatch() = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchCells(t *testing.T) {
+	tests := []struct {
+		name  string
+		rng   string
+		cells []CellPatch
+		opts  *PatchOptions
+		want  [][]string
+		// The ranges written, or nil if nothing should be.
+		wantRanges []string
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name:       "RunsOfCells",
+			cells:      []CellPatch{{Cell: "C2", New: "hull"}, {Cell: "B2", New: "31"}, {Cell: "A3", New: "rob"}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "31", "hull"}, {"rob", "25", "leeds"}},
+			wantRanges: []string{"ws!B2:C2", "ws!A3"},
+		},
+		{
+			name:       "InRange",
+			rng:        "A2:C3",
+			cells:      []CellPatch{{Cell: "B3", New: "26"}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "26", "leeds"}},
+			wantRanges: []string{"ws!B3"},
+		},
+		{
+			name:       "Verified",
+			cells:      []CellPatch{{Cell: "B2", New: "31", Old: strPtr("30")}, {Cell: "B3", New: "26"}},
+			opts:       &PatchOptions{Verify: true},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "31", "york"}, {"bob", "26", "leeds"}},
+			wantRanges: []string{"ws!B2", "ws!B3"},
+		},
+		{
+			name:      "Conflict",
+			cells:     []CellPatch{{Cell: "B2", New: "31", Old: strPtr("29")}},
+			opts:      &PatchOptions{Verify: true},
+			want:      [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr:   true,
+			wantErrIs: ErrConflict,
+		},
+		{
+			name:       "UnverifiedOldIgnored",
+			cells:      []CellPatch{{Cell: "B2", New: "31", Old: strPtr("29")}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "31", "york"}, {"bob", "25", "leeds"}},
+			wantRanges: []string{"ws!B2"},
+		},
+		{
+			name:    "OutsideRange",
+			rng:     "A2:C3",
+			cells:   []CellPatch{{Cell: "A1", New: "x"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:      "NotACell",
+			cells:     []CellPatch{{Cell: "A1:B2", New: "x"}},
+			want:      [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr:   true,
+			wantErrIs: ErrInvalidRange,
+		},
+		{
+			name:    "Duplicate",
+			cells:   []CellPatch{{Cell: "A1", New: "x"}, {Cell: "A1", New: "y"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPeopleBackend()
+			spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+			if tt.rng != "" {
+				spec.Range = MustRangeFromString(tt.rng)
+			}
+			err := PatchCells(context.Background(), b, spec, tt.cells, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PatchCells() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("PatchCells() error = %v, want %v", err, tt.wantErrIs)
+			}
+			var want [][]string
+			if tt.wantRanges != nil {
+				want = [][]string{tt.wantRanges}
+			}
+			if !reflect.DeepEqual(b.batches, want) {
+				t.Errorf("PatchCells() wrote %v, want %v", b.batches, want)
+			}
+			got, _ := b.GetValues(context.Background(), "wb", "ws", nil)
+			if !reflect.DeepEqual(StringsFromValueRange(got), tt.want) {
+				t.Errorf("after PatchCells() = %v, want %v", StringsFromValueRange(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       []map[string]string
+		want       [][]string
+		wantResult *UpsertResult
+		wantRanges []string
+		wantErr    bool
+	}{
+		{
+			name: "UpdateAndInsert",
+			rows: []map[string]string{
+				{"name": "bob", "age": "26", "city": "leeds"},
+				{"name": "alice", "age": "30"},
+				{"city": "york", "name": "dave"},
+			},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "26", "leeds"}, {"dave", "", "york"}},
+			wantResult: &UpsertResult{Updated: 1, Inserted: 1},
+			wantRanges: []string{"ws!B3", "ws!A4:C4"},
+		},
+		{
+			name:       "NoChanges",
+			rows:       []map[string]string{{"name": "alice", "city": "york"}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantResult: &UpsertResult{},
+		},
+		{
+			name:    "UnknownColumn",
+			rows:    []map[string]string{{"name": "bob", "height": "180"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:    "NoKey",
+			rows:    []map[string]string{{"age": "1"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:    "DuplicateKey",
+			rows:    []map[string]string{{"name": "bob", "age": "1"}, {"name": "bob", "age": "2"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPeopleBackend()
+			spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+			got, err := PatchRows(context.Background(), b, spec, "name", tt.rows, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PatchRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantResult) {
+				t.Errorf("PatchRows() = %+v, want %+v", got, tt.wantResult)
+			}
+			var want [][]string
+			if tt.wantRanges != nil {
+				want = [][]string{tt.wantRanges}
+			}
+			if !reflect.DeepEqual(b.batches, want) {
+				t.Errorf("PatchRows() wrote %v, want %v", b.batches, want)
+			}
+			values, _ := b.GetValues(context.Background(), "wb", "ws", nil)
+			if !reflect.DeepEqual(StringsFromValueRange(values), tt.want) {
+				t.Errorf("after PatchRows() = %v, want %v", StringsFromValueRange(values), tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchRowsGrowsGrid(t *testing.T) {
+	m := newSmallWorksheet(t)
+	m.SetValues("wb", "ws", [][]interface{}{{"name", "age"}, {"alice", "30"}})
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	rows := []map[string]string{{"name": "bob", "age": "25"}, {"name": "carol", "age": "35"}}
+	if _, err := PatchRows(context.Background(), m, spec, "name", rows, nil); err != nil {
+		t.Fatalf("PatchRows() error = %v", err)
+	}
+	ws, err := FindWorksheet(context.Background(), m, "wb", "ws")
+	if err != nil {
+		t.Fatalf("FindWorksheet() error = %v", err)
+	}
+	if ws.GridProperties.RowCount != 4 {
+		t.Errorf("after PatchRows() worksheet has %d rows, want 4", ws.GridProperties.RowCount)
+	}
+}
@@ -0,0 +1,657 @@
+package sheet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This is a small SQL dialect for querying worksheets, evaluated locally by Query (see queryeval.go):
+//
+//	SELECT [* | t.* | expr [AS name]], ...
+//	FROM table [[AS] t] [[INNER | LEFT [OUTER]] JOIN table [[AS] t] ON expr]...
+//	[WHERE expr] [GROUP BY expr|ordinal, ...] [HAVING expr]
+//	[ORDER BY expr|ordinal|name [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+//
+// Tables are aliases (@sales, @sales!A1:D100) or quoted data specs ('SpReAdShEeTiD Sheet1', or with quotes
+// doubled, 'SpReAdShEeTiD ''My Sheet''!A1:D9'), and their first row is a header. Columns are referred to by
+// header, quoted with "" or `` if need be, and qualified with the table's name (the alias or worksheet, unless
+// it's given one) if more than one table has that header.
+// Expressions have the usual comparisons, AND, OR, NOT, LIKE, + - * /, 'strings', numbers, and the aggregates
+// COUNT(*), COUNT, SUM, AVG, MIN and MAX. Empty cells are like NULL: two of them are never equal.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	// Keywords and bare names.
+	tokIdent
+	// "Quoted" or `quoted` names.
+	tokQuotedIdent
+	tokNumber
+	tokString
+	// @alias, with whatever follows it up to a space.
+	tokAlias
+	// Operators and punctuation.
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// Where the token starts and ends in the query, for error messages and column names.
+	pos, end int
+}
+
+// lexQuery splits a query into tokens.
+func lexQuery(s string, aliasPrefix string) ([]token, error) {
+	ret := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(s[i:], aliasPrefix):
+			for i < len(s) && !strings.ContainsRune(" \t\n\r,()", rune(s[i])) {
+				i++
+			}
+			ret = append(ret, token{kind: tokAlias, text: s[start:i], pos: start, end: i})
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// Quotes are escaped by doubling them, as in SQL.
+			text := strings.Builder{}
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated %c at %d", c, start)
+				}
+				if s[i] == c {
+					if i+1 < len(s) && s[i+1] == c {
+						text.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteByte(s[i])
+				i++
+			}
+			kind := tokQuotedIdent
+			if c == '\'' {
+				kind = tokString
+			}
+			ret = append(ret, token{kind: kind, text: text.String(), pos: start, end: i})
+			continue
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			ret = append(ret, token{kind: tokNumber, text: s[start:i], pos: start, end: i})
+			continue
+		case c == '_' || isLetter(c):
+			for i < len(s) && (s[i] == '_' || isLetter(s[i]) || s[i] >= '0' && s[i] <= '9') {
+				i++
+			}
+			ret = append(ret, token{kind: tokIdent, text: s[start:i], pos: start, end: i})
+			continue
+		}
+		op := ""
+		for _, o := range []string{"<=", ">=", "!=", "<>", "=", "<", ">", "+", "-", "*", "/", "(", ")", ",", ".", ";"} {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+		i += len(op)
+		ret = append(ret, token{kind: tokOp, text: op, pos: start, end: i})
+	}
+	return append(ret, token{kind: tokEOF, pos: len(s), end: len(s)}), nil
+}
+
+// Words that can't be used as bare column or table names.
+var queryKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "BY": true, "HAVING": true, "ORDER": true,
+	"ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true, "JOIN": true, "INNER": true, "LEFT": true,
+	"OUTER": true, "ON": true, "AS": true, "AND": true, "OR": true, "NOT": true, "LIKE": true,
+}
+
+// queryExpr is an expression in a query.
+type queryExpr interface{}
+
+type (
+	literalExpr struct {
+		value Cell
+	}
+	columnExpr struct {
+		table string
+		name  string
+		// The column's index in a joined row, once bound.
+		slot int
+	}
+	unaryExpr struct {
+		op string
+		x  queryExpr
+	}
+	binaryExpr struct {
+		op   string
+		l, r queryExpr
+		// For LIKE, the last pattern compiled, since it's usually the same for every row.
+		pattern string
+		like    *regexp.Regexp
+	}
+	// An aggregate function. COUNT(*) has no arg.
+	callExpr struct {
+		name string
+		arg  queryExpr
+	}
+)
+
+type selectItem struct {
+	expr queryExpr
+	// The output column's header.
+	name string
+	// For * and t.*, which are expanded when the tables are known.
+	star      bool
+	starTable string
+}
+
+type tableRef struct {
+	// Arguments for ResolveArgsToDataSpec.
+	args []string
+	name string
+}
+
+type joinClause struct {
+	table tableRef
+	left  bool
+	on    queryExpr
+}
+
+type orderItem struct {
+	expr queryExpr
+	desc bool
+}
+
+// parsedQuery is a query, as parsed by parseQuery.
+type parsedQuery struct {
+	selects []selectItem
+	from    tableRef
+	joins   []joinClause
+	where   queryExpr
+	groupBy []queryExpr
+	having  queryExpr
+	orderBy []orderItem
+	// -1 if there's no limit.
+	limit  int
+	offset int
+}
+
+type queryParser struct {
+	src         string
+	toks        []token
+	i           int
+	aliasPrefix string
+}
+
+func (p *queryParser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *queryParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// isKeyword returns true if the next token is one of the given keywords.
+func (p *queryParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKeyword consumes the next token if it's the given keyword.
+func (p *queryParser) acceptKeyword(word string) bool {
+	if p.isKeyword(word) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) acceptOp(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	at := "end of query"
+	if t.kind != tokEOF {
+		at = fmt.Sprintf("%q at %d", p.src[t.pos:t.end], t.pos)
+	}
+	return fmt.Errorf("bad query: %v, at %v", fmt.Sprintf(format, args...), at)
+}
+
+func (p *queryParser) expectKeyword(word string) error {
+	if !p.acceptKeyword(word) {
+		return p.errorf("expected %v", word)
+	}
+	return nil
+}
+
+func (p *queryParser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected %q", op)
+	}
+	return nil
+}
+
+// name parses a column or table name, bare or quoted.
+func (p *queryParser) name() (string, error) {
+	t := p.peek()
+	if t.kind == tokQuotedIdent || t.kind == tokIdent && !queryKeywords[strings.ToUpper(t.text)] {
+		p.next()
+		return t.text, nil
+	}
+	return "", p.errorf("expected a name")
+}
+
+// parseQuery parses a query, in the dialect described at the top of this file.
+func parseQuery(s string, aliasPrefix string) (*parsedQuery, error) {
+	toks, err := lexQuery(s, aliasPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("bad query: %w", err)
+	}
+	p := &queryParser{src: s, toks: toks, aliasPrefix: aliasPrefix}
+	q := &parsedQuery{limit: -1}
+
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	for {
+		item, err := p.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		q.selects = append(q.selects, item)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if q.from, err = p.table(); err != nil {
+		return nil, err
+	}
+	for {
+		join := joinClause{}
+		if p.acceptKeyword("LEFT") {
+			p.acceptKeyword("OUTER")
+			join.left = true
+		} else {
+			p.acceptKeyword("INNER")
+		}
+		if !p.acceptKeyword("JOIN") {
+			if join.left {
+				return nil, p.errorf("expected JOIN")
+			}
+			break
+		}
+		if join.table, err = p.table(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		if join.on, err = p.expr(); err != nil {
+			return nil, err
+		}
+		q.joins = append(q.joins, join)
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, e)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("HAVING") {
+		if q.having, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: e}
+			if p.acceptKeyword("DESC") {
+				item.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, item)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.limit, err = p.count(); err != nil {
+			return nil, err
+		}
+		if p.acceptKeyword("OFFSET") {
+			if q.offset, err = p.count(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.acceptOp(";")
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected")
+	}
+	return q, nil
+}
+
+// count parses a whole number, for LIMIT and OFFSET.
+func (p *queryParser) count() (int, error) {
+	t := p.peek()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != tokNumber || err != nil || n < 0 {
+		return 0, p.errorf("expected a number")
+	}
+	p.next()
+	return n, nil
+}
+
+func (p *queryParser) selectItem() (selectItem, error) {
+	if p.acceptOp("*") {
+		return selectItem{star: true}, nil
+	}
+	// t.*
+	if t := p.peek(); t.kind == tokIdent || t.kind == tokQuotedIdent {
+		if dot, star := p.toks[p.i+1], p.toks[min(p.i+2, len(p.toks)-1)]; dot.kind == tokOp && dot.text == "." && star.kind == tokOp && star.text == "*" {
+			p.i += 3
+			return selectItem{star: true, starTable: t.text}, nil
+		}
+	}
+
+	start := p.peek().pos
+	e, err := p.expr()
+	if err != nil {
+		return selectItem{}, err
+	}
+	item := selectItem{expr: e, name: p.src[start:p.toks[p.i-1].end]}
+	if col, ok := e.(*columnExpr); ok {
+		item.name = col.name
+	}
+	if p.acceptKeyword("AS") {
+		if item.name, err = p.name(); err != nil {
+			return selectItem{}, err
+		}
+	} else if t := p.peek(); t.kind == tokQuotedIdent || t.kind == tokIdent && !queryKeywords[strings.ToUpper(t.text)] {
+		item.name = p.next().text
+	}
+	return item, nil
+}
+
+func (p *queryParser) table() (tableRef, error) {
+	ret := tableRef{}
+	// Only consume the table once it's good, so errors point at it.
+	t := p.peek()
+	switch t.kind {
+	case tokAlias:
+		ret.args = []string{t.text}
+		ret.name, _, _ = strings.Cut(strings.TrimPrefix(t.text, p.aliasPrefix), "!")
+	case tokString:
+		// A workbook and a worksheet or range, which can have spaces in, e.g. 'wb ''My Sheet''!A1:B2'.
+		if wb, rest, ok := strings.Cut(strings.TrimSpace(t.text), " "); ok {
+			ret.args = []string{wb, strings.TrimSpace(rest)}
+		} else if wb != "" {
+			ret.args = []string{wb}
+		}
+		if len(ret.args) == 0 {
+			return ret, p.errorf("empty table")
+		}
+	default:
+		return ret, p.errorf("expected a table (an %valias, or a 'quoted data spec')", p.aliasPrefix)
+	}
+	p.next()
+	if p.acceptKeyword("AS") {
+		name, err := p.name()
+		if err != nil {
+			return ret, err
+		}
+		ret.name = name
+	} else if t := p.peek(); t.kind == tokQuotedIdent || t.kind == tokIdent && !queryKeywords[strings.ToUpper(t.text)] {
+		ret.name = p.next().text
+	}
+	return ret, nil
+}
+
+// Expressions are parsed by precedence, loosest first: OR, AND, NOT, comparisons, + -, * /, unary -.
+
+func (p *queryParser) expr() (queryExpr, error) {
+	l, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		r, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *queryParser) andExpr() (queryExpr, error) {
+	l, err := p.notExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		r, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *queryParser) notExpr() (queryExpr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", x: x}, nil
+	}
+	return p.comparison()
+}
+
+var queryComparisons = map[string]bool{"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *queryParser) comparison() (queryExpr, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	op := ""
+	switch {
+	case t.kind == tokOp && queryComparisons[t.text]:
+		op = t.text
+		p.next()
+	case p.isKeyword("LIKE"):
+		op = "LIKE"
+		p.next()
+	case p.isKeyword("NOT") && p.toks[p.i+1].kind == tokIdent && strings.EqualFold(p.toks[p.i+1].text, "LIKE"):
+		op = "NOT LIKE"
+		p.i += 2
+	default:
+		return l, nil
+	}
+	r, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	if op == "NOT LIKE" {
+		return &unaryExpr{op: "NOT", x: &binaryExpr{op: "LIKE", l: l, r: r}}, nil
+	}
+	return &binaryExpr{op: op, l: l, r: r}, nil
+}
+
+func (p *queryParser) additive() (queryExpr, error) {
+	l, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "+" && t.text != "-") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *queryParser) multiplicative() (queryExpr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "*" && t.text != "/") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *queryParser) unary() (queryExpr, error) {
+	if p.acceptOp("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "-", x: x}, nil
+	}
+	return p.primary()
+}
+
+// The aggregate functions.
+var queryAggregates = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+func (p *queryParser) primary() (queryExpr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf("bad number")
+		}
+		p.next()
+		return &literalExpr{value: NewNumberCell(n)}, nil
+	case tokString:
+		p.next()
+		if t.text == "" {
+			// Not an empty cell, which equals nothing, so that = '' finds them.
+			return &literalExpr{value: NewStringCell("")}, nil
+		}
+		return &literalExpr{value: ParseCell(t.text)}, nil
+	case tokOp:
+		if p.acceptOp("(") {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expectOp(")")
+		}
+	case tokIdent:
+		if fn := strings.ToUpper(t.text); queryAggregates[fn] && p.toks[p.i+1].text == "(" {
+			p.i += 2
+			call := &callExpr{name: fn}
+			if fn == "COUNT" && p.acceptOp("*") {
+				return call, p.expectOp(")")
+			}
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			call.arg = arg
+			return call, p.expectOp(")")
+		}
+		if strings.EqualFold(t.text, "TRUE") || strings.EqualFold(t.text, "FALSE") {
+			p.next()
+			return &literalExpr{value: NewBoolCell(strings.EqualFold(t.text, "TRUE"))}, nil
+		}
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, p.errorf("expected a column, value or (")
+	}
+	if p.acceptOp(".") {
+		col, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return &columnExpr{table: name, name: col}, nil
+	}
+	return &columnExpr{name: name}, nil
+}
@@ -0,0 +1,253 @@
+package sheet
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newQueryBackend(t *testing.T) *MemoryBackend {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "sales", "people", "orders", "customers", "My Sheet")
+	m.SetValues("wb", "My Sheet", [][]interface{}{{"a"}, {"1"}, {"2"}})
+	m.SetValues("wb", "sales", [][]interface{}{
+		{"Name", "Region", "Amount"},
+		{"alice", "EMEA", "10"},
+		{"bob", "US", "5"},
+		{"alice", "US", "7"},
+		{"carol", "EMEA", "3"},
+		{"bob", "EMEA"},
+	})
+	m.SetValues("wb", "people", [][]interface{}{
+		{"Name", "Email"},
+		{"alice", "alice@example.com"},
+		{"bob", "bob@example.com"},
+		{"dave", "dave@example.com"},
+	})
+	m.SetValues("wb", "orders", [][]interface{}{{"Id", "Cust"}, {"1", ""}, {"2"}, {"3", "alice"}})
+	m.SetValues("wb", "customers", [][]interface{}{{"Name", "Email"}, {"", "blank@"}, {"alice", "alice@"}})
+	// For the alias b (c!A1:B2), in alias_small.
+	m.AddWorkbook("b", "c")
+	m.SetValues("b", "c", [][]interface{}{{"x", "y"}, {"1", "2"}, {"3", "4"}})
+	return m
+}
+
+func TestQuery(t *testing.T) {
+	SetupTempConfig(t, "alias_small")
+	tests := []struct {
+		name    string
+		query   string
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "Star",
+			query: "SELECT * FROM 'wb people'",
+			want: [][]interface{}{
+				{"Name", "Email"},
+				{"alice", "alice@example.com"},
+				{"bob", "bob@example.com"},
+				{"dave", "dave@example.com"},
+			},
+		},
+		{
+			name:  "Alias",
+			query: "select x, y * 10 as big from @b where x >= 1",
+			want:  [][]interface{}{{"x", "big"}, {1.0, 20.0}},
+		},
+		{
+			name:  "WhereOrderLimit",
+			query: "SELECT Name, Amount FROM 'wb sales' WHERE Region = 'EMEA' AND Amount >= 3 ORDER BY Amount DESC LIMIT 1",
+			want:  [][]interface{}{{"Name", "Amount"}, {"alice", 10.0}},
+		},
+		{
+			name:  "Offset",
+			query: "SELECT Name FROM 'wb people' ORDER BY Name DESC LIMIT 1 OFFSET 1",
+			want:  [][]interface{}{{"Name"}, {"bob"}},
+		},
+		{
+			name:  "GroupBy",
+			query: "SELECT Name, SUM(Amount), COUNT(*), COUNT(Amount) AS sold FROM 'wb sales' GROUP BY Name ORDER BY 2 DESC",
+			want: [][]interface{}{
+				{"Name", "SUM(Amount)", "COUNT(*)", "sold"},
+				{"alice", 17.0, 2.0, 2.0},
+				{"bob", 5.0, 2.0, 1.0},
+				{"carol", 3.0, 1.0, 1.0},
+			},
+		},
+		{
+			name:  "GroupByOrdinal",
+			query: "SELECT Region, COUNT(*) AS n FROM 'wb sales' GROUP BY 1 ORDER BY n DESC",
+			want:  [][]interface{}{{"Region", "n"}, {"EMEA", 3.0}, {"US", 2.0}},
+		},
+		{
+			name:    "GroupByAggregate",
+			query:   "SELECT COUNT(*) FROM 'wb sales' GROUP BY 1",
+			wantErr: true,
+		},
+		{
+			name:  "Having",
+			query: `SELECT Region, AVG(Amount) "Average", MIN(Name), MAX(Name) FROM 'wb sales' GROUP BY Region HAVING COUNT(*) > 2`,
+			want:  [][]interface{}{{"Region", "Average", "MIN(Name)", "MAX(Name)"}, {"EMEA", 6.5, "alice", "carol"}},
+		},
+		{
+			name:  "AggregateWithoutGroupBy",
+			query: "SELECT COUNT(*), SUM(Amount) FROM 'wb sales' WHERE Region = 'nowhere'",
+			want:  [][]interface{}{{"COUNT(*)", "SUM(Amount)"}, {0.0, 0.0}},
+		},
+		{
+			name:  "Join",
+			query: "SELECT s.Name, p.Email, s.Amount FROM 'wb sales' s JOIN 'wb people' AS p ON s.Name = p.Name WHERE s.Region = 'US' ORDER BY 1",
+			want: [][]interface{}{
+				{"Name", "Email", "Amount"},
+				{"alice", "alice@example.com", 7.0},
+				{"bob", "bob@example.com", 5.0},
+			},
+		},
+		{
+			name:  "LeftJoin",
+			query: "SELECT people.Name, COUNT(sales.Amount) AS n FROM 'wb people' LEFT JOIN 'wb sales' ON people.Name = sales.Name AND sales.Amount > 4 GROUP BY people.Name ORDER BY n, people.Name",
+			want:  [][]interface{}{{"Name", "n"}, {"dave", 0.0}, {"bob", 1.0}, {"alice", 2.0}},
+		},
+		{
+			name:  "BlankKeysDontJoin",
+			query: "SELECT o.Id, c.Email FROM 'wb orders' o LEFT JOIN 'wb customers' c ON o.Cust = c.Name ORDER BY 1",
+			want:  [][]interface{}{{"Id", "Email"}, {1.0, ""}, {2.0, ""}, {3.0, "alice@"}},
+		},
+		{
+			name:  "BlanksNotEqual",
+			query: "SELECT Id FROM 'wb orders' o JOIN 'wb customers' c ON 1 = 1 WHERE o.Cust = c.Name OR o.Cust != c.Name",
+			want:  [][]interface{}{{"Id"}, {1.0}, {2.0}, {3.0}, {3.0}},
+		},
+		{
+			name:  "EqualsEmptyString",
+			query: "SELECT Id FROM 'wb orders' WHERE Cust = ''",
+			want:  [][]interface{}{{"Id"}, {1.0}, {2.0}},
+		},
+		{
+			name:  "QuotedWorksheet",
+			query: "SELECT SUM(a) FROM 'wb ''My Sheet'''",
+			want:  [][]interface{}{{"SUM(a)"}, {3.0}},
+		},
+		{
+			name:  "QuotedWorksheetRange",
+			query: "SELECT a FROM 'wb ''My Sheet''!A1:A2'",
+			want:  [][]interface{}{{"a"}, {1.0}},
+		},
+		{
+			name:  "TableStar",
+			query: "SELECT p.* FROM 'wb sales' s JOIN 'wb people' p ON s.Name = p.Name WHERE s.Amount = 10",
+			want:  [][]interface{}{{"Name", "Email"}, {"alice", "alice@example.com"}},
+		},
+		{
+			name:  "LikeNotOr",
+			query: "SELECT Name FROM 'wb people' WHERE Email LIKE 'A%' OR NOT (Name <> 'dave')",
+			want:  [][]interface{}{{"Name"}, {"alice"}, {"dave"}},
+		},
+		{
+			name:  "NotLike",
+			query: "SELECT Name FROM 'wb people' WHERE Name NOT LIKE '_o%';",
+			want:  [][]interface{}{{"Name"}, {"alice"}, {"dave"}},
+		},
+		{
+			name:  "CaseInsensitiveColumn",
+			query: "SELECT name FROM 'wb people' WHERE name = 'bob'",
+			want:  [][]interface{}{{"name"}, {"bob"}},
+		},
+		{
+			name:    "AmbiguousColumn",
+			query:   "SELECT Name FROM 'wb sales' JOIN 'wb people' ON sales.Name = people.Name",
+			wantErr: true,
+		},
+		{
+			name:    "NoSuchColumn",
+			query:   "SELECT Phone FROM 'wb people'",
+			wantErr: true,
+		},
+		{
+			name:    "SameTableTwice",
+			query:   "SELECT * FROM 'wb people' JOIN 'wb people' ON Name = Name",
+			wantErr: true,
+		},
+		{
+			name:    "AggregateInWhere",
+			query:   "SELECT Name FROM 'wb sales' WHERE SUM(Amount) > 1",
+			wantErr: true,
+		},
+		{
+			name:    "SumOfText",
+			query:   "SELECT SUM(Name) FROM 'wb sales'",
+			wantErr: true,
+		},
+		{
+			name:    "BadOrdinal",
+			query:   "SELECT Name FROM 'wb people' ORDER BY 2",
+			wantErr: true,
+		},
+		{
+			name:    "Workbook",
+			query:   "SELECT * FROM 'wb'",
+			wantErr: true,
+		},
+		{
+			name:    "NoSuchWorksheet",
+			query:   "SELECT * FROM 'wb nope'",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newQueryBackend(t)
+			got, err := Query(context.Background(), b, tt.query, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("Query() = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		// The end of the error, to check it points at the right place.
+		wantMsg string
+	}{
+		{name: "Simple", query: "SELECT a FROM @t"},
+		{name: "Everything", query: `SELECT t.a AS x, "b c", COUNT(*) FROM @t AS t LEFT OUTER JOIN 'wb u' u ON t.a = u.a INNER JOIN @v ON 1=1 ` +
+			`WHERE a != 'it''s' AND -b <= 2.5 GROUP BY 1, a HAVING COUNT(*) > 1 ORDER BY x ASC, 2 DESC LIMIT 5 OFFSET 2`},
+		{name: "NoSelect", query: "a FROM @t", wantErr: true},
+		{name: "NoFrom", query: "SELECT a", wantErr: true},
+		{name: "BareTable", query: "SELECT a FROM t", wantErr: true},
+		{name: "JoinWithoutOn", query: "SELECT a FROM @t JOIN @u", wantErr: true},
+		{name: "LeftWithoutJoin", query: "SELECT a FROM @t LEFT @u ON a = b", wantErr: true},
+		{name: "Unterminated", query: "SELECT 'a FROM @t", wantErr: true},
+		{name: "Trailing", query: "SELECT a FROM @t b c", wantErr: true},
+		{name: "BadLimit", query: "SELECT a FROM @t LIMIT x", wantErr: true},
+		{name: "UnclosedParen", query: "SELECT (a FROM @t", wantErr: true},
+		{name: "BadCharacter", query: "SELECT a FROM @t WHERE a ~ 1", wantErr: true},
+		{name: "NoTable", query: "SELECT COUNT(*) FROM", wantErr: true, wantMsg: "expected a table (an @alias, or a 'quoted data spec'), at end of query"},
+		{name: "NotATable", query: "SELECT a FROM 1", wantErr: true, wantMsg: `at "1" at 14`},
+		{name: "EmptyTable", query: "SELECT a FROM ' '", wantErr: true, wantMsg: `empty table, at "' '" at 14`},
+		{name: "BadNumber", query: "SELECT 1.2.3 FROM @t", wantErr: true, wantMsg: `bad number, at "1.2.3" at 7`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQuery(tt.query, "@")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Errorf("parseQuery() error = %v, want it to end %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,650 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// queryTable is a table's data, as a query sees it.
+type queryTable struct {
+	name   string
+	header []string
+	rows   [][]Cell
+	// Where the table's columns start in a joined row.
+	offset int
+}
+
+// queryCell converts a value read from a sheet for use in a query, so that numbers read as text (as
+// they are with FormattedRender) are numbers.
+func queryCell(v interface{}) Cell {
+	c := CellFromValue(v)
+	if c.Kind == StringCell {
+		return ParseCell(c.Text)
+	}
+	return c
+}
+
+// Query runs a SQL query over worksheets and ranges, returning the results with a header row. The query is
+// evaluated locally, after reading each table in full, so it can join tables from different workbooks. It
+// supports a subset of SQL:
+//
+//	SELECT Name, SUM(Amount) AS Total FROM @sales WHERE Region = 'EMEA' GROUP BY Name ORDER BY 2 DESC LIMIT 10
+//	SELECT o.Id, c.Email FROM @orders o JOIN 'SpReAdShEeTiD Customers' c ON o.Customer = c.Name
+//
+// Tables are aliases, or quoted arguments naming a worksheet or range as 'sheet get' would take them, and
+// their first row is a header. Columns are referred to by their header, in "double quotes" if need be. There
+// are comparisons, AND/OR/NOT, LIKE, arithmetic, COUNT/SUM/AVG/MIN/MAX, [LEFT] JOIN ... ON, WHERE, GROUP BY,
+// HAVING, ORDER BY (by expression, output column name or number) and LIMIT/OFFSET. opts may be nil, in which
+// case values are read unformatted (with dates as strings), so that numbers formatted like "$1,200.00" are
+// still numbers.
+func Query(ctx context.Context, b Backend, query string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	return clientWithBackend(b).Query(ctx, query, opts)
+}
+
+func (c *Client) Query(ctx context.Context, query string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	alias_prefix := c.AliasPrefix
+	if alias_prefix == "" {
+		alias_prefix = "@"
+	}
+	q, err := parseQuery(query, alias_prefix)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &ReadOptions{ValueRender: UnformattedRender, DateRender: StringDateRender}
+	}
+
+	tables, err := c.queryTables(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	return runQuery(q, tables)
+}
+
+// queryTables reads the tables a query refers to, in the order they're named.
+func (c *Client) queryTables(ctx context.Context, q *parsedQuery, opts *ReadOptions) ([]*queryTable, error) {
+	refs := []tableRef{q.from}
+	for _, j := range q.joins {
+		refs = append(refs, j.table)
+	}
+
+	specs := []*DataSpec{}
+	ret := []*queryTable{}
+	for _, ref := range refs {
+		spec, err := c.ResolveArgsToDataSpec(ctx, ref.args)
+		if err != nil {
+			return nil, err
+		}
+		if spec.IsWorkbook() {
+			return nil, fmt.Errorf("tables must be worksheets or ranges, not a workbook: %v", strings.Join(ref.args, " "))
+		}
+		name := ref.name
+		if name == "" {
+			name = spec.Worksheet
+		}
+		for _, t := range ret {
+			if strings.EqualFold(t.name, name) {
+				return nil, fmt.Errorf("more than one table called %v -- name them with AS", name)
+			}
+		}
+		specs = append(specs, spec)
+		ret = append(ret, &queryTable{name: name})
+	}
+
+	resp, err := c.BatchGetValues(ctx, specs, opts)
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	for i, t := range ret {
+		values := resp[i].Values
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no header row in %v", specs[i].String())
+		}
+		for _, v := range values[0] {
+			t.header = append(t.header, CellFromValue(v).String())
+		}
+		for _, row := range values[1:] {
+			cells := make([]Cell, len(t.header))
+			for j := 0; j < len(cells) && j < len(row); j++ {
+				cells[j] = queryCell(row[j])
+			}
+			t.rows = append(t.rows, cells)
+		}
+		t.offset = offset
+		offset += len(t.header)
+	}
+	return ret, nil
+}
+
+// findColumn returns the index of a column in a header, matching case if it can.
+func findColumn(header []string, name string) (int, bool) {
+	found := -1
+	for i, h := range header {
+		if h == name {
+			return i, true
+		}
+		if strings.EqualFold(h, name) {
+			if found >= 0 {
+				// Ambiguous without the case.
+				return 0, false
+			}
+			found = i
+		}
+	}
+	return found, found >= 0
+}
+
+// bindColumns resolves the columns in an expression to their places in a joined row of tables.
+func bindColumns(e queryExpr, tables []*queryTable) error {
+	switch e := e.(type) {
+	case *columnExpr:
+		slot := -1
+		for _, t := range tables {
+			if e.table != "" && !strings.EqualFold(e.table, t.name) {
+				continue
+			}
+			if i, ok := findColumn(t.header, e.name); ok {
+				if slot >= 0 {
+					return fmt.Errorf("column %v is in more than one table -- say which, e.g. %v.%v", e.name, t.name, e.name)
+				}
+				slot = t.offset + i
+			}
+		}
+		if slot < 0 {
+			if e.table != "" {
+				return fmt.Errorf("no column %v.%v", e.table, e.name)
+			}
+			return fmt.Errorf("no column %v", e.name)
+		}
+		e.slot = slot
+	case *unaryExpr:
+		return bindColumns(e.x, tables)
+	case *binaryExpr:
+		if err := bindColumns(e.l, tables); err != nil {
+			return err
+		}
+		return bindColumns(e.r, tables)
+	case *callExpr:
+		if e.arg != nil {
+			return bindColumns(e.arg, tables)
+		}
+	}
+	return nil
+}
+
+// hasAggregate returns true if an expression has an aggregate function in it.
+func hasAggregate(e queryExpr) bool {
+	switch e := e.(type) {
+	case *callExpr:
+		return true
+	case *unaryExpr:
+		return hasAggregate(e.x)
+	case *binaryExpr:
+		return hasAggregate(e.l) || hasAggregate(e.r)
+	}
+	return false
+}
+
+// evalContext is what an expression is evaluated against: a row, and for grouped queries, its group.
+type evalContext struct {
+	row   []Cell
+	group [][]Cell
+}
+
+func truthy(c Cell) bool {
+	switch c.Kind {
+	case BoolCell:
+		return c.Bool
+	case NumberCell:
+		return c.Number != 0
+	case EmptyCell:
+		return false
+	}
+	return c.Text != ""
+}
+
+// likeRegexp converts a LIKE pattern (with % and _ wildcards) to a case-insensitive regexp.
+func likeRegexp(pattern string) *regexp.Regexp {
+	re := strings.Builder{}
+	re.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
+
+func arithmetic(op string, a Cell, b Cell) (Cell, error) {
+	if a.Kind == EmptyCell || b.Kind == EmptyCell {
+		return Cell{}, nil
+	}
+	if a.Kind != NumberCell || b.Kind != NumberCell {
+		return Cell{}, fmt.Errorf("can't %v %q and %q", op, a.String(), b.String())
+	}
+	switch op {
+	case "+":
+		return NewNumberCell(a.Number + b.Number), nil
+	case "-":
+		return NewNumberCell(a.Number - b.Number), nil
+	case "*":
+		return NewNumberCell(a.Number * b.Number), nil
+	}
+	if b.Number == 0 {
+		return Cell{}, fmt.Errorf("division by zero")
+	}
+	return NewNumberCell(a.Number / b.Number), nil
+}
+
+func evalExpr(e queryExpr, ctx *evalContext) (Cell, error) {
+	switch e := e.(type) {
+	case *literalExpr:
+		return e.value, nil
+	case *columnExpr:
+		if e.slot >= len(ctx.row) {
+			return Cell{}, nil
+		}
+		return ctx.row[e.slot], nil
+	case *unaryExpr:
+		x, err := evalExpr(e.x, ctx)
+		if err != nil {
+			return Cell{}, err
+		}
+		if e.op == "NOT" {
+			return NewBoolCell(!truthy(x)), nil
+		}
+		return arithmetic("-", NewNumberCell(0), x)
+	case *binaryExpr:
+		l, err := evalExpr(e.l, ctx)
+		if err != nil {
+			return Cell{}, err
+		}
+		// AND and OR don't need the right side if the left decides it.
+		if e.op == "AND" && !truthy(l) || e.op == "OR" && truthy(l) {
+			return NewBoolCell(e.op == "OR"), nil
+		}
+		r, err := evalExpr(e.r, ctx)
+		if err != nil {
+			return Cell{}, err
+		}
+		switch e.op {
+		case "AND", "OR":
+			return NewBoolCell(truthy(r)), nil
+		case "LIKE":
+			if e.like == nil || e.pattern != r.String() {
+				e.pattern, e.like = r.String(), likeRegexp(r.String())
+			}
+			return NewBoolCell(e.like.MatchString(l.String())), nil
+		case "+", "-", "*", "/":
+			return arithmetic(e.op, l, r)
+		}
+		// Empty cells are like SQL's NULL, which isn't equal (or unequal) to another NULL, so that blank
+		// keys don't join to each other. Compare with '' to find them.
+		if l.Kind == EmptyCell && r.Kind == EmptyCell {
+			return NewBoolCell(false), nil
+		}
+		return NewBoolCell(compareOp(e.op, l, r)), nil
+	case *callExpr:
+		if ctx.group == nil {
+			return Cell{}, fmt.Errorf("%v() can only be used in SELECT, HAVING or ORDER BY", e.name)
+		}
+		return aggregate(e, ctx.group)
+	}
+	return Cell{}, fmt.Errorf("can't evaluate %T", e)
+}
+
+func aggregate(e *callExpr, group [][]Cell) (Cell, error) {
+	if e.arg == nil {
+		return NewNumberCell(float64(len(group))), nil
+	}
+	values := []Cell{}
+	for _, row := range group {
+		v, err := evalExpr(e.arg, &evalContext{row: row})
+		if err != nil {
+			return Cell{}, err
+		}
+		if v.Kind != EmptyCell {
+			values = append(values, v)
+		}
+	}
+
+	switch e.name {
+	case "COUNT":
+		return NewNumberCell(float64(len(values))), nil
+	case "SUM", "AVG":
+		sum := 0.0
+		for _, v := range values {
+			if v.Kind != NumberCell {
+				return Cell{}, fmt.Errorf("can't %v %q, it isn't a number", e.name, v.String())
+			}
+			sum += v.Number
+		}
+		if e.name == "SUM" {
+			return NewNumberCell(sum), nil
+		}
+		if len(values) == 0 {
+			return Cell{}, nil
+		}
+		return NewNumberCell(sum / float64(len(values))), nil
+	}
+
+	// MIN and MAX
+	if len(values) == 0 {
+		return Cell{}, nil
+	}
+	ret := values[0]
+	for _, v := range values[1:] {
+		c := compareCells(v, ret)
+		if e.name == "MIN" && c < 0 || e.name == "MAX" && c > 0 {
+			ret = v
+		}
+	}
+	return ret, nil
+}
+
+// joinRows joins the rows so far with a table's, on a condition. An equality between a column already
+// joined and a column of the table is done with a lookup, rather than comparing every pair of rows.
+func joinRows(rows [][]Cell, t *queryTable, join joinClause, width int) ([][]Cell, error) {
+	combine := func(l []Cell, r []Cell) []Cell {
+		row := make([]Cell, width)
+		copy(row, l)
+		copy(row[t.offset:], r)
+		return row
+	}
+	// The index in t of a column that's in it.
+	inTable := func(col *columnExpr) (int, bool) {
+		return col.slot - t.offset, col.slot >= t.offset && col.slot < t.offset+len(t.header)
+	}
+
+	var lookup map[string][]int
+	var key *columnExpr
+	if eq, ok := join.on.(*binaryExpr); ok && eq.op == "=" {
+		l, lok := eq.l.(*columnExpr)
+		r, rok := eq.r.(*columnExpr)
+		if lok && rok {
+			if _, ok := inTable(l); ok {
+				l, r = r, l
+			}
+			if i, ok := inTable(r); ok && l.slot < t.offset {
+				key = l
+				lookup = map[string][]int{}
+				for j, row := range t.rows {
+					// Blank keys never match (see evalExpr), so leave them out.
+					if row[i].Kind != EmptyCell {
+						lookup[row[i].String()] = append(lookup[row[i].String()], j)
+					}
+				}
+			}
+		}
+	}
+
+	ret := [][]Cell{}
+	for _, l := range rows {
+		matched := false
+		candidates := t.rows
+		if lookup != nil {
+			candidates = nil
+			for _, j := range lookup[l[key.slot].String()] {
+				candidates = append(candidates, t.rows[j])
+			}
+		}
+		for _, r := range candidates {
+			row := combine(l, r)
+			on, err := evalExpr(join.on, &evalContext{row: row})
+			if err != nil {
+				return nil, err
+			}
+			if truthy(on) {
+				ret = append(ret, row)
+				matched = true
+			}
+		}
+		if join.left && !matched {
+			ret = append(ret, combine(l, nil))
+		}
+	}
+	return ret, nil
+}
+
+// queryResult is an output row, with what it was made from, for ORDER BY.
+type queryResult struct {
+	values []Cell
+	ctx    *evalContext
+}
+
+func runQuery(q *parsedQuery, tables []*queryTable) (*sheets.ValueRange, error) {
+	width := 0
+	for _, t := range tables {
+		width += len(t.header)
+	}
+
+	// Expand * and t.* to the columns they stand for.
+	selects := []selectItem{}
+	for _, item := range q.selects {
+		if !item.star {
+			if err := bindColumns(item.expr, tables); err != nil {
+				return nil, err
+			}
+			selects = append(selects, item)
+			continue
+		}
+		found := false
+		for _, t := range tables {
+			if item.starTable != "" && !strings.EqualFold(item.starTable, t.name) {
+				continue
+			}
+			found = true
+			for i, h := range t.header {
+				selects = append(selects, selectItem{expr: &columnExpr{table: t.name, name: h, slot: t.offset + i}, name: h})
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no table %v", item.starTable)
+		}
+	}
+
+	rows := [][]Cell{}
+	for _, r := range tables[0].rows {
+		row := make([]Cell, width)
+		copy(row, r)
+		rows = append(rows, row)
+	}
+	for i, join := range q.joins {
+		if err := bindColumns(join.on, tables[:i+2]); err != nil {
+			return nil, err
+		}
+		var err error
+		if rows, err = joinRows(rows, tables[i+1], join, width); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.where != nil {
+		if err := bindColumns(q.where, tables); err != nil {
+			return nil, err
+		}
+		kept := [][]Cell{}
+		for _, row := range rows {
+			ok, err := evalExpr(q.where, &evalContext{row: row})
+			if err != nil {
+				return nil, err
+			}
+			if truthy(ok) {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+
+	// ORDER BY can name an output column, or give its number.
+	orderCols := make([]int, len(q.orderBy))
+	for i, item := range q.orderBy {
+		orderCols[i] = -1
+		switch e := item.expr.(type) {
+		case *literalExpr:
+			n := int(e.value.Number)
+			if e.value.Kind != NumberCell || float64(n) != e.value.Number || n < 1 || n > len(selects) {
+				return nil, fmt.Errorf("ORDER BY %v isn't a column number from 1 to %d", e.value.String(), len(selects))
+			}
+			orderCols[i] = n - 1
+			continue
+		case *columnExpr:
+			if e.table == "" {
+				for j, s := range selects {
+					if s.name == e.name {
+						orderCols[i] = j
+						break
+					}
+				}
+			}
+		}
+		if orderCols[i] < 0 {
+			if err := bindColumns(item.expr, tables); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	grouped := len(q.groupBy) > 0 || q.having != nil
+	for _, s := range selects {
+		grouped = grouped || hasAggregate(s.expr)
+	}
+	for i, item := range q.orderBy {
+		grouped = grouped || orderCols[i] < 0 && hasAggregate(item.expr)
+	}
+
+	contexts := []*evalContext{}
+	if grouped {
+		groups := map[string]int{}
+		for i, e := range q.groupBy {
+			// GROUP BY 1 is by the first output column.
+			if lit, ok := e.(*literalExpr); ok {
+				n := int(lit.value.Number)
+				if lit.value.Kind != NumberCell || float64(n) != lit.value.Number || n < 1 || n > len(selects) {
+					return nil, fmt.Errorf("GROUP BY %v isn't a column number from 1 to %d", lit.value.String(), len(selects))
+				}
+				e = selects[n-1].expr
+				q.groupBy[i] = e
+			}
+			if hasAggregate(e) {
+				return nil, fmt.Errorf("can't GROUP BY an aggregate")
+			}
+			if err := bindColumns(e, tables); err != nil {
+				return nil, err
+			}
+		}
+		for _, row := range rows {
+			key := []string{}
+			for _, e := range q.groupBy {
+				v, err := evalExpr(e, &evalContext{row: row})
+				if err != nil {
+					return nil, err
+				}
+				key = append(key, v.String())
+			}
+			k := strings.Join(key, "\x00")
+			g, ok := groups[k]
+			if !ok {
+				g = len(contexts)
+				groups[k] = g
+				contexts = append(contexts, &evalContext{row: row})
+			}
+			contexts[g].group = append(contexts[g].group, row)
+		}
+		// Aggregates of nothing are still a row, e.g. COUNT(*) is 0.
+		if len(q.groupBy) == 0 && len(contexts) == 0 {
+			contexts = append(contexts, &evalContext{group: [][]Cell{}})
+		}
+		if q.having != nil {
+			if err := bindColumns(q.having, tables); err != nil {
+				return nil, err
+			}
+			kept := []*evalContext{}
+			for _, ctx := range contexts {
+				ok, err := evalExpr(q.having, ctx)
+				if err != nil {
+					return nil, err
+				}
+				if truthy(ok) {
+					kept = append(kept, ctx)
+				}
+			}
+			contexts = kept
+		}
+	} else {
+		for _, row := range rows {
+			contexts = append(contexts, &evalContext{row: row})
+		}
+	}
+
+	results := []*queryResult{}
+	for _, ctx := range contexts {
+		res := &queryResult{ctx: ctx}
+		for _, s := range selects {
+			v, err := evalExpr(s.expr, ctx)
+			if err != nil {
+				return nil, err
+			}
+			res.values = append(res.values, v)
+		}
+		results = append(results, res)
+	}
+
+	if len(q.orderBy) > 0 {
+		// Work out the sort keys first, so sorting can't fail.
+		keys := map[*queryResult][]Cell{}
+		for _, res := range results {
+			for i, item := range q.orderBy {
+				if orderCols[i] >= 0 {
+					keys[res] = append(keys[res], res.values[orderCols[i]])
+					continue
+				}
+				v, err := evalExpr(item.expr, res.ctx)
+				if err != nil {
+					return nil, err
+				}
+				keys[res] = append(keys[res], v)
+			}
+		}
+		sort.SliceStable(results, func(a, b int) bool {
+			for i, item := range q.orderBy {
+				c := compareCells(keys[results[a]][i], keys[results[b]][i])
+				if c != 0 {
+					return c < 0 != item.desc
+				}
+			}
+			return false
+		})
+	}
+
+	results = results[min(q.offset, len(results)):]
+	if q.limit >= 0 && q.limit < len(results) {
+		results = results[:q.limit]
+	}
+
+	header := []interface{}{}
+	for _, s := range selects {
+		header = append(header, s.name)
+	}
+	ret := &sheets.ValueRange{Values: [][]interface{}{header}}
+	for _, res := range results {
+		row := []interface{}{}
+		for _, v := range res.values {
+			row = append(row, v.Value())
+		}
+		ret.Values = append(ret.Values, row)
+	}
+	return ret, nil
+}
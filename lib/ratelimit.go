@@ -0,0 +1,164 @@
+package sheet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket, limiting requests to a number per minute. Its state can be kept in
+// a file (see NewSharedRateLimiter), so several processes share one budget.
+type RateLimiter struct {
+	perMinute float64
+	// How many requests can be made at once, after a quiet spell. Defaults to 1, i.e. requests are evenly spaced.
+	burst float64
+	// Where the bucket is kept, if it's shared.
+	path string
+
+	mu    sync.Mutex
+	state bucketState
+
+	// For testing.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter returns a RateLimiter allowing perMinute requests a minute in this process.
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{perMinute: float64(perMinute), burst: 1}
+}
+
+// NewSharedRateLimiter returns a RateLimiter allowing perMinute requests a minute between every process using
+// the state file at path. The file is locked while it's updated.
+func NewSharedRateLimiter(perMinute int, path string) *RateLimiter {
+	return &RateLimiter{perMinute: float64(perMinute), burst: 1, path: path}
+}
+
+// DefaultRateLimitFile is where the CLI keeps its shared rate limit state.
+func DefaultRateLimitFile() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return filepath.Join(homedir, ".config", "sheet", "ratelimit.json"), nil
+}
+
+// The state of a token bucket.
+type bucketState struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// take takes a token from the bucket, and returns how long to wait before using it. The bucket
+// goes into debt if it's empty, so that everyone waiting is queued up in turn.
+func (s *bucketState) take(now time.Time, perMinute float64, burst float64) time.Duration {
+	if s.Updated.IsZero() {
+		s.Tokens = burst
+		s.Updated = now
+	}
+	perSecond := perMinute / 60
+	if now.After(s.Updated) {
+		s.Tokens = min(burst, s.Tokens+now.Sub(s.Updated).Seconds()*perSecond)
+		s.Updated = now
+	}
+	s.Tokens--
+	if s.Tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-s.Tokens / perSecond * float64(time.Second))
+}
+
+// Wait blocks until a request may be made, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait, err := l.reserve()
+	if err != nil {
+		return err
+	}
+	if wait <= 0 {
+		return nil
+	}
+	sleep := l.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	return sleep(ctx, wait)
+}
+
+func (l *RateLimiter) reserve() (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+
+	if l.path == "" {
+		return l.state.take(now, l.perMinute, l.burst), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return 0, fmt.Errorf("unable to create rate limit directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open rate limit file: %w", err)
+	}
+	defer f.Close()
+
+	unlock, err := lockFile(f)
+	if err != nil {
+		return 0, fmt.Errorf("unable to lock rate limit file: %w", err)
+	}
+	defer unlock()
+
+	state := bucketState{}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read rate limit file: %w", err)
+	}
+	if len(data) > 0 {
+		// If it's corrupt somehow, start again with a full bucket.
+		if json.Unmarshal(data, &state) != nil {
+			state = bucketState{}
+		}
+	}
+
+	wait := state.take(now, l.perMinute, l.burst)
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return 0, fmt.Errorf("unable to write rate limit file: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return 0, fmt.Errorf("unable to write rate limit file: %w", err)
+	}
+	return wait, nil
+}
+
+// RateLimitTransport is an http.RoundTripper that waits for a RateLimiter before each request.
+type RateLimitTransport struct {
+	// The transport that actually makes requests. Defaults to http.DefaultTransport.
+	Base    http.RoundTripper
+	Limiter *RateLimiter
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if err := t.Limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,149 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for RateLimiters, which only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestRateLimiter returns l using clock, recording the delays it would sleep for, without sleeping.
+func newTestRateLimiter(l *RateLimiter, clock *fakeClock) *[]time.Duration {
+	var mu sync.Mutex
+	delays := &[]time.Duration{}
+	l.now = clock.Now
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return delays
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(60)
+	delays := newTestRateLimiter(l, clock)
+
+	// The first request goes straight away, then the next ones queue up a second apart.
+	for range 3 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	want := []time.Duration{time.Second, 2 * time.Second}
+	if len(*delays) != len(want) || (*delays)[0] != want[0] || (*delays)[1] != want[1] {
+		t.Fatalf("delays = %v, want %v", *delays, want)
+	}
+
+	// After a quiet spell, there's no wait -- but only for one request.
+	clock.Advance(time.Minute)
+	*delays = nil
+	l.Wait(context.Background())
+	l.Wait(context.Background())
+	if len(*delays) != 1 || (*delays)[0] != time.Second {
+		t.Errorf("delays after a minute = %v, want [1s]", *delays)
+	}
+}
+
+func TestRateLimiter_Cancelled(t *testing.T) {
+	l := NewRateLimiter(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Wait(ctx)
+
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRateLimiter_Shared(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	path := filepath.Join(t.TempDir(), "sheet", "ratelimit.json")
+
+	// As if in separate processes, sharing only the file.
+	limiters := []*RateLimiter{}
+	delays := []*[]time.Duration{}
+	for range 4 {
+		l := NewSharedRateLimiter(120, path)
+		limiters = append(limiters, l)
+		delays = append(delays, newTestRateLimiter(l, clock))
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range limiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				if err := l.Wait(context.Background()); err != nil {
+					t.Errorf("Wait() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 20 requests at 2/s: one straight away, the rest queued up to 9.5s out, each in its own slot.
+	slots := map[time.Duration]bool{}
+	for _, d := range delays {
+		for _, delay := range *d {
+			if slots[delay] {
+				t.Errorf("more than one request waiting for %v", delay)
+			}
+			slots[delay] = true
+		}
+	}
+	if len(slots) != 19 {
+		t.Errorf("got %v delayed requests, want 19", len(slots))
+	}
+	for i := 1; i < 20; i++ {
+		if d := time.Duration(i) * 500 * time.Millisecond; !slots[d] {
+			t.Errorf("no request waiting for %v", d)
+		}
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	srv := newFlakyServer(t, 0, 200, "", "{}")
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(30)
+	delays := newTestRateLimiter(l, clock)
+
+	client := &http.Client{Transport: &RateLimitTransport{Limiter: l}}
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if srv.count() != 2 {
+		t.Errorf("server got %v requests, want 2", srv.count())
+	}
+	if len(*delays) != 1 || (*delays)[0] != 2*time.Second {
+		t.Errorf("delays = %v, want [2s]", *delays)
+	}
+}
@@ -0,0 +1,78 @@
+package sheet
+
+import (
+	"errors"
+)
+
+// Implement enum-a-likes for the --render and --date-render flags
+type ValueRender string
+
+const (
+	FormattedRender   ValueRender = "formatted"
+	UnformattedRender ValueRender = "unformatted"
+	FormulaRender     ValueRender = "formula"
+)
+
+func (r *ValueRender) String() string { return string(*r) }
+func (r *ValueRender) Type() string   { return "ValueRender" }
+func (r *ValueRender) Set(v string) error {
+	switch v {
+	case "formatted", "unformatted", "formula":
+		*r = ValueRender(v)
+		return nil
+	default:
+		return errors.New("invalid ValueRender. Allowed [formatted|unformatted|formula]")
+	}
+}
+
+// The API's ValueRenderOption for this render mode.
+func (r *ValueRender) apiOption() string {
+	switch *r {
+	case UnformattedRender:
+		return "UNFORMATTED_VALUE"
+	case FormulaRender:
+		return "FORMULA"
+	default:
+		return "FORMATTED_VALUE"
+	}
+}
+
+type DateRender string
+
+const (
+	SerialDateRender DateRender = "serial"
+	StringDateRender DateRender = "string"
+)
+
+func (r *DateRender) String() string { return string(*r) }
+func (r *DateRender) Type() string   { return "DateRender" }
+func (r *DateRender) Set(v string) error {
+	switch v {
+	case "serial", "string":
+		*r = DateRender(v)
+		return nil
+	default:
+		return errors.New("invalid DateRender. Allowed [serial|string]")
+	}
+}
+
+// The API's DateTimeRenderOption for this render mode.
+func (r *DateRender) apiOption() string {
+	switch *r {
+	case StringDateRender:
+		return "FORMATTED_STRING"
+	default:
+		return "SERIAL_NUMBER"
+	}
+}
+
+// ReadOptions controls how values are rendered when read from a sheet.
+// The zero value reads values as they're formatted in the sheet.
+type ReadOptions struct {
+	// How values are rendered: as formatted in the sheet, unformatted (raw numbers, bools etc.),
+	// or as the formulas behind them.
+	ValueRender ValueRender
+	// How dates and times are rendered when ValueRender is unformatted or formula:
+	// as serial numbers (days since 1899-12-30), or as strings in the cell's format.
+	DateRender DateRender
+}
@@ -0,0 +1,67 @@
+package sheet
+
+import "testing"
+
+func TestValueRender_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		want    string
+		wantErr bool
+	}{
+		{name: "Formatted", v: "formatted", want: "FORMATTED_VALUE"},
+		{name: "Unformatted", v: "unformatted", want: "UNFORMATTED_VALUE"},
+		{name: "Formula", v: "formula", want: "FORMULA"},
+		{name: "Unknown", v: "blah", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r ValueRender
+			if err := r.Set(tt.v); (err != nil) != tt.wantErr {
+				t.Errorf("ValueRender.Set() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := r.apiOption(); got != tt.want {
+				t.Errorf("ValueRender.apiOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueRender_DefaultIsFormatted(t *testing.T) {
+	var r ValueRender
+	if got := r.apiOption(); got != "FORMATTED_VALUE" {
+		t.Errorf("ValueRender.apiOption() = %v, want FORMATTED_VALUE", got)
+	}
+}
+
+func TestDateRender_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		want    string
+		wantErr bool
+	}{
+		{name: "Serial", v: "serial", want: "SERIAL_NUMBER"},
+		{name: "String", v: "string", want: "FORMATTED_STRING"},
+		{name: "Unknown", v: "blah", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r DateRender
+			if err := r.Set(tt.v); (err != nil) != tt.wantErr {
+				t.Errorf("DateRender.Set() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := r.apiOption(); got != tt.want {
+				t.Errorf("DateRender.apiOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package sheet
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for RetryTransport.
+const (
+	DefaultMaxAttempts = 5
+	defaultBaseDelay   = time.Second
+	defaultMaxDelay    = 32 * time.Second
+)
+
+// RetryTransport is an http.RoundTripper that retries requests which fail with quota (429) or
+// transient server (5xx) errors, or which don't get a response at all. Retries back off exponentially,
+// with jitter, unless the server says how long to wait with Retry-After (which is capped at MaxDelay).
+//
+// A request that isn't idempotent, like appending values, may have been done by the server even if it
+// failed with a 5xx or no response, so those are only retried after a 429, when the server didn't do
+// them. See idempotent.
+//
+// Requests with a body are only retried if the body can be re-read (i.e. req.GetBody is set,
+// as it is for everything the Sheets API client sends).
+type RetryTransport struct {
+	// The transport that actually makes requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// How many times to try a request, including the first. Defaults to DefaultMaxAttempts; 1 disables retries.
+	MaxAttempts int
+	// The delay before the first retry, doubled for each retry after that up to MaxDelay.
+	// Default to 1s and 32s.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// For testing, so we don't actually wait.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryTransport returns a RetryTransport wrapping base, which tries each request up to maxAttempts times.
+func NewRetryTransport(base http.RoundTripper, maxAttempts int) *RetryTransport {
+	return &RetryTransport{Base: base, MaxAttempts: maxAttempts}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	attempts := t.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// We can't send the body again.
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := base.RoundTrip(req)
+
+		if attempt >= attempts || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = min(after, t.maxDelay())
+			}
+			// Let the connection be reused.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		// Cancelled, or out of time.
+		return false
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		// Rejected before it was done, so it's always safe to send again.
+		return true
+	}
+	if !idempotent(req) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// The paths of Sheets API POSTs that only set, clear or read values, so doing them twice is the same as once.
+var idempotentPostSuffixes = []string{"/values:batchUpdate", "/values:batchClear", "/values:batchGet", ":clear"}
+
+// idempotent returns true if sending a request twice has the same effect as sending it once, so it can be
+// retried even if it might have been done already. POSTs aren't, in general: a values:append would append
+// the rows again, and a batchUpdate could delete more rows or add another worksheet.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		for _, suffix := range idempotentPostSuffixes {
+			if strings.HasSuffix(req.URL.Path, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *RetryTransport) maxDelay() time.Duration {
+	if t.MaxDelay <= 0 {
+		return defaultMaxDelay
+	}
+	return t.MaxDelay
+}
+
+// backoff returns how long to wait after the given (1-based) attempt: somewhere between half and
+// all of BaseDelay * 2^(attempt-1), capped at MaxDelay.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	base := t.BaseDelay
+	if base <= 0 {
+		base = defaultBaseDelay
+	}
+	max := t.maxDelay()
+
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	d = min(d, max)
+
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a response's Retry-After header, which is either a number of seconds or a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(h); err == nil {
+		return max(time.Until(when), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,270 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// flakyServer fails the first `failures` requests with status (and Retry-After, if set), then succeeds
+// with body. It records the body of every request it gets.
+type flakyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFlakyServer(t *testing.T, failures int, status int, retryAfter string, body string) *flakyServer {
+	f := &flakyServer{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, string(reqBody))
+		n := len(f.requests)
+		f.mu.Unlock()
+
+		if n <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, `{"error": {"code": 429, "message": "Quota exceeded"}}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *flakyServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// newTestRetryTransport returns a RetryTransport that records the delays it would sleep for, without sleeping.
+func newTestRetryTransport(maxAttempts int) (*RetryTransport, *[]time.Duration) {
+	delays := &[]time.Duration{}
+	t := &RetryTransport{MaxAttempts: maxAttempts, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return t, delays
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		status      int
+		retryAfter  string
+		maxAttempts int
+		wantStatus  int
+		wantCount   int
+	}{
+		{name: "NoFailures", failures: 0, status: 429, maxAttempts: 5, wantStatus: 200, wantCount: 1},
+		{name: "Quota", failures: 2, status: 429, maxAttempts: 5, wantStatus: 200, wantCount: 3},
+		{name: "ServerError", failures: 1, status: 503, maxAttempts: 5, wantStatus: 200, wantCount: 2},
+		{name: "GiveUp", failures: 10, status: 429, maxAttempts: 3, wantStatus: 429, wantCount: 3},
+		{name: "NoRetries", failures: 1, status: 429, maxAttempts: 1, wantStatus: 429, wantCount: 1},
+		{name: "NotRetryable", failures: 1, status: 400, maxAttempts: 5, wantStatus: 400, wantCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFlakyServer(t, tt.failures, tt.status, tt.retryAfter, "{}")
+			rt, _ := newTestRetryTransport(tt.maxAttempts)
+
+			resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Get() status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if srv.count() != tt.wantCount {
+				t.Errorf("server got %v requests, want %v", srv.count(), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_Delays(t *testing.T) {
+	srv := newFlakyServer(t, 4, 429, "", "{}")
+	rt, delays := newTestRetryTransport(5)
+
+	resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	// 1s, 2s, 4s, then capped at 4s -- each with jitter taking off up to half.
+	maxDelays := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	if len(*delays) != len(maxDelays) {
+		t.Fatalf("delays = %v, want %v of them", *delays, len(maxDelays))
+	}
+	for i, d := range *delays {
+		if d < maxDelays[i]/2 || d > maxDelays[i] {
+			t.Errorf("delay %v = %v, want between %v and %v", i, d, maxDelays[i]/2, maxDelays[i])
+		}
+	}
+}
+
+func TestRetryTransport_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+	}{
+		{name: "Seconds", retryAfter: "3", want: 3 * time.Second},
+		{name: "CappedAtMaxDelay", retryAfter: "86400", want: 4 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFlakyServer(t, 1, 429, tt.retryAfter, "{}")
+			rt, delays := newTestRetryTransport(5)
+
+			resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if len(*delays) != 1 || (*delays)[0] != tt.want {
+				t.Errorf("delays = %v, want [%v]", *delays, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_Posts(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		wantCount int
+	}{
+		{name: "AppendQuota", path: "/v4/spreadsheets/wb/values/Sheet1!A1:append", status: 429, wantCount: 2},
+		{name: "AppendServerError", path: "/v4/spreadsheets/wb/values/Sheet1!A1:append", status: 503, wantCount: 1},
+		{name: "BatchUpdateServerError", path: "/v4/spreadsheets/wb:batchUpdate", status: 503, wantCount: 1},
+		{name: "ValuesBatchUpdateServerError", path: "/v4/spreadsheets/wb/values:batchUpdate", status: 503, wantCount: 2},
+		{name: "ClearServerError", path: "/v4/spreadsheets/wb/values/Sheet1!A1:clear", status: 503, wantCount: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFlakyServer(t, 1, tt.status, "", "{}")
+			rt, _ := newTestRetryTransport(5)
+
+			resp, err := (&http.Client{Transport: rt}).Post(srv.URL+tt.path, "application/json", strings.NewReader("{}"))
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			resp.Body.Close()
+			if srv.count() != tt.wantCount {
+				t.Errorf("server got %v requests, want %v", srv.count(), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_ResendsBody(t *testing.T) {
+	srv := newFlakyServer(t, 2, 429, "", "{}")
+	rt, _ := newTestRetryTransport(5)
+
+	resp, err := (&http.Client{Transport: rt}).Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if srv.count() != 3 {
+		t.Errorf("server got %v requests, want 3", srv.count())
+	}
+	for i, body := range srv.requests {
+		if body != "hello" {
+			t.Errorf("request %v body = %q, want %q", i, body, "hello")
+		}
+	}
+}
+
+func TestRetryTransport_Cancelled(t *testing.T) {
+	srv := newFlakyServer(t, 10, 429, "", "{}")
+	ctx, cancel := context.WithCancel(context.Background())
+	rt, _ := newTestRetryTransport(5)
+	rt.sleep = func(context.Context, time.Duration) error {
+		// Interrupted while waiting to retry.
+		cancel()
+		return ctx.Err()
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err := (&http.Client{Transport: rt}).Do(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() error = %v, want context.Canceled", err)
+	}
+	if srv.count() != 1 {
+		t.Errorf("server got %v requests, want 1", srv.count())
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "None", header: "", wantOk: false},
+		{name: "Seconds", header: "30", want: 30 * time.Second, wantOk: true},
+		{name: "PastDate", header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOk: true},
+		{name: "Garbage", header: "soon", wantOk: false},
+		{name: "Negative", header: "-1", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(resp)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGoogleBackend_Retries(t *testing.T) {
+	// A Sheets API that's over quota for a couple of requests.
+	srv := newFlakyServer(t, 2, 429, "0", `{"range": "Sheet1!A1:B1", "values": [["a", "b"]]}`)
+	rt, _ := newTestRetryTransport(5)
+
+	service, err := sheets.NewService(context.Background(),
+		option.WithHTTPClient(&http.Client{Transport: rt}), option.WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	resp, err := NewGoogleBackend(service).GetValues(context.Background(), "wb", "Sheet1!A1:B1", nil)
+	if err != nil {
+		t.Fatalf("GetValues() error = %v", err)
+	}
+	if got, _ := FormatValues(resp, CsvFormat); got != "a,b\n" {
+		t.Errorf("GetValues() = %q, want %q", got, "a,b\n")
+	}
+	if srv.count() != 3 {
+		t.Errorf("server got %v requests, want 3", srv.count())
+	}
+}
@@ -0,0 +1,218 @@
+package sheet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// DefaultWriteChunkSize is how many rows WriteStreamToWorksheet writes per request, unless told otherwise.
+const DefaultWriteChunkSize = 500
+
+// StreamOptions controls how WriteStreamToWorksheet reads and writes data.
+// The zero value reads csv, and writes it in chunks of DefaultWriteChunkSize rows, as if typed in by a user.
+type StreamOptions struct {
+	WriteOptions
+	// The format of the input. Only csv and tsv can be streamed.
+	Format DataFormat
+	// How many rows to write per request.
+	ChunkSize int
+	// If set, the number of rows written so far is kept in this file after each chunk. If the write is
+	// interrupted, calling again with the same file (and the same input) carries on after the last chunk
+	// written, rather than starting again. The file is removed once the write is complete.
+	CheckpointFile string
+}
+
+// streamCheckpoint is what's kept in a StreamOptions.CheckpointFile.
+type streamCheckpoint struct {
+	Workbook  string `json:"workbook"`
+	Worksheet string `json:"worksheet"`
+	// Rows of the input written so far.
+	Rows int `json:"rows"`
+}
+
+// readCheckpoint returns how many rows a checkpoint file says have been written to spec, or 0 if there's no file.
+func readCheckpoint(path string, spec *DataSpec) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to read checkpoint file: %w", err)
+	}
+	cp := streamCheckpoint{}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0, fmt.Errorf("bad checkpoint file %v: %w", path, err)
+	}
+	if cp.Workbook != spec.Workbook || cp.Worksheet != spec.Worksheet {
+		return 0, fmt.Errorf("checkpoint file %v is for %v in workbook %v, not %v", path, cp.Worksheet, cp.Workbook, spec.String())
+	}
+	return cp.Rows, nil
+}
+
+// writeCheckpoint records progress, replacing the file in one go so it's never half-written.
+func writeCheckpoint(path string, spec *DataSpec, rows int) error {
+	data, err := json.Marshal(streamCheckpoint{Workbook: spec.Workbook, Worksheet: spec.Worksheet, Rows: rows})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// growGrid adds rows and columns to a worksheet as needed, so it's at least rows x cols,
+// updating ws to match.
+func growGrid(ctx context.Context, b Backend, workbook string, ws *sheets.SheetProperties, rows int, cols int) error {
+	if ws.GridProperties == nil {
+		ws.GridProperties = &sheets.GridProperties{}
+	}
+	grid := ws.GridProperties
+	grow := []*sheets.Request{}
+	if int64(rows) > grid.RowCount {
+		grow = append(grow, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+			SheetId: ws.SheetId, Dimension: "ROWS", Length: int64(rows) - grid.RowCount,
+		}})
+	}
+	if int64(cols) > grid.ColumnCount {
+		grow = append(grow, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+			SheetId: ws.SheetId, Dimension: "COLUMNS", Length: int64(cols) - grid.ColumnCount,
+		}})
+	}
+	if len(grow) == 0 {
+		return nil
+	}
+	if _, err := b.BatchUpdate(ctx, workbook, grow); err != nil {
+		return fmt.Errorf("unable to resize worksheet %v: %w", ws.Title, err)
+	}
+	grid.RowCount = max(grid.RowCount, int64(rows))
+	grid.ColumnCount = max(grid.ColumnCount, int64(cols))
+	return nil
+}
+
+// WriteStreamToWorksheet replaces the contents of a worksheet with rows read from r, writing them a chunk
+// at a time, so the whole input never needs to be in memory. The worksheet's grid is grown to fit as needed.
+// opts may be nil.
+func WriteStreamToWorksheet(ctx context.Context, b Backend, spec *DataSpec, r io.Reader, protect bool, force bool, opts *StreamOptions) error {
+	c := clientWithBackend(b)
+	c.ProtectWorksheets = c.ProtectWorksheets || protect
+	return c.WriteStreamToWorksheet(ctx, spec, r, force, opts)
+}
+
+func (c *Client) WriteStreamToWorksheet(ctx context.Context, spec *DataSpec, r io.Reader, force bool, opts *StreamOptions) error {
+	if opts == nil {
+		opts = &StreamOptions{}
+	}
+	if !spec.IsWorksheet() {
+		return fmt.Errorf("not a worksheet: %v", spec.String())
+	}
+	format := opts.Format
+	if format == "" {
+		format = CsvFormat
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultWriteChunkSize
+	}
+
+	vr, err := NewValueReader(r, format)
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	if opts.CheckpointFile != "" {
+		written, err = readCheckpoint(opts.CheckpointFile, spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	if written == 0 {
+		if err := c.ClearWorksheet(ctx, spec, force); err != nil {
+			return err
+		}
+	} else {
+		// The worksheet was cleared when we started, so skip the rows that have been written since.
+		if c.ProtectWorksheets && !force {
+			return fmt.Errorf("protection prevents writing to (%v): %w", spec.String(), ErrProtected)
+		}
+		for skipped := 0; skipped < written; {
+			rows, err := vr.ReadRows(min(chunkSize, written-skipped))
+			if err == io.EOF {
+				return fmt.Errorf("checkpoint says %d rows were written, but the input only has %d", written, skipped)
+			}
+			if err != nil {
+				return err
+			}
+			skipped += len(rows)
+		}
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return err
+	}
+	ws, err := FindWorksheet(ctx, b, spec.Workbook, spec.Worksheet)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rows, err := vr.ReadRows(chunkSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		width := 0
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		first, last := written+1, written+len(rows)
+
+		if err := growGrid(ctx, b, spec.Workbook, ws, last, width); err != nil {
+			return err
+		}
+
+		chunk := &DataSpec{Workbook: spec.Workbook, Worksheet: spec.Worksheet, Range: DataRange{StartRow: first, StartCol: 1, EndRow: last, EndCol: width}}
+		if err := b.UpdateValues(ctx, spec.Workbook, chunk.GetInSheetDataSpec(), valueRangeForWrite(rows, &opts.WriteOptions), opts.InputOption); err != nil {
+			return fmt.Errorf("unable to write rows %d-%d of %v: %w", first, last, spec.String(), err)
+		}
+		written = last
+
+		if opts.CheckpointFile != "" {
+			if err := writeCheckpoint(opts.CheckpointFile, spec, written); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.CheckpointFile != "" {
+		if err := os.Remove(opts.CheckpointFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to remove checkpoint file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// newSmallWorksheet returns a backend with a 2x2 worksheet "ws" in workbook "wb", with some old data in it.
+func newSmallWorksheet(t *testing.T) *MemoryBackend {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb")
+	_, err := m.BatchUpdate(context.Background(), "wb", []*sheets.Request{
+		{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{
+			Title:          "ws",
+			GridProperties: &sheets.GridProperties{RowCount: 2, ColumnCount: 2},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("BatchUpdate() error = %v", err)
+	}
+	m.SetValues("wb", "ws", [][]interface{}{{"old", "old"}, {"old", "old"}})
+	return m
+}
+
+// failingBackend fails UpdateValues once it's been called failAfter times.
+type failingBackend struct {
+	*MemoryBackend
+	failAfter int
+	updates   int
+}
+
+func (f *failingBackend) UpdateValues(ctx context.Context, workbook string, rng string, values *sheets.ValueRange, input InputOption) error {
+	if f.updates >= f.failAfter {
+		return errors.New("connection reset")
+	}
+	f.updates++
+	return f.MemoryBackend.UpdateValues(ctx, workbook, rng, values, input)
+}
+
+func TestWriteStreamToWorksheet(t *testing.T) {
+	m := newSmallWorksheet(t)
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	input := "a,1\nb,2,x\nc\nd,4\ne,5\n"
+
+	err := WriteStreamToWorksheet(context.Background(), m, spec, strings.NewReader(input), false, false, &StreamOptions{ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("WriteStreamToWorksheet() error = %v", err)
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "ws", &ReadOptions{ValueRender: UnformattedRender})
+	want := [][]interface{}{{"a", 1.0}, {"b", 2.0, "x"}, {"c"}, {"d", 4.0}, {"e", 5.0}}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after WriteStreamToWorksheet() = %#v, want %#v", got.Values, want)
+	}
+	ws, _ := FindWorksheet(context.Background(), m, "wb", "ws")
+	if ws.GridProperties.RowCount != 5 || ws.GridProperties.ColumnCount != 3 {
+		t.Errorf("after WriteStreamToWorksheet() grid = %vx%v, want 5x3", ws.GridProperties.RowCount, ws.GridProperties.ColumnCount)
+	}
+
+	if err := WriteStreamToWorksheet(context.Background(), m, spec, strings.NewReader(input), true, false, nil); !errors.Is(err, ErrProtected) {
+		t.Errorf("WriteStreamToWorksheet() to protected worksheet error = %v, want %v", err, ErrProtected)
+	}
+	if err := WriteStreamToWorksheet(context.Background(), m, spec, strings.NewReader(input), false, false, &StreamOptions{Format: JsonFormat}); err == nil {
+		t.Errorf("WriteStreamToWorksheet() of json error = nil, want error")
+	}
+}
+
+func TestWriteStreamToWorksheet_Resume(t *testing.T) {
+	m := newSmallWorksheet(t)
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	input := "a\nb\nc\nd\ne\n"
+	opts := &StreamOptions{ChunkSize: 2, CheckpointFile: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	// The third chunk fails, leaving the first two written.
+	f := &failingBackend{MemoryBackend: m, failAfter: 2}
+	if err := WriteStreamToWorksheet(context.Background(), f, spec, strings.NewReader(input), false, false, opts); err == nil {
+		t.Fatalf("WriteStreamToWorksheet() error = nil, want error")
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+	if want := [][]interface{}{{"a"}, {"b"}, {"c"}, {"d"}}; !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after failed WriteStreamToWorksheet() = %#v, want %#v", got.Values, want)
+	}
+
+	// Something else writes to the worksheet meanwhile, which resuming should leave alone.
+	m.UpdateValues(context.Background(), "wb", "ws!B1", &sheets.ValueRange{Values: [][]interface{}{{"keep"}}}, RawInput)
+
+	f.failAfter = 100
+	if err := WriteStreamToWorksheet(context.Background(), f, spec, strings.NewReader(input), false, false, opts); err != nil {
+		t.Fatalf("resumed WriteStreamToWorksheet() error = %v", err)
+	}
+	if f.updates != 3 {
+		t.Errorf("WriteStreamToWorksheet() made %d writes in all, want 3", f.updates)
+	}
+	got, _ = m.GetValues(context.Background(), "wb", "ws", nil)
+	if want := [][]interface{}{{"a", "keep"}, {"b"}, {"c"}, {"d"}, {"e"}}; !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after resumed WriteStreamToWorksheet() = %#v, want %#v", got.Values, want)
+	}
+	if _, err := os.Stat(opts.CheckpointFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint file still exists after WriteStreamToWorksheet() (%v)", err)
+	}
+}
+
+func TestWriteStreamToWorksheet_BadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint string
+		input      string
+	}{
+		{
+			name:       "OtherWorksheet",
+			checkpoint: `{"workbook":"wb","worksheet":"other","rows":2}`,
+			input:      "a\nb\nc\n",
+		},
+		{
+			name:       "InputTooShort",
+			checkpoint: `{"workbook":"wb","worksheet":"ws","rows":5}`,
+			input:      "a\nb\nc\n",
+		},
+		{
+			name:       "Corrupt",
+			checkpoint: `{"workbook":`,
+			input:      "a\nb\nc\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newSmallWorksheet(t)
+			spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			if err := os.WriteFile(path, []byte(tt.checkpoint), 0600); err != nil {
+				t.Fatal(err)
+			}
+			err := WriteStreamToWorksheet(context.Background(), m, spec, strings.NewReader(tt.input), false, false, &StreamOptions{CheckpointFile: path})
+			if err == nil {
+				t.Errorf("WriteStreamToWorksheet() error = nil, want error")
+			}
+			got, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+			if want := [][]interface{}{{"old", "old"}, {"old", "old"}}; !reflect.DeepEqual(got.Values, want) {
+				t.Errorf("after WriteStreamToWorksheet() = %#v, want %#v", got.Values, want)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package sheet
 import (
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"testing"
 
@@ -44,12 +43,3 @@ func SetupTempConfig(t *testing.T, cfname string) {
 		t.Errorf("Error reading test config %v", err)
 	}
 }
-
-func RangeFromString(s string) DataRange {
-	ret := DataRange{}
-	_, err := ret.FromString(s)
-	if err != nil {
-		log.Fatalf("error parsing range %v :  %v", s, err)
-	}
-	return ret
-}
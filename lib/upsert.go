@@ -0,0 +1,68 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// UpsertRows updates a worksheet whose first row is a header from data, whose first row is also a header,
+// matching rows by their value in the key column. Rows that have changed are updated in place (only the cells
+// that differ are written), and rows with new keys are added after the last row. The header of data can have
+// its columns in any order, or leave some out, but they must all be in the worksheet's header.
+// With prune, rows whose keys aren't in data are deleted. Rows of the worksheet with a blank key (such as
+// blank rows) are left alone, even with prune. The deletions are made first, in one request, then the
+// rest are written in another: if that fails, the error says how many rows were already deleted. opts may be nil.
+func UpsertRows(ctx context.Context, b Backend, spec *DataSpec, key string, data [][]string, prune bool, opts *PatchOptions) (*UpsertResult, error) {
+	return clientWithBackend(b).UpsertRows(ctx, spec, key, data, prune, opts)
+}
+
+func (c *Client) UpsertRows(ctx context.Context, spec *DataSpec, key string, data [][]string, prune bool, opts *PatchOptions) (*UpsertResult, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no header row in data")
+	}
+	header := data[0]
+	if _, err := keyColumn(header, key); err != nil {
+		return nil, err
+	}
+
+	rows := []map[string]string{}
+	for i, values := range data[1:] {
+		if len(values) > len(header) {
+			return nil, fmt.Errorf("row %d has %d columns, but the header only has %d", i+2, len(values), len(header))
+		}
+		row := map[string]string{}
+		for j, h := range header {
+			row[h] = cellAt(data, i+1, j)
+		}
+		rows = append(rows, row)
+	}
+	return c.upsertRows(ctx, spec, key, rows, prune, opts)
+}
+
+// deleteRows deletes rows (by 0-based index) from a worksheet in one request, merging adjacent rows.
+func deleteRows(ctx context.Context, b Backend, spec *DataSpec, rows []int) error {
+	ws, err := FindWorksheet(ctx, b, spec.Workbook, spec.Worksheet)
+	if err != nil {
+		return err
+	}
+	// Delete from the bottom up, so each deletion doesn't move the rows of the ones after it.
+	sort.Sort(sort.Reverse(sort.IntSlice(rows)))
+	reqs := []*sheets.Request{}
+	for i := 0; i < len(rows); {
+		end := rows[i] + 1
+		start := rows[i]
+		for i++; i < len(rows) && rows[i] == start-1; i++ {
+			start--
+		}
+		reqs = append(reqs, &sheets.Request{DeleteDimension: &sheets.DeleteDimensionRequest{
+			Range: &sheets.DimensionRange{SheetId: ws.SheetId, Dimension: "ROWS", StartIndex: int64(start), EndIndex: int64(end)},
+		}})
+	}
+	if _, err := b.BatchUpdate(ctx, spec.Workbook, reqs); err != nil {
+		return fmt.Errorf("unable to delete rows from %v: %w", spec.String(), err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestUpsertRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       [][]string
+		prune      bool
+		want       [][]string
+		wantResult *UpsertResult
+		wantErr    bool
+	}{
+		{
+			name:       "UpdateAndInsert",
+			data:       [][]string{{"city", "name"}, {"hull", "bob"}, {"york", "alice"}, {"leeds", "carol"}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "hull"}, {"carol", "", "leeds"}},
+			wantResult: &UpsertResult{Updated: 1, Inserted: 1},
+		},
+		{
+			name:       "ShortRows",
+			data:       [][]string{{"name", "age", "city"}, {"alice", "31"}},
+			want:       [][]string{{"name", "age", "city"}, {"alice", "31"}, {"bob", "25", "leeds"}},
+			wantResult: &UpsertResult{Updated: 1},
+		},
+		{
+			name:       "Prune",
+			data:       [][]string{{"name", "age"}, {"bob", "26"}, {"dave", "40"}},
+			prune:      true,
+			want:       [][]string{{"name", "age", "city"}, {"bob", "26", "leeds"}, {"dave", "40"}},
+			wantResult: &UpsertResult{Updated: 1, Inserted: 1, Deleted: 1},
+		},
+		{
+			name:       "PruneEverything",
+			data:       [][]string{{"name"}},
+			prune:      true,
+			want:       [][]string{{"name", "age", "city"}},
+			wantResult: &UpsertResult{Deleted: 2},
+		},
+		{
+			name:    "NoKeyColumn",
+			data:    [][]string{{"age"}, {"30"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:    "EmptyKey",
+			data:    [][]string{{"name", "age"}, {"", "30"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:    "LongRow",
+			data:    [][]string{{"name"}, {"alice", "30"}},
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+		{
+			name:    "NoHeader",
+			want:    [][]string{{"name", "age", "city"}, {"alice", "30", "york"}, {"bob", "25", "leeds"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPeopleBackend()
+			spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+			got, err := UpsertRows(context.Background(), b, spec, "name", tt.data, tt.prune, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpsertRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantResult) {
+				t.Errorf("UpsertRows() = %+v, want %+v", got, tt.wantResult)
+			}
+			values, _ := b.GetValues(context.Background(), "wb", "ws", nil)
+			if !reflect.DeepEqual(StringsFromValueRange(values), tt.want) {
+				t.Errorf("after UpsertRows() = %v, want %v", StringsFromValueRange(values), tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertRows_BlankKeys(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"name", "age"}, {"alice", "30"}, {}, {"bob", "25"}, {"", "note"}})
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	data := [][]string{{"name", "age"}, {"bob", "26"}}
+	got, err := UpsertRows(context.Background(), m, spec, "name", data, true, nil)
+	if err != nil {
+		t.Fatalf("UpsertRows() error = %v", err)
+	}
+	if want := (&UpsertResult{Updated: 1, Deleted: 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UpsertRows() = %+v, want %+v", got, want)
+	}
+	// Rows without a key are left alone, even with prune.
+	values, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+	if want := [][]string{{"name", "age"}, {}, {"bob", "26"}, {"", "note"}}; !reflect.DeepEqual(StringsFromValueRange(values), want) {
+		t.Errorf("after UpsertRows() = %v, want %v", StringsFromValueRange(values), want)
+	}
+}
+
+// failingValuesBackend fails every BatchUpdateValues, like a connection that drops after the deletions.
+type failingValuesBackend struct {
+	*MemoryBackend
+}
+
+func (f *failingValuesBackend) BatchUpdateValues(ctx context.Context, workbook string, data []*sheets.ValueRange, input InputOption) error {
+	return errors.New("connection reset")
+}
+
+func TestUpsertRows_PruneThenFail(t *testing.T) {
+	m := newPeopleBackend()
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	data := [][]string{{"name", "age"}, {"bob", "26"}}
+	_, err := UpsertRows(context.Background(), &failingValuesBackend{MemoryBackend: m.MemoryBackend}, spec, "name", data, true, nil)
+	if err == nil || !strings.Contains(err.Error(), "deleted 1 rows") {
+		t.Fatalf("UpsertRows() error = %v, want one saying a row was deleted", err)
+	}
+	// The deletion was made in one go, and nothing else.
+	values, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+	if want := [][]string{{"name", "age", "city"}, {"bob", "25", "leeds"}}; !reflect.DeepEqual(StringsFromValueRange(values), want) {
+		t.Errorf("after UpsertRows() = %v, want %v", StringsFromValueRange(values), want)
+	}
+}
+
+// commaBackend renders whole numbers with thousands separators unless they're read unformatted, as a sheet
+// with them formatted that way would.
+type commaBackend struct {
+	*MemoryBackend
+}
+
+func (c *commaBackend) GetValues(ctx context.Context, workbook string, rng string, opts *ReadOptions) (*sheets.ValueRange, error) {
+	resp, err := c.MemoryBackend.GetValues(ctx, workbook, rng, opts)
+	if err != nil || opts != nil && opts.ValueRender == UnformattedRender {
+		return resp, err
+	}
+	for _, row := range resp.Values {
+		for i, v := range row {
+			if s, ok := v.(string); ok && len(s) > 3 && strings.Trim(s, "0123456789") == "" {
+				row[i] = s[:len(s)-3] + "," + s[len(s)-3:]
+			}
+		}
+	}
+	return resp, nil
+}
+
+func TestUpsertRows_Formatted(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"name", "stock"}, {"alice", "1000"}, {"bob", "2000"}})
+	b := &commaBackend{MemoryBackend: m}
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	data := [][]string{{"name", "stock"}, {"alice", "1000"}, {"bob", "2500"}}
+	got, err := UpsertRows(context.Background(), b, spec, "name", data, false, nil)
+	if err != nil {
+		t.Fatalf("UpsertRows() error = %v", err)
+	}
+	if want := (&UpsertResult{Updated: 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UpsertRows() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDeleteRows(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"0"}, {"1"}, {"2"}, {"3"}, {"4"}, {"5"}})
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+	if err := deleteRows(context.Background(), m, spec, []int{1, 5, 2, 4}); err != nil {
+		t.Fatalf("deleteRows() error = %v", err)
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+	if want := [][]string{{"0"}, {"3"}}; !reflect.DeepEqual(StringsFromValueRange(got), want) {
+		t.Errorf("after deleteRows() = %v, want %v", StringsFromValueRange(got), want)
+	}
+}
@@ -0,0 +1,135 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SpreadsheetURL is a link to a spreadsheet, as copied from the browser, e.g.
+// https://docs.google.com/spreadsheets/d/<id>/edit#gid=123&range=A1:C4
+type SpreadsheetURL struct {
+	// The workbook ID.
+	ID string
+	// The worksheet's ID, if the link has one (HasGID). Note that the first worksheet is usually 0.
+	GID    int64
+	HasGID bool
+	// The range within the worksheet, if any.
+	Range DataRange
+}
+
+// IsSpreadsheetURL returns true if s looks like a link to a spreadsheet, rather than an ID.
+func IsSpreadsheetURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// ParseSpreadsheetURL parses a link to a spreadsheet. The gid and range can be in the fragment
+// (as the browser has them) or the query.
+func ParseSpreadsheetURL(s string) (*SpreadsheetURL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("bad spreadsheet URL %v: %w", s, err)
+	}
+	if u.Host != "docs.google.com" {
+		return nil, fmt.Errorf("not a spreadsheet URL: %v", s)
+	}
+
+	// /spreadsheets/d/<id>/edit, or /spreadsheets/u/0/d/<id>/edit when signed in to several accounts.
+	ret := &SpreadsheetURL{}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[0] == "spreadsheets" && parts[i] == "d" {
+			ret.ID = parts[i+1]
+			break
+		}
+	}
+	if ret.ID == "" {
+		return nil, fmt.Errorf("no spreadsheet ID in URL: %v", s)
+	}
+
+	params := u.Query()
+	fragment, err := url.ParseQuery(u.Fragment)
+	if err != nil {
+		return nil, fmt.Errorf("bad fragment in spreadsheet URL %v: %w", s, err)
+	}
+	for k, v := range fragment {
+		params[k] = v
+	}
+
+	if gid := params.Get("gid"); gid != "" {
+		ret.GID, err = strconv.ParseInt(gid, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad gid in spreadsheet URL %v: %w", s, err)
+		}
+		ret.HasGID = true
+	}
+	if rng := params.Get("range"); rng != "" {
+		if _, err := ret.Range.FromString(rng); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// workbookFromArg returns the workbook ID in an argument, which is either the ID or a link.
+func workbookFromArg(arg string) (string, error) {
+	if !IsSpreadsheetURL(arg) {
+		return arg, nil
+	}
+	u, err := ParseSpreadsheetURL(arg)
+	if err != nil {
+		return "", err
+	}
+	return u.ID, nil
+}
+
+// ResolveArgsToDataSpec is like ExpandArgsToDataSpec, but a link given on its own also picks up its
+// worksheet (looking up the gid in b) and range, and named ranges are resolved to their worksheet and range.
+func ResolveArgsToDataSpec(ctx context.Context, b Backend, args []string) (*DataSpec, error) {
+	return clientWithBackend(b).ResolveArgsToDataSpec(ctx, args)
+}
+
+// ResolveArgsToDataSpec is like ExpandArgsToDataSpec, but a link given on its own also picks up its
+// worksheet (looking up the gid in c's Backend) and range. If there's a second argument, that's the
+// worksheet instead, and only the ID is taken from the link. Named ranges are resolved too.
+func (c *Client) ResolveArgsToDataSpec(ctx context.Context, args []string) (*DataSpec, error) {
+	if len(args) != 1 || !IsSpreadsheetURL(args[0]) {
+		spec, err := c.ExpandArgsToDataSpec(args)
+		if err != nil || !spec.IsNamedRange() {
+			return spec, err
+		}
+		b, err := c.GetBackend(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ResolveNamedRange(ctx, b, spec)
+	}
+	u, err := ParseSpreadsheetURL(args[0])
+	if err != nil {
+		return nil, err
+	}
+	spec := &DataSpec{Workbook: u.ID}
+	if !u.HasGID && u.Range == (DataRange{}) {
+		return spec, nil
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wb, err := b.GetWorkbook(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i, ws := range wb.Sheets {
+		// A range without a gid is in the first worksheet.
+		if (u.HasGID && ws.Properties.SheetId == u.GID) || (!u.HasGID && i == 0) {
+			spec.Worksheet = ws.Properties.Title
+			spec.Range = u.Range
+			return spec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: gid %v in %v", ErrWorksheetNotFound, u.GID, u.ID)
+}
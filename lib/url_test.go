@@ -0,0 +1,168 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseSpreadsheetURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    *SpreadsheetURL
+		wantErr bool
+	}{
+		{
+			name: "Plain",
+			url:  "https://docs.google.com/spreadsheets/d/abc123/edit",
+			want: &SpreadsheetURL{ID: "abc123"},
+		},
+		{
+			name: "NoTrailingPath",
+			url:  "https://docs.google.com/spreadsheets/d/abc123",
+			want: &SpreadsheetURL{ID: "abc123"},
+		},
+		{
+			name: "Gid",
+			url:  "https://docs.google.com/spreadsheets/d/abc123/edit#gid=0",
+			want: &SpreadsheetURL{ID: "abc123", GID: 0, HasGID: true},
+		},
+		{
+			name: "GidAndRange",
+			url:  "https://docs.google.com/spreadsheets/d/abc123/edit#gid=123&range=A1:C4",
+			want: &SpreadsheetURL{ID: "abc123", GID: 123, HasGID: true, Range: MustRangeFromString("A1:C4")},
+		},
+		{
+			name: "SingleCell",
+			url:  "https://docs.google.com/spreadsheets/d/abc123/edit#gid=123&range=B3",
+			want: &SpreadsheetURL{ID: "abc123", GID: 123, HasGID: true, Range: MustRangeFromString("B3")},
+		},
+		{
+			name: "InQuery",
+			url:  "https://docs.google.com/spreadsheets/d/abc123/edit?gid=7",
+			want: &SpreadsheetURL{ID: "abc123", GID: 7, HasGID: true},
+		},
+		{
+			name: "SeveralAccounts",
+			url:  "https://docs.google.com/spreadsheets/u/1/d/abc123/edit#gid=5",
+			want: &SpreadsheetURL{ID: "abc123", GID: 5, HasGID: true},
+		},
+		{
+			name:    "NotSheets",
+			url:     "https://example.com/spreadsheets/d/abc123/edit",
+			wantErr: true,
+		},
+		{
+			name:    "NoID",
+			url:     "https://docs.google.com/spreadsheets/",
+			wantErr: true,
+		},
+		{
+			name:    "Document",
+			url:     "https://docs.google.com/document/d/abc123/edit",
+			wantErr: true,
+		},
+		{
+			name:    "BadGid",
+			url:     "https://docs.google.com/spreadsheets/d/abc123/edit#gid=first",
+			wantErr: true,
+		},
+		{
+			name:    "BadRange",
+			url:     "https://docs.google.com/spreadsheets/d/abc123/edit#gid=0&range=A:3",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSpreadsheetURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpreadsheetURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpreadsheetURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveArgsToDataSpec(t *testing.T) {
+	b := NewMemoryBackend()
+	b.AddWorkbook("abc123", "first", "My Sheet")
+	wb, err := b.GetWorkbook(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("GetWorkbook() error = %v", err)
+	}
+	gid := wb.Sheets[1].Properties.SheetId
+	base := "https://docs.google.com/spreadsheets/d/abc123/edit"
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    *DataSpec
+		wantErr error
+	}{
+		{
+			name: "Workbook",
+			args: []string{base},
+			want: &DataSpec{Workbook: "abc123"},
+		},
+		{
+			name: "Worksheet",
+			args: []string{base + "#gid=" + strconv.FormatInt(gid, 10)},
+			want: &DataSpec{Workbook: "abc123", Worksheet: "My Sheet"},
+		},
+		{
+			name: "Range",
+			args: []string{base + "#gid=" + strconv.FormatInt(gid, 10) + "&range=A1:C4"},
+			want: &DataSpec{Workbook: "abc123", Worksheet: "My Sheet", Range: MustRangeFromString("A1:C4")},
+		},
+		{
+			name: "RangeWithoutGid",
+			args: []string{base + "#range=B2"},
+			want: &DataSpec{Workbook: "abc123", Worksheet: "first", Range: MustRangeFromString("B2")},
+		},
+		{
+			name: "WorksheetArgument",
+			args: []string{base + "#gid=" + strconv.FormatInt(gid, 10), "other!A1:B2"},
+			want: &DataSpec{Workbook: "abc123", Worksheet: "other", Range: MustRangeFromString("A1:B2")},
+		},
+		{
+			name: "NotAURL",
+			args: []string{"abc123", "first"},
+			want: &DataSpec{Workbook: "abc123", Worksheet: "first"},
+		},
+		{
+			name:    "NoSuchGid",
+			args:    []string{base + "#gid=999999"},
+			wantErr: ErrWorksheetNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Backend: b}
+			got, err := c.ResolveArgsToDataSpec(context.Background(), tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveArgsToDataSpec() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveArgsToDataSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandArgsToDataSpec_URL(t *testing.T) {
+	// Without a backend, only the ID is taken from the link.
+	c := &Client{}
+	got, err := c.ExpandArgsToDataSpec([]string{"https://docs.google.com/spreadsheets/d/abc123/edit#gid=5&range=A1:B2"})
+	if err != nil {
+		t.Fatalf("ExpandArgsToDataSpec() error = %v", err)
+	}
+	if want := (&DataSpec{Workbook: "abc123"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandArgsToDataSpec() = %v, want %v", got, want)
+	}
+}
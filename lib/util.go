@@ -1,33 +1,110 @@
 package sheet
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
-	"github.com/spf13/viper"
 	"google.golang.org/api/sheets/v4"
 )
 
-func ClearWorksheet(srv *sheets.Service, spec *DataSpec, protect bool, force bool) error {
-	if (protect || viper.GetBool("protect-worksheets")) && !force {
-		return fmt.Errorf("protection prevents clearing of: (%v)", spec.String())
+// Implement an enum-a-like for the --input-option flag
+type InputOption string
+
+const (
+	// Values are stored exactly as given -- nothing is parsed as a formula, number or date.
+	RawInput InputOption = "raw"
+	// Values are parsed as if typed into the sheet, so "=A1+1" becomes a formula.
+	UserEnteredInput InputOption = "user-entered"
+)
+
+func (o *InputOption) String() string { return string(*o) }
+func (o *InputOption) Type() string   { return "InputOption" }
+func (o *InputOption) Set(v string) error {
+	switch v {
+	case "raw", "user-entered":
+		*o = InputOption(v)
+		return nil
+	default:
+		return errors.New("invalid InputOption. Allowed [raw|user-entered]")
+	}
+}
+
+// The API's ValueInputOption for this input option.
+func (o *InputOption) apiOption() string {
+	if *o == RawInput {
+		return "RAW"
+	}
+	return "USER_ENTERED"
+}
+
+// WriteOptions controls how values are interpreted when written to a sheet.
+// The zero value writes values as if typed in by a user.
+type WriteOptions struct {
+	InputOption InputOption
+	// Escape any cell that would be interpreted as a formula (see SanitizeFormula), so that untrusted
+	// input can't inject formulas. Only needed with user-entered input, since raw input is never parsed.
+	SanitizeFormulas bool
+}
+
+// Cells starting with these may be interpreted as formulas by sheets (or by whatever later opens an export).
+const formulaPrefixes = "=+-@\t\r"
+
+// SanitizeFormula escapes s with a leading apostrophe if it might be interpreted as a formula,
+// so sheets stores it as text. Numbers (e.g. "-5") are left alone.
+func SanitizeFormula(s string) string {
+	if s == "" || !strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return s
+	}
+	if ParseCell(s).Kind == NumberCell {
+		return s
+	}
+	return "'" + s
+}
+
+// ClearWorksheet clears the worksheet in spec, unless it's protected (by protect, or the
+// protect-worksheets config item) and force is false.
+func ClearWorksheet(ctx context.Context, b Backend, spec *DataSpec, protect bool, force bool) error {
+	c := clientWithBackend(b)
+	c.ProtectWorksheets = c.ProtectWorksheets || protect
+	return c.ClearWorksheet(ctx, spec, force)
+}
+
+func (c *Client) ClearWorksheet(ctx context.Context, spec *DataSpec, force bool) error {
+	if c.ProtectWorksheets && !force {
+		return fmt.Errorf("protection prevents clearing of (%v): %w", spec.String(), ErrProtected)
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return err
 	}
 
-	_, err := srv.Spreadsheets.Values.Clear(spec.Workbook, spec.GetInSheetDataSpec(), &sheets.ClearValuesRequest{}).Do()
+	err = b.ClearValues(ctx, spec.Workbook, spec.GetInSheetDataSpec())
 
 	if err != nil {
-		return fmt.Errorf("unable to clear worksheet (%v): %v", spec, err)
+		return fmt.Errorf("unable to clear worksheet (%v): %w", spec, err)
 	}
 
 	return nil
 }
 
-func ClearRange(srv *sheets.Service, spec *DataSpec) error {
+func ClearRange(ctx context.Context, b Backend, spec *DataSpec) error {
+	return clientWithBackend(b).ClearRange(ctx, spec)
+}
+
+func (c *Client) ClearRange(ctx context.Context, spec *DataSpec) error {
 	if !spec.IsRange() {
 		return fmt.Errorf("not a range: %v", spec.String())
 	}
-	_, err := srv.Spreadsheets.Values.Clear(spec.Workbook, spec.GetInSheetDataSpec(), &sheets.ClearValuesRequest{}).Do()
+	b, err := c.GetBackend(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to clear range: %v", err)
+		return err
+	}
+	err = b.ClearValues(ctx, spec.Workbook, spec.GetInSheetDataSpec())
+	if err != nil {
+		return fmt.Errorf("unable to clear range: %w", err)
 	}
 	return nil
 }
@@ -35,41 +112,92 @@ func ClearRange(srv *sheets.Service, spec *DataSpec) error {
 func checkDataFitsInRange(spec *DataSpec, data [][]string) error {
 	rcols, rrows := spec.Range.SizeXY()
 
-	if len(data) > rrows {
-		return fmt.Errorf("data overflow: %d rows in range, %d in data", rrows, len(data))
+	dcols := 0
+	for _, row := range data {
+		dcols = max(dcols, len(row))
 	}
 
-	if len(data[0]) > rcols {
-		return fmt.Errorf("data overflow: %d columns in range, %d in data", rcols, len(data[0]))
+	if len(data) > rrows || dcols > rcols {
+		return &DataOverflowError{RangeRows: rrows, RangeCols: rcols, DataRows: len(data), DataCols: dcols}
 	}
 
 	return nil
 }
 
+// CellsFromStrings infers typed Cells from text input.
+func CellsFromStrings(data [][]string) [][]Cell {
+	ret := make([][]Cell, len(data))
+	for i, row := range data {
+		ret[i] = make([]Cell, len(row))
+		for j, cell := range row {
+			ret[i][j] = ParseCell(cell)
+		}
+	}
+	return ret
+}
+
 func valueRangeFromStrings(data [][]string) *sheets.ValueRange {
-	sheet_values := make([][]interface{}, len(data))
+	return valueRangeFromCells(CellsFromStrings(data))
+}
+
+// valueRangeForWrite converts data for writing with opts. Numbers and booleans are sent typed, and
+// everything else as text. With RAW input, nothing is sanitized, since formulas aren't evaluated anyway.
+func valueRangeForWrite(data [][]string, opts *WriteOptions) *sheets.ValueRange {
+	if !opts.SanitizeFormulas || opts.InputOption == RawInput {
+		return valueRangeFromStrings(data)
+	}
+	cells := make([][]Cell, len(data))
 	for i, row := range data {
-		sheet_values[i] = make([]interface{}, len(row))
+		cells[i] = make([]Cell, len(row))
 		for j, cell := range row {
-			sheet_values[i][j] = cell
+			if sanitized := SanitizeFormula(cell); sanitized != cell {
+				cells[i][j] = NewStringCell(sanitized)
+			} else {
+				cells[i][j] = ParseCell(cell)
+			}
 		}
 	}
-	return &sheets.ValueRange{Values: sheet_values}
+	return valueRangeFromCells(cells)
 }
 
-func WriteDataToWorksheet(srv *sheets.Service, spec *DataSpec, data [][]string, protect bool, force bool) error {
-	err := ClearWorksheet(srv, spec, protect, force)
+// WriteDataToWorksheet replaces the contents of a worksheet with data.
+// opts may be nil, in which case values are written as if typed in by a user.
+// For more data than fits comfortably in memory (or in one request), see WriteStreamToWorksheet.
+func WriteDataToWorksheet(ctx context.Context, b Backend, spec *DataSpec, data [][]string, protect bool, force bool, opts *WriteOptions) error {
+	c := clientWithBackend(b)
+	c.ProtectWorksheets = c.ProtectWorksheets || protect
+	return c.WriteDataToWorksheet(ctx, spec, data, force, opts)
+}
+
+func (c *Client) WriteDataToWorksheet(ctx context.Context, spec *DataSpec, data [][]string, force bool, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	err := c.ClearWorksheet(ctx, spec, force)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = srv.Spreadsheets.Values.Update(spec.Workbook, spec.GetInSheetDataSpec(), valueRangeFromStrings(data)).ValueInputOption("USER_ENTERED").Do()
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return err
+	}
+
+	return b.UpdateValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), valueRangeForWrite(data, opts), opts.InputOption)
+}
 
-	return err
+// WriteDataToRange replaces the contents of a range with data, which must fit in the range.
+// opts may be nil, in which case values are written as if typed in by a user.
+func WriteDataToRange(ctx context.Context, b Backend, spec *DataSpec, data [][]string, opts *WriteOptions) error {
+	return clientWithBackend(b).WriteDataToRange(ctx, spec, data, opts)
 }
 
-func WriteDataToRange(srv *sheets.Service, spec *DataSpec, data [][]string) error {
+func (c *Client) WriteDataToRange(ctx context.Context, spec *DataSpec, data [][]string, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
 
 	err := checkDataFitsInRange(spec, data)
 
@@ -77,13 +205,46 @@ func WriteDataToRange(srv *sheets.Service, spec *DataSpec, data [][]string) erro
 		return err
 	}
 
-	err = ClearRange(srv, spec)
+	err = c.ClearRange(ctx, spec)
+
+	if err != nil {
+		return err
+	}
 
+	b, err := c.GetBackend(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = srv.Spreadsheets.Values.Update(spec.Workbook, spec.GetInSheetDataSpec(), valueRangeFromStrings(data)).ValueInputOption("USER_ENTERED").Do()
+	return b.UpdateValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), valueRangeForWrite(data, opts), opts.InputOption)
+}
+
+// AppendData appends rows after the last row of data in the worksheet or range, inserting new rows
+// as needed. It returns the range that was written to.
+// opts may be nil, in which case values are written as if typed in by a user.
+func AppendData(ctx context.Context, b Backend, spec *DataSpec, data [][]string, opts *WriteOptions) (string, error) {
+	return clientWithBackend(b).AppendData(ctx, spec, data, opts)
+}
+
+func (c *Client) AppendData(ctx context.Context, spec *DataSpec, data [][]string, opts *WriteOptions) (string, error) {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	if spec.IsWorkbook() {
+		return "", fmt.Errorf("cannot append to a workbook: %v", spec.String())
+	}
+
+	b, err := c.GetBackend(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	updated, err := b.AppendValues(ctx, spec.Workbook, spec.GetInSheetDataSpec(), valueRangeForWrite(data, opts), opts.InputOption)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to append data (%v): %w", spec, err)
+	}
 
-	return err
+	return updated, nil
 }
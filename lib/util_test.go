@@ -1,14 +1,15 @@
 package sheet
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
-
-	"google.golang.org/api/sheets/v4"
 )
 
 func TestClearWorksheet(t *testing.T) {
 	type args struct {
-		srv     *sheets.Service
+		b       Backend
 		spec    *DataSpec
 		protect bool
 		force   bool
@@ -20,13 +21,13 @@ func TestClearWorksheet(t *testing.T) {
 	}{
 		{
 			name:    "TestProtection",
-			args:    args{srv: &sheets.Service{}, spec: &DataSpec{Worksheet: "doot"}, protect: true, force: false},
+			args:    args{b: NewMemoryBackend(), spec: &DataSpec{Worksheet: "doot"}, protect: true, force: false},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ClearWorksheet(tt.args.srv, tt.args.spec, tt.args.protect, tt.args.force); (err != nil) != tt.wantErr {
+			if err := ClearWorksheet(context.Background(), tt.args.b, tt.args.spec, tt.args.protect, tt.args.force); (err != nil) != tt.wantErr {
 				t.Errorf("ClearWorksheet() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -68,6 +69,14 @@ func Test_valueRangeFromStrings(t *testing.T) {
 	}
 }
 
+func Test_valueRangeFromStringsTyped(t *testing.T) {
+	got := valueRangeFromStrings([][]string{{"a", "1.5", "TRUE", "", "=A1", "007"}})
+	want := [][]interface{}{{"a", 1.5, true, "", "=A1", "007"}}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("valueRangeFromStrings() = %#v, want %#v", got.Values, want)
+	}
+}
+
 func TestDataRange_IsFixedSize(t *testing.T) {
 	tests := []struct {
 		name string
@@ -76,17 +85,17 @@ func TestDataRange_IsFixedSize(t *testing.T) {
 	}{
 		{
 			name: "FixedRange",
-			rng:  RangeFromString("A1:B2"),
+			rng:  MustRangeFromString("A1:B2"),
 			want: true,
 		},
 		{
 			name: "WholeColumns",
-			rng:  RangeFromString("A:B"),
+			rng:  MustRangeFromString("A:B"),
 			want: false,
 		},
 		{
 			name: "SingleCell",
-			rng:  RangeFromString("A1:A1"),
+			rng:  MustRangeFromString("A1:A1"),
 			want: true,
 		},
 	}
@@ -111,35 +120,227 @@ func Test_checkDataFitsInRange(t *testing.T) {
 	}{
 		{
 			name:    "TestDataOverflowCols",
-			args:    args{spec: &DataSpec{Range: RangeFromString("A1:B2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:B2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
 			wantErr: true,
 		},
 		{
 			name:    "TestDataOverflowRows",
-			args:    args{spec: &DataSpec{Range: RangeFromString("A1:D1")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:D1")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
 			wantErr: true,
 		},
 		{
 			name:    "TestExactFit",
-			args:    args{spec: &DataSpec{Range: RangeFromString("A1:C2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:C2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
 			wantErr: false,
 		},
 		{
 			name:    "TestFewerCols",
-			args:    args{spec: &DataSpec{Range: RangeFromString("A1:E2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:E2")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
 			wantErr: false,
 		},
 		{
 			name:    "TestFewerRows",
-			args:    args{spec: &DataSpec{Range: RangeFromString("A1:C3")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:C3")}, data: [][]string{{"1", "2", "3"}, {"4", "5", "6"}}},
+			wantErr: false,
+		},
+		{
+			name:    "TestLaterRowOverflowCols",
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:B2")}, data: [][]string{{"1"}, {"4", "5", "6"}}},
+			wantErr: true,
+		},
+		{
+			name:    "TestNoData",
+			args:    args{spec: &DataSpec{Range: MustRangeFromString("A1:B2")}, data: [][]string{}},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := checkDataFitsInRange(tt.args.spec, tt.args.data); (err != nil) != tt.wantErr {
+			err := checkDataFitsInRange(tt.args.spec, tt.args.data)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("checkDataFitsInRange() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantErr && !errors.Is(err, ErrDataOverflow) {
+				t.Errorf("checkDataFitsInRange() error = %v, want ErrDataOverflow", err)
+			}
 		})
 	}
 }
+
+func TestInputOption_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		want    string
+		wantErr bool
+	}{
+		{name: "Raw", v: "raw", want: "RAW"},
+		{name: "UserEntered", v: "user-entered", want: "USER_ENTERED"},
+		{name: "Unknown", v: "RAW", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o InputOption
+			if err := o.Set(tt.v); (err != nil) != tt.wantErr {
+				t.Errorf("InputOption.Set() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := o.apiOption(); got != tt.want {
+				t.Errorf("InputOption.apiOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFormula(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "Empty", s: "", want: ""},
+		{name: "Plain", s: "hello", want: "hello"},
+		{name: "Formula", s: "=HYPERLINK(\"http://evil\")", want: "'=HYPERLINK(\"http://evil\")"},
+		{name: "Plus", s: "+1+1", want: "'+1+1"},
+		{name: "Minus", s: "-1+cmd|' /C calc'!A0", want: "'-1+cmd|' /C calc'!A0"},
+		{name: "At", s: "@SUM(A1)", want: "'@SUM(A1)"},
+		{name: "Tab", s: "\t=1", want: "'\t=1"},
+		{name: "NegativeNumber", s: "-5", want: "-5"},
+		{name: "NegativeFraction", s: "-0.5", want: "-0.5"},
+		{name: "EqualsLater", s: "a=b", want: "a=b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFormula(tt.s); got != tt.want {
+				t.Errorf("SanitizeFormula() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_valueRangeForWrite(t *testing.T) {
+	data := [][]string{{"=1+1", "-5", "x", "@a", "007", "TRUE"}}
+	tests := []struct {
+		name string
+		opts *WriteOptions
+		want [][]interface{}
+	}{
+		{
+			name: "Default",
+			opts: &WriteOptions{},
+			want: [][]interface{}{{"=1+1", float64(-5), "x", "@a", "007", true}},
+		},
+		{
+			name: "Sanitized",
+			opts: &WriteOptions{SanitizeFormulas: true},
+			want: [][]interface{}{{"'=1+1", float64(-5), "x", "'@a", "007", true}},
+		},
+		{
+			name: "RawIsNeverSanitized",
+			opts: &WriteOptions{InputOption: RawInput, SanitizeFormulas: true},
+			want: [][]interface{}{{"=1+1", float64(-5), "x", "@a", "007", true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := valueRangeForWrite(data, tt.opts); !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("valueRangeForWrite() = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteDataToWorksheet(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"old", "old"}, {"old", "old"}, {"old"}})
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws"}
+
+	if err := WriteDataToWorksheet(context.Background(), m, spec, [][]string{{"a", "1"}, {"b"}}, false, false, nil); err != nil {
+		t.Fatalf("WriteDataToWorksheet() error = %v", err)
+	}
+	got, _ := m.GetValues(context.Background(), "wb", "ws", &ReadOptions{ValueRender: UnformattedRender})
+	want := [][]interface{}{{"a", 1.0}, {"b"}}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after WriteDataToWorksheet() = %#v, want %#v", got.Values, want)
+	}
+
+	if err := WriteDataToWorksheet(context.Background(), m, spec, [][]string{{"c"}}, true, false, nil); err == nil {
+		t.Errorf("WriteDataToWorksheet() to protected worksheet error = nil, want error")
+	}
+}
+
+func TestWriteDataToRange_Raw(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	spec := &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString("A1:D1")}
+	data := [][]string{{"42", "TRUE", "007", "=1+1"}}
+	if err := WriteDataToRange(context.Background(), m, spec, data, &WriteOptions{InputOption: RawInput}); err != nil {
+		t.Fatalf("WriteDataToRange() error = %v", err)
+	}
+	// Numbers and booleans keep their types, and the rest is text, formulas included.
+	got, _ := m.GetValues(context.Background(), "wb", "ws", &ReadOptions{ValueRender: UnformattedRender})
+	if want := [][]interface{}{{float64(42), true, "007", "=1+1"}}; !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("after WriteDataToRange() = %#v, want %#v", got.Values, want)
+	}
+}
+
+func TestWriteDataToRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		rng     string
+		data    [][]string
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{
+			name: "ClearsRestOfRange",
+			rng:  "B1:C2",
+			data: [][]string{{"x"}},
+			want: [][]interface{}{{"old", "x", "", "old"}, {"old", "", "", "old"}},
+		},
+		{
+			name:    "Overflow",
+			rng:     "B1:C2",
+			data:    [][]string{{"x", "y", "z"}},
+			want:    [][]interface{}{{"old", "old", "old", "old"}, {"old", "old", "old", "old"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryBackend()
+			m.AddWorkbook("wb", "ws")
+			m.SetValues("wb", "ws", [][]interface{}{{"old", "old", "old", "old"}, {"old", "old", "old", "old"}})
+			spec := &DataSpec{Workbook: "wb", Worksheet: "ws", Range: MustRangeFromString(tt.rng)}
+			if err := WriteDataToRange(context.Background(), m, spec, tt.data, nil); (err != nil) != tt.wantErr {
+				t.Errorf("WriteDataToRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, _ := m.GetValues(context.Background(), "wb", "ws", nil)
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("after WriteDataToRange() = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendData(t *testing.T) {
+	m := NewMemoryBackend()
+	m.AddWorkbook("wb", "ws")
+	m.SetValues("wb", "ws", [][]interface{}{{"a", "b"}})
+
+	got, err := AppendData(context.Background(), m, &DataSpec{Workbook: "wb", Worksheet: "ws"}, [][]string{{"c", "d"}, {"e", "f"}}, nil)
+	if err != nil {
+		t.Fatalf("AppendData() error = %v", err)
+	}
+	if got != "ws!A2:B3" {
+		t.Errorf("AppendData() = %v, want ws!A2:B3", got)
+	}
+
+	if _, err := AppendData(context.Background(), m, &DataSpec{Workbook: "wb"}, [][]string{{"c"}}, nil); err == nil {
+		t.Errorf("AppendData() to workbook error = nil, want error")
+	}
+}